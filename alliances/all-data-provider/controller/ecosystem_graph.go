@@ -79,6 +79,17 @@ func (egc *EcosystemGraphController) RefreshLockEcosystemGraph(c *fiber.Ctx) err
 	return c.JSON(fiber.Map{})
 }
 
+func (egc *EcosystemGraphController) GetLockStatusEcosystemGraph(c *fiber.Ctx) error {
+	lockStatus, err := egc.cacheRepository.GetLockStatus("ecosystem-graph")
+	if err != nil {
+		c.Status(fiber.StatusInternalServerError)
+		return c.JSON(fiber.Map{
+			"error": "Could not get lock status",
+		})
+	}
+	return c.JSON(lockStatus)
+}
+
 func (egc *EcosystemGraphController) DropLockEcosystemGraph(c *fiber.Ctx) error {
 	var dropLockParams model.DropLockEcosystemGraphParams
 	err := json.Unmarshal(c.Body(), &dropLockParams)
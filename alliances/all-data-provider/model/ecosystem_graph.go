@@ -29,3 +29,10 @@ type RefreshLockEcosystemGraphParams struct {
 type DropLockEcosystemGraphParams struct {
 	UserID string `json:"userID"`
 }
+
+// LockStatus tells whether a user is editing the ecosystem graph, and until when
+type LockStatus struct {
+	Locked    bool   `json:"locked"`
+	Owner     string `json:"owner"`
+	ExpiresAt int64  `json:"expiresAt"` // Unix seconds timestamp
+}
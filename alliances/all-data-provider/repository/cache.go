@@ -1,12 +1,14 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/pkg/errors"
 	"github.com/tizianocitro/hood-framework/alliances/all-data-provider/config/db"
+	"github.com/tizianocitro/hood-framework/alliances/all-data-provider/model"
 )
 
 const MAX_LOCK_TIME = time.Minute * 30
@@ -86,3 +88,28 @@ func (r *CacheRepository) DropLock(lockName string, owner string) error {
 	}
 	return nil
 }
+
+// Returns the status of the lock on the resource identified by the lockName argument. Expired locks are reported as not locked.
+func (r *CacheRepository) GetLockStatus(lockName string) (model.LockStatus, error) {
+	var lock struct {
+		Owner     string
+		ExpiresAt int64
+	}
+	err := r.db.GetBuilder(r.db.DB, &lock, r.db.Builder.
+		Select("Owner", "ExpiresAt").
+		From("CSFDP_Locks").
+		Where(sq.Eq{"Key": lockName}))
+	if err == sql.ErrNoRows {
+		return model.LockStatus{}, nil
+	} else if err != nil {
+		return model.LockStatus{}, errors.Wrap(err, fmt.Sprintf("could not get lock %s", lockName))
+	}
+	if lock.ExpiresAt < time.Now().Unix() {
+		return model.LockStatus{}, nil
+	}
+	return model.LockStatus{
+		Locked:    true,
+		Owner:     lock.Owner,
+		ExpiresAt: lock.ExpiresAt,
+	}, nil
+}
@@ -96,6 +96,9 @@ func useEcosystem(basePath fiber.Router, context *config.Context) {
 	ecosystem.Get("/ecosystem_graph", func(c *fiber.Ctx) error {
 		return ecosystemGraphController.GetEcosystemGraph(c)
 	})
+	ecosystem.Get("/ecosystem_graph/lock", func(c *fiber.Ctx) error {
+		return ecosystemGraphController.GetLockStatusEcosystemGraph(c)
+	})
 	ecosystem.Get("/:issueId", func(c *fiber.Ctx) error {
 		return issueController.GetIssue(c)
 	})
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// AuditHandler is the API handler.
+type AuditHandler struct {
+	*ErrorHandler
+	authorizationService *app.AuthorizationService
+}
+
+// NewAuditHandler returns a new audit log api handler
+func NewAuditHandler(router *mux.Router, authorizationService *app.AuthorizationService) *AuditHandler {
+	handler := &AuditHandler{
+		ErrorHandler:         &ErrorHandler{},
+		authorizationService: authorizationService,
+	}
+
+	auditRouter := router.PathPrefix("/audit").Subrouter()
+	auditRouter.HandleFunc("", withContext(handler.getAuditRecords)).Methods(http.MethodGet)
+
+	return handler
+}
+
+func (h *AuditHandler) getAuditRecords(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	perPage, _ := strconv.Atoi(query.Get("per_page"))
+	records, err := h.authorizationService.GetAuditRecords(userID, app.AuditFilterOptions{
+		UserID:  query.Get("user_id"),
+		Action:  query.Get("action"),
+		Page:    page,
+		PerPage: perPage,
+	})
+	if err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			h.PermissionsCheck(w, c.logger, err)
+		} else {
+			h.HandleError(w, c.logger, err)
+		}
+		return
+	}
+	ReturnJSON(w, records, http.StatusOK)
+}
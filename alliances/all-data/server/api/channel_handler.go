@@ -4,12 +4,16 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 
 	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
 )
 
+// Number of elements and users returned by default when getting the most referenced elements
+const defaultTopBacklinksLimit = 10
+
 // ChannelHandler is the API handler.
 type ChannelHandler struct {
 	*ErrorHandler
@@ -30,10 +34,19 @@ func NewChannelHandler(router *mux.Router, channelService *app.ChannelService) *
 
 	channelRouter := router.PathPrefix("/channel/{channelId}").Subrouter()
 	channelRouter.HandleFunc("", withContext(handler.getChannelByID)).Methods(http.MethodGet)
-	channelRouter.HandleFunc("/export", withContext(handler.exportChannel)).Methods(http.MethodPost)
+	channelRouter.HandleFunc("/rename", withContext(handler.renameChannel)).Methods(http.MethodPut)
+	channelRouter.HandleFunc("/move", withContext(handler.moveChannel)).Methods(http.MethodPut)
+	channelRouter.HandleFunc("/unlink", withContext(handler.unlinkChannel)).Methods(http.MethodPost)
+	channelRouter.HandleFunc("/archive", withContext(handler.archiveChannel)).Methods(http.MethodPost)
+	channelRouter.HandleFunc("/restore", withContext(handler.restoreChannel)).Methods(http.MethodPost)
 
 	backlinksRouter := router.PathPrefix("/backlinks").Subrouter()
 	backlinksRouter.HandleFunc("", withContext(handler.getBacklinks)).Methods(http.MethodGet)
+	backlinksRouter.HandleFunc("/organizations/{organizationId}/top", withContext(handler.getTopBacklinks)).Methods(http.MethodGet)
+	backlinksRouter.HandleFunc("/rebuild", withContext(handler.rebuildBacklinks)).Methods(http.MethodPost)
+	backlinksRouter.HandleFunc("/graph", withContext(handler.exportBacklinkGraph)).Methods(http.MethodGet)
+	backlinksRouter.HandleFunc("/posts/{postId}/stances", withContext(handler.getBacklinkStances)).Methods(http.MethodGet)
+	backlinksRouter.HandleFunc("/posts/{postId}/stance", withContext(handler.setBacklinkStance)).Methods(http.MethodPut)
 
 	return handler
 }
@@ -94,6 +107,64 @@ func (h *ChannelHandler) archiveChannels(c *Context, w http.ResponseWriter, r *h
 	ReturnJSON(w, "", http.StatusOK)
 }
 
+func (h *ChannelHandler) renameChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	var params app.RenameChannelParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to decode channel to rename", err)
+		return
+	}
+	result, err := h.channelService.RenameChannel(userID, mux.Vars(r)["channelId"], params)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "channel not found")
+		return
+	}
+	ReturnJSON(w, result, http.StatusOK)
+}
+
+func (h *ChannelHandler) moveChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	var params app.MoveChannelParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to decode channel to move", err)
+		return
+	}
+	result, err := h.channelService.MoveChannel(userID, mux.Vars(r)["channelId"], params)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "channel not found")
+		return
+	}
+	ReturnJSON(w, result, http.StatusOK)
+}
+
+func (h *ChannelHandler) unlinkChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	if err := h.channelService.UnlinkChannel(userID, mux.Vars(r)["channelId"]); err != nil {
+		h.HandleAppError(w, c.logger, err, "channel not found")
+		return
+	}
+	ReturnJSON(w, "", http.StatusOK)
+}
+
+func (h *ChannelHandler) archiveChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	if err := h.channelService.ArchiveChannel(userID, mux.Vars(r)["channelId"]); err != nil {
+		h.HandleAppError(w, c.logger, err, "channel not found")
+		return
+	}
+	ReturnJSON(w, "", http.StatusOK)
+}
+
+func (h *ChannelHandler) restoreChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	result, err := h.channelService.RestoreChannel(userID, mux.Vars(r)["channelId"])
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "channel not found")
+		return
+	}
+	ReturnJSON(w, result, http.StatusOK)
+}
+
 func (h *ChannelHandler) getBacklinks(c *Context, w http.ResponseWriter, r *http.Request) {
 	elementURL := r.URL.Query().Get("elementUrl")
 	userID := r.Header.Get("Mattermost-User-Id")
@@ -110,30 +181,87 @@ func (h *ChannelHandler) getBacklinks(c *Context, w http.ResponseWriter, r *http
 	ReturnJSON(w, backlinks, http.StatusOK)
 }
 
-func (h *ChannelHandler) exportChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+func (h *ChannelHandler) getTopBacklinks(c *Context, w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
-	channelID := vars["channelId"]
-	var params app.ExportChannelParams
-	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to decode export channel data", err)
-		return
+	organizationID := vars["organizationId"]
+	userID := r.Header.Get("Mattermost-User-Id")
+	limit := defaultTopBacklinksLimit
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		parsedLimit, err := strconv.Atoi(limitParam)
+		if err != nil || parsedLimit < 0 {
+			h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "invalid limit", err)
+			return
+		}
+		limit = parsedLimit
 	}
-	stixChannel, err := h.channelService.ExportChannel(channelID, params)
+	topBacklinks, err := h.channelService.GetTopBacklinks(organizationID, userID, limit)
 	if err != nil {
-		if errors.Is(err, app.ErrNotFound) {
-			h.HandleErrorWithCode(w, c.logger, http.StatusNotFound, "channel not found", err)
+		h.HandleError(w, c.logger, err)
+		return
+	}
+
+	ReturnJSON(w, topBacklinks, http.StatusOK)
+}
+
+func (h *ChannelHandler) rebuildBacklinks(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	if err := h.channelService.RebuildBacklinks(userID); err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			h.PermissionsCheck(w, c.logger, err)
+		} else if errors.Is(err, app.ErrAlreadyRunning) {
+			h.HandleErrorWithCode(w, c.logger, http.StatusConflict, "backlinks rebuild already running", err)
 		} else {
 			h.HandleError(w, c.logger, err)
 		}
 		return
 	}
+	ReturnJSON(w, "", http.StatusAccepted)
+}
+
+func (h *ChannelHandler) getBacklinkStances(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	stances, err := h.channelService.GetBacklinkStances(userID, mux.Vars(r)["postId"])
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "post not found")
+		return
+	}
+	ReturnJSON(w, stances, http.StatusOK)
+}
 
-	var exporter app.Exporter
-	if params.Format == "json" {
-		exporter = &app.JSON{}
-		exporter.Export(w, stixChannel)
+func (h *ChannelHandler) setBacklinkStance(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	var params app.SetBacklinkStanceParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to decode backlink stance", err)
 		return
 	}
+	stance, err := h.channelService.SetBacklinkStance(userID, mux.Vars(r)["postId"], params)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "post not found")
+		return
+	}
+	ReturnJSON(w, stance, http.StatusOK)
+}
 
-	h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "missing format", nil)
+func (h *ChannelHandler) exportBacklinkGraph(c *Context, w http.ResponseWriter, r *http.Request) {
+	organizationID := r.URL.Query().Get("organizationId")
+	format := r.URL.Query().Get("format")
+	userID := r.Header.Get("Mattermost-User-Id")
+	exporter, found := app.GetGraphExporter(format)
+	if !found {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unsupported format, use one of graphml, csv or jsonld", nil)
+		return
+	}
+	graph, err := h.channelService.GetBacklinkGraph(organizationID, userID)
+	if err != nil {
+		h.HandleError(w, c.logger, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", exporter.ContentType())
+	w.Header().Set("Content-Disposition", "attachment; filename="+exporter.FileName("backlinks"))
+	w.WriteHeader(http.StatusOK)
+	if err := exporter.Export(w, graph); err != nil {
+		c.logger.WithError(err).Warn("Unable to write backlink graph")
+	}
 }
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/command"
+)
+
+// CommandHandler is the API handler.
+type CommandHandler struct {
+	*ErrorHandler
+	commandService *app.CommandService
+}
+
+// NewCommandHandler returns a new slash commands api handler, serving the dynamic lists of their autocomplete
+func NewCommandHandler(router *mux.Router, commandService *app.CommandService) *CommandHandler {
+	handler := &CommandHandler{
+		ErrorHandler:   &ErrorHandler{},
+		commandService: commandService,
+	}
+
+	router.HandleFunc(command.HoodOrganizationsAutocompletePath, withContext(handler.getOrganizationItems)).Methods(http.MethodGet)
+	router.HandleFunc(command.HoodElementsAutocompletePath, withContext(handler.getElementItems)).Methods(http.MethodGet)
+	router.HandleFunc(command.HoodLinksAutocompletePath, withContext(handler.getLinkItems)).Methods(http.MethodGet)
+
+	return handler
+}
+
+func (h *CommandHandler) getOrganizationItems(c *Context, w http.ResponseWriter, r *http.Request) {
+	items, err := h.commandService.GetOrganizationItems()
+	if err != nil {
+		h.HandleError(w, c.logger, err)
+		return
+	}
+	ReturnJSON(w, items, http.StatusOK)
+}
+
+// The user input is the whole command typed so far, the server filters the items by the argument being typed
+func (h *CommandHandler) getElementItems(c *Context, w http.ResponseWriter, r *http.Request) {
+	userInput := r.URL.Query().Get("user_input")
+	fields := strings.Fields(userInput)
+	argument := ""
+	if len(fields) > 0 {
+		argument = fields[len(fields)-1]
+	}
+	userID := r.Header.Get("Mattermost-User-Id")
+	items, err := h.commandService.GetElementItems(userID, argument)
+	if err != nil {
+		h.HandleError(w, c.logger, err)
+		return
+	}
+	ReturnJSON(w, items, http.StatusOK)
+}
+
+// The user input is the whole command typed so far, while parsed is the part preceding the argument being typed
+func (h *CommandHandler) getLinkItems(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	query := strings.TrimPrefix(r.URL.Query().Get("user_input"), r.URL.Query().Get("parsed"))
+	items, err := h.commandService.GetLinkItems(userID, query)
+	if err != nil {
+		h.HandleError(w, c.logger, err)
+		return
+	}
+	ReturnJSON(w, items, http.StatusOK)
+}
@@ -4,9 +4,12 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
 )
 
 type ErrorHandler struct {
@@ -23,6 +26,25 @@ func (h *ErrorHandler) HandleErrorWithCode(w http.ResponseWriter, logger logrus.
 	HandleErrorWithCode(logger, w, code, publicErrorMsg, internalErr)
 }
 
+// HandleAppError maps the errors returned by the app services to the matching response codes,
+// using the given message for entities not found. Unknown errors are handled by HandleError.
+func (h *ErrorHandler) HandleAppError(w http.ResponseWriter, logger logrus.FieldLogger, err error, notFoundMsg string) {
+	switch {
+	case errors.Is(err, app.ErrForbidden):
+		h.PermissionsCheck(w, logger, err)
+	case errors.Is(err, app.ErrTooManyAttempts):
+		h.HandleErrorWithCode(w, logger, http.StatusTooManyRequests, "too many unauthorized attempts, try again later", err)
+	case errors.Is(err, app.ErrInvalidInput):
+		h.HandleErrorWithCode(w, logger, http.StatusBadRequest, err.Error(), err)
+	case errors.Is(err, app.ErrNotFound):
+		h.HandleErrorWithCode(w, logger, http.StatusNotFound, notFoundMsg, err)
+	case errors.Is(err, app.ErrAlreadyExists), errors.Is(err, app.ErrAlreadyRunning):
+		h.HandleErrorWithCode(w, logger, http.StatusConflict, err.Error(), err)
+	default:
+		h.HandleError(w, logger, err)
+	}
+}
+
 // PermissionsCheck handles the output of a permissions check
 // Automatically does the proper error handling.
 // Returns true if the check passed and false on failure. Correct use is: if !h.PermissionsCheck(w, check) { return }
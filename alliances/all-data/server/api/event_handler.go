@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -50,8 +51,15 @@ func (h *EventHandler) setOrganization(c *Context, w http.ResponseWriter, r *htt
 		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to decode set organization payload", err)
 		return
 	}
-	if err := h.eventService.SetOrganizations(params); err != nil {
-		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to handle set organization", err)
+	actorID := r.Header.Get("Mattermost-User-Id")
+	if err := h.eventService.SetOrganizations(actorID, params); err != nil {
+		if errors.Is(err, app.ErrForbidden) {
+			h.PermissionsCheck(w, c.logger, err)
+		} else if errors.Is(err, app.ErrTooManyAttempts) {
+			h.HandleErrorWithCode(w, c.logger, http.StatusTooManyRequests, "too many unauthorized attempts, try again later", err)
+		} else {
+			h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to handle set organization", err)
+		}
 		return
 	}
 	ReturnJSON(w, "", http.StatusOK)
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// ExportHandler is the API handler.
+type ExportHandler struct {
+	*ErrorHandler
+	exportService *app.ExportService
+}
+
+// NewExportHandler returns a new exports api handler
+func NewExportHandler(router *mux.Router, exportService *app.ExportService) *ExportHandler {
+	handler := &ExportHandler{
+		ErrorHandler:  &ErrorHandler{},
+		exportService: exportService,
+	}
+
+	channelRouter := router.PathPrefix("/channel/{channelId}").Subrouter()
+	channelRouter.HandleFunc("/export", withContext(handler.exportChannel)).Methods(http.MethodPost)
+
+	exportsRouter := router.PathPrefix("/exports").Subrouter()
+	exportsRouter.HandleFunc("/channels/{channelId}", withContext(handler.startExportJob)).Methods(http.MethodPost)
+	exportsRouter.HandleFunc("/sections/{sectionId}", withContext(handler.startSectionExportJob)).Methods(http.MethodPost)
+	exportsRouter.HandleFunc("/organizations/{organizationId}", withContext(handler.startOrganizationExportJob)).Methods(http.MethodPost)
+	exportsRouter.HandleFunc("/{jobId}", withContext(handler.getExportJob)).Methods(http.MethodGet)
+	exportsRouter.HandleFunc("/{jobId}/download", withContext(handler.downloadExportJob)).Methods(http.MethodGet)
+
+	return handler
+}
+
+// Streams the export directly in the response, which suits small channels
+func (h *ExportHandler) exportChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	channelID := mux.Vars(r)["channelId"]
+	userID := r.Header.Get("Mattermost-User-Id")
+	params, ok := h.decodeExportParams(c, w, r)
+	if !ok {
+		return
+	}
+	channelExport, exporter, err := h.exportService.NewChannelExport(channelID, userID, params)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", exporter.ContentType())
+	w.Header().Set("Content-Disposition", "attachment; filename="+exporter.FileName(channelExport.Channel.Name))
+	w.WriteHeader(http.StatusOK)
+	if err := h.exportService.WriteChannelExport(w, exporter, channelExport, nil); err != nil {
+		c.logger.WithError(err).Warn("Unable to write channel export")
+	}
+}
+
+func (h *ExportHandler) startExportJob(c *Context, w http.ResponseWriter, r *http.Request) {
+	h.startScopeExportJob(c, w, r, app.ExportScopeChannel, mux.Vars(r)["channelId"])
+}
+
+func (h *ExportHandler) startSectionExportJob(c *Context, w http.ResponseWriter, r *http.Request) {
+	h.startScopeExportJob(c, w, r, app.ExportScopeSection, mux.Vars(r)["sectionId"])
+}
+
+func (h *ExportHandler) startOrganizationExportJob(c *Context, w http.ResponseWriter, r *http.Request) {
+	h.startScopeExportJob(c, w, r, app.ExportScopeOrganization, mux.Vars(r)["organizationId"])
+}
+
+func (h *ExportHandler) startScopeExportJob(c *Context, w http.ResponseWriter, r *http.Request, scope, scopeID string) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	params, ok := h.decodeExportParams(c, w, r)
+	if !ok {
+		return
+	}
+	job, err := h.exportService.StartExportJob(scope, scopeID, userID, params)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, job, http.StatusAccepted)
+}
+
+func (h *ExportHandler) getExportJob(c *Context, w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["jobId"]
+	userID := r.Header.Get("Mattermost-User-Id")
+	job, err := h.exportService.GetExportJob(jobID, userID)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, job, http.StatusOK)
+}
+
+func (h *ExportHandler) downloadExportJob(c *Context, w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["jobId"]
+	userID := r.Header.Get("Mattermost-User-Id")
+	fileURL, err := h.exportService.GetExportJobFileURL(jobID, userID)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	http.Redirect(w, r, fileURL, http.StatusFound)
+}
+
+func (h *ExportHandler) decodeExportParams(c *Context, w http.ResponseWriter, r *http.Request) (app.ExportChannelParams, bool) {
+	var params app.ExportChannelParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to decode export channel data", err)
+		return params, false
+	}
+	if _, found := app.GetExporter(params.Format); !found {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unsupported format", nil)
+		return params, false
+	}
+	return params, true
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// ExportScheduleHandler is the API handler.
+type ExportScheduleHandler struct {
+	*ErrorHandler
+	exportScheduleService *app.ExportScheduleService
+}
+
+// NewExportScheduleHandler returns a new export schedules api handler
+func NewExportScheduleHandler(router *mux.Router, exportScheduleService *app.ExportScheduleService) *ExportScheduleHandler {
+	handler := &ExportScheduleHandler{
+		ErrorHandler:          &ErrorHandler{},
+		exportScheduleService: exportScheduleService,
+	}
+
+	schedulesRouter := router.PathPrefix("/export_schedules").Subrouter()
+	schedulesRouter.HandleFunc("", withContext(handler.getExportSchedules)).Methods(http.MethodGet)
+	schedulesRouter.HandleFunc("", withContext(handler.addExportSchedule)).Methods(http.MethodPost)
+	schedulesRouter.HandleFunc("/{scheduleId}", withContext(handler.deleteExportSchedule)).Methods(http.MethodDelete)
+
+	return handler
+}
+
+func (h *ExportScheduleHandler) getExportSchedules(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	schedules, err := h.exportScheduleService.GetExportSchedules(userID)
+	if err != nil {
+		h.HandleError(w, c.logger, err)
+		return
+	}
+	ReturnJSON(w, schedules, http.StatusOK)
+}
+
+func (h *ExportScheduleHandler) addExportSchedule(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	var params app.AddExportScheduleParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to decode export schedule data", err)
+		return
+	}
+	schedule, err := h.exportScheduleService.AddExportSchedule(userID, params)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, schedule, http.StatusCreated)
+}
+
+func (h *ExportScheduleHandler) deleteExportSchedule(c *Context, w http.ResponseWriter, r *http.Request) {
+	scheduleID := mux.Vars(r)["scheduleId"]
+	userID := r.Header.Get("Mattermost-User-Id")
+	if err := h.exportScheduleService.DeleteExportSchedule(scheduleID, userID); err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, "", http.StatusOK)
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// Imported files are limited to the size of any other request, so the form is parsed in memory
+const importMaxSize = MaxRequestSize
+
+// ImportHandler is the API handler.
+type ImportHandler struct {
+	*ErrorHandler
+	importService *app.ImportService
+}
+
+// NewImportHandler returns a new imports api handler
+func NewImportHandler(router *mux.Router, importService *app.ImportService) *ImportHandler {
+	handler := &ImportHandler{
+		ErrorHandler:  &ErrorHandler{},
+		importService: importService,
+	}
+
+	importsRouter := router.PathPrefix("/imports").Subrouter()
+	importsRouter.HandleFunc("/channels", withContext(handler.importChannel)).Methods(http.MethodPost)
+
+	return handler
+}
+
+// Expects a multipart form with the exported file in the file field and the import parameters in the other fields
+func (h *ImportHandler) importChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	r.Body = http.MaxBytesReader(w, r.Body, importMaxSize)
+	if err := r.ParseMultipartForm(importMaxSize); err != nil {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, fmt.Sprintf("unable to parse import form, files are limited to %d MB", importMaxSize>>20), err)
+		return
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "missing file to import", err)
+		return
+	}
+	defer file.Close()
+
+	createPublicChannel, _ := strconv.ParseBool(r.FormValue("createPublicChannel"))
+	mapAuthors, _ := strconv.ParseBool(r.FormValue("mapAuthors"))
+	params := app.ImportChannelParams{
+		ChannelName:         r.FormValue("channelName"),
+		CreatePublicChannel: createPublicChannel,
+		ParentID:            r.FormValue("parentId"),
+		SectionID:           r.FormValue("sectionId"),
+		TeamID:              r.FormValue("teamId"),
+		OrganizationID:      r.FormValue("organizationId"),
+		MapAuthors:          mapAuthors,
+	}
+	result, err := h.importService.ImportChannel(userID, params, file)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, result, http.StatusCreated)
+}
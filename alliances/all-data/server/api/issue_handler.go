@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// IssueHandler is the API handler.
+type IssueHandler struct {
+	*ErrorHandler
+	issueChannelService *app.IssueChannelService
+}
+
+// NewIssueHandler returns a new ecosystem issues api handler
+func NewIssueHandler(router *mux.Router, issueChannelService *app.IssueChannelService) *IssueHandler {
+	handler := &IssueHandler{
+		ErrorHandler:        &ErrorHandler{},
+		issueChannelService: issueChannelService,
+	}
+
+	issueRouter := router.PathPrefix("/issues/{issueId}").Subrouter()
+	issueRouter.HandleFunc("/channel", withContext(handler.syncIssueChannel)).Methods(http.MethodPost)
+
+	return handler
+}
+
+// Creates or links the channel of an issue, returning the existing one if the issue already has a channel
+func (h *IssueHandler) syncIssueChannel(c *Context, w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("Mattermost-User-Id")
+	var params app.SyncIssueChannelParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to decode issue channel params", err)
+		return
+	}
+	result, err := h.issueChannelService.SyncIssueChannel(userID, mux.Vars(r)["issueId"], params)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, result, http.StatusOK)
+}
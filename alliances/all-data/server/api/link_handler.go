@@ -0,0 +1,48 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// LinkHandler is the API handler.
+type LinkHandler struct {
+	*ErrorHandler
+	linkService *app.LinkService
+}
+
+// NewLinkHandler returns a new links api handler
+func NewLinkHandler(router *mux.Router, linkService *app.LinkService) *LinkHandler {
+	handler := &LinkHandler{
+		ErrorHandler: &ErrorHandler{},
+		linkService:  linkService,
+	}
+
+	linksRouter := router.PathPrefix("/links").Subrouter()
+	linksRouter.HandleFunc("/resolve", withContext(handler.resolveLink)).Methods(http.MethodGet)
+
+	return handler
+}
+
+func (h *LinkHandler) resolveLink(c *Context, w http.ResponseWriter, r *http.Request) {
+	rawURL := r.URL.Query().Get("url")
+	key := r.URL.Query().Get("key")
+	if rawURL == "" && key == "" {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "either url or key must be provided", nil)
+		return
+	}
+	result, err := h.linkService.ResolveLink(rawURL, key)
+	if err != nil {
+		if errors.Is(err, app.ErrNotFound) {
+			h.HandleErrorWithCode(w, c.logger, http.StatusNotFound, "link not found", err)
+		} else {
+			h.HandleError(w, c.logger, err)
+		}
+		return
+	}
+	ReturnJSON(w, result, http.StatusOK)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/mattermost/mattermost-server/v6/model"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// LinkPreviewHandler is the API handler.
+type LinkPreviewHandler struct {
+	*ErrorHandler
+	linkPreviewService *app.LinkPreviewService
+}
+
+// NewLinkPreviewHandler returns a new link previews api handler, serving the buttons of the previews
+func NewLinkPreviewHandler(router *mux.Router, linkPreviewService *app.LinkPreviewService) *LinkPreviewHandler {
+	handler := &LinkPreviewHandler{
+		ErrorHandler:       &ErrorHandler{},
+		linkPreviewService: linkPreviewService,
+	}
+
+	actionsRouter := router.PathPrefix(app.LinkPreviewActionsPath).Subrouter()
+	actionsRouter.HandleFunc("/{action}", withContext(handler.doAction)).Methods(http.MethodPost)
+
+	return handler
+}
+
+// Answers the click on a preview button with an ephemeral message to the user who clicked it
+func (h *LinkPreviewHandler) doAction(c *Context, w http.ResponseWriter, r *http.Request) {
+	var request model.PostActionIntegrationRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to decode post action", err)
+		return
+	}
+	params := app.LinkPreviewActionParams{
+		UserID:    r.Header.Get("Mattermost-User-Id"),
+		TeamID:    request.TeamId,
+		ChannelID: request.ChannelId,
+		PostID:    request.PostId,
+		Context:   request.Context,
+	}
+
+	var text string
+	var err error
+	switch mux.Vars(r)["action"] {
+	case app.LinkPreviewOpenAction:
+		text, err = h.linkPreviewService.Open(params)
+	case app.LinkPreviewBacklinksAction:
+		text, err = h.linkPreviewService.ShowBacklinks(params)
+	case app.LinkPreviewPinAction:
+		text, err = h.linkPreviewService.PinAsEvidence(params)
+	default:
+		h.HandleErrorWithCode(w, c.logger, http.StatusNotFound, "unknown action", nil)
+		return
+	}
+	if err != nil {
+		c.logger.WithError(err).Warn("Unable to handle link preview action")
+		text = linkPreviewErrorText(err)
+	}
+	ReturnJSON(w, &model.PostActionIntegrationResponse{EphemeralText: text}, http.StatusOK)
+}
+
+// Mattermost only shows the ephemeral text of successful responses, so errors are explained there
+func linkPreviewErrorText(err error) string {
+	switch {
+	case errors.Is(err, app.ErrForbidden):
+		return "You are not allowed to do this."
+	case errors.Is(err, app.ErrNotFound):
+		return "The post no longer exists."
+	case errors.Is(err, app.ErrInvalidInput):
+		return "This preview is outdated, post the link again."
+	default:
+		return "Something went wrong, try again later."
+	}
+}
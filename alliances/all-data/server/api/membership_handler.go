@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// MembershipHandler is the API handler.
+type MembershipHandler struct {
+	*ErrorHandler
+	membershipService *app.MembershipService
+}
+
+// NewMembershipHandler returns a new organization memberships api handler
+func NewMembershipHandler(router *mux.Router, membershipService *app.MembershipService) *MembershipHandler {
+	handler := &MembershipHandler{
+		ErrorHandler:      &ErrorHandler{},
+		membershipService: membershipService,
+	}
+
+	membershipsRouter := router.PathPrefix("/memberships").Subrouter()
+	membershipsRouter.HandleFunc("", withContext(handler.getUserMemberships)).Methods(http.MethodGet)
+	membershipsRouter.HandleFunc("", withContext(handler.addMembership)).Methods(http.MethodPost)
+	membershipsRouter.HandleFunc("/reconcile", withContext(handler.startReconciliation)).Methods(http.MethodPost)
+	membershipsRouter.HandleFunc("/reconcile", withContext(handler.getReconciliationReport)).Methods(http.MethodGet)
+
+	organizationRouter := membershipsRouter.PathPrefix("/organizations/{organizationId}").Subrouter()
+	organizationRouter.HandleFunc("", withContext(handler.getOrganizationMemberships)).Methods(http.MethodGet)
+	organizationRouter.HandleFunc("/users/{userId}", withContext(handler.updateMembership)).Methods(http.MethodPut)
+	organizationRouter.HandleFunc("/users/{userId}", withContext(handler.removeMembership)).Methods(http.MethodDelete)
+
+	return handler
+}
+
+// Gets the memberships of the user in the userId query parameter, defaulting to the current user
+func (h *MembershipHandler) getUserMemberships(c *Context, w http.ResponseWriter, r *http.Request) {
+	actorID := r.Header.Get("Mattermost-User-Id")
+	userID := r.URL.Query().Get("userId")
+	if userID == "" {
+		userID = actorID
+	}
+	memberships, err := h.membershipService.GetUserMemberships(actorID, userID)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, memberships, http.StatusOK)
+}
+
+func (h *MembershipHandler) getOrganizationMemberships(c *Context, w http.ResponseWriter, r *http.Request) {
+	actorID := r.Header.Get("Mattermost-User-Id")
+	memberships, err := h.membershipService.GetOrganizationMemberships(actorID, mux.Vars(r)["organizationId"])
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, memberships, http.StatusOK)
+}
+
+func (h *MembershipHandler) addMembership(c *Context, w http.ResponseWriter, r *http.Request) {
+	actorID := r.Header.Get("Mattermost-User-Id")
+	var params app.AddMembershipParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to decode membership", err)
+		return
+	}
+	membership, err := h.membershipService.AddMembership(actorID, params)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, membership, http.StatusCreated)
+}
+
+func (h *MembershipHandler) updateMembership(c *Context, w http.ResponseWriter, r *http.Request) {
+	actorID := r.Header.Get("Mattermost-User-Id")
+	vars := mux.Vars(r)
+	var params app.UpdateMembershipParams
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		h.HandleErrorWithCode(w, c.logger, http.StatusBadRequest, "unable to decode membership", err)
+		return
+	}
+	membership, err := h.membershipService.UpdateMembership(actorID, vars["userId"], vars["organizationId"], params)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, membership, http.StatusOK)
+}
+
+func (h *MembershipHandler) removeMembership(c *Context, w http.ResponseWriter, r *http.Request) {
+	actorID := r.Header.Get("Mattermost-User-Id")
+	vars := mux.Vars(r)
+	if err := h.membershipService.RemoveMembership(actorID, vars["userId"], vars["organizationId"]); err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, "", http.StatusOK)
+}
+
+// Starts reconciling the organization channels in background, the report of the last reconciliation can then be fetched
+func (h *MembershipHandler) startReconciliation(c *Context, w http.ResponseWriter, r *http.Request) {
+	actorID := r.Header.Get("Mattermost-User-Id")
+	if err := h.membershipService.StartReconciliation(actorID); err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, "", http.StatusAccepted)
+}
+
+func (h *MembershipHandler) getReconciliationReport(c *Context, w http.ResponseWriter, r *http.Request) {
+	actorID := r.Header.Get("Mattermost-User-Id")
+	report, err := h.membershipService.GetReconciliationReport(actorID)
+	if err != nil {
+		h.HandleAppError(w, c.logger, err, "not found")
+		return
+	}
+	ReturnJSON(w, report, http.StatusOK)
+}
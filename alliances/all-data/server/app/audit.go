@@ -0,0 +1,37 @@
+package app
+
+// Privileged actions, which are authorized through Mattermost permissions and recorded in the audit log
+const (
+	ActionViewAllOrganizations  = "view_all_organizations"
+	ActionSetUserOrganization   = "set_user_organization"
+	ActionResetUserOrganization = "reset_user_organization"
+	ActionManageMemberships     = "manage_memberships"
+	ActionReconcileMemberships  = "reconcile_memberships"
+	ActionMapImportedAuthors    = "map_imported_authors"
+	ActionCreateIssue           = "create_issue"
+)
+
+// AuditRecord is an attempt to perform a privileged action, whether it was allowed or not.
+type AuditRecord struct {
+	ID       string `json:"id"`
+	UserID   string `json:"userId"`
+	Action   string `json:"action"`
+	TeamID   string `json:"teamId"`
+	TargetID string `json:"targetId"` // What the action was performed on, e.g. a user or an organization
+	Allowed  bool   `json:"allowed"`
+	Details  string `json:"details"`
+	CreateAt int64  `json:"createAt"`
+}
+
+type AuditFilterOptions struct {
+	UserID string
+	Action string
+
+	// Pagination options
+	Page    int
+	PerPage int
+}
+
+type GetAuditRecordsResults struct {
+	Items []AuditRecord `json:"items"`
+}
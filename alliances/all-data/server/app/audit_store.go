@@ -0,0 +1,9 @@
+package app
+
+// AuditStore is an interface for storing the audit log of privileged actions
+type AuditStore interface {
+	AddAuditRecord(record AuditRecord) error
+
+	// GetAuditRecords retrieves the records matching the options, most recent first
+	GetAuditRecords(options AuditFilterOptions) ([]AuditRecord, error)
+}
@@ -0,0 +1,152 @@
+package app
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/util"
+)
+
+const (
+	authFailuresKeyPrefix = "auth_failures_"
+	// Users failing this many authorizations within the window are blocked until the window ends
+	maxAuthFailures   = 5
+	authFailureWindow = 15 * time.Minute
+)
+
+type AuthorizationService struct {
+	api           plugin.API
+	auditStore    AuditStore
+	configuration *config.MattermostConfig
+}
+
+// NewAuthorizationService returns a new service authorizing privileged actions
+func NewAuthorizationService(api plugin.API, auditStore AuditStore, configuration *config.MattermostConfig) *AuthorizationService {
+	return &AuthorizationService{
+		api:           api,
+		auditStore:    auditStore,
+		configuration: configuration,
+	}
+}
+
+// authFailures counts the unauthorized attempts of a user within a window
+type authFailures struct {
+	Count       int   `json:"count"`
+	WindowStart int64 `json:"windowStart"`
+}
+
+// Checks whether a user is a system admin, an admin of the team if given, or has one of the privileged roles in the plugin settings.
+func (s *AuthorizationService) IsPrivileged(userID, teamID string) bool {
+	if s.api.HasPermissionTo(userID, mattermost.PermissionManageSystem) {
+		return true
+	}
+	if teamID != "" && s.api.HasPermissionToTeam(userID, teamID, mattermost.PermissionManageTeam) {
+		return true
+	}
+	privilegedRoles := s.privilegedRoles()
+	if len(privilegedRoles) == 0 {
+		return false
+	}
+	user, appErr := s.api.GetUser(userID)
+	if appErr != nil {
+		return false
+	}
+	for _, role := range strings.Fields(user.Roles) {
+		if privilegedRoles[role] {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorizes a privileged action, recording the attempt in the audit log.
+// Users are blocked for a while after too many unauthorized attempts, even if they become privileged in the meantime.
+func (s *AuthorizationService) Authorize(userID, teamID, action, targetID, details string) error {
+	failures := s.getFailures(userID)
+	if failures.Count >= maxAuthFailures {
+		s.Audit(userID, teamID, action, targetID, false, "blocked after too many unauthorized attempts: "+details)
+		return errors.Wrapf(ErrTooManyAttempts, "user %s is temporarily blocked from %s", userID, action)
+	}
+	if !s.IsPrivileged(userID, teamID) {
+		s.recordFailure(userID, failures)
+		s.Audit(userID, teamID, action, targetID, false, details)
+		return errors.Wrapf(ErrForbidden, "user %s is not allowed to %s", userID, action)
+	}
+	s.Audit(userID, teamID, action, targetID, true, details)
+	return nil
+}
+
+// Records a privileged action in the audit log, which is also mirrored in the server logs.
+// Failing to record is logged, without blocking the action.
+func (s *AuthorizationService) Audit(userID, teamID, action, targetID string, allowed bool, details string) {
+	record := AuditRecord{
+		ID:       util.GenerateUUID(),
+		UserID:   userID,
+		Action:   action,
+		TeamID:   teamID,
+		TargetID: targetID,
+		Allowed:  allowed,
+		Details:  details,
+		CreateAt: time.Now().UnixMilli(),
+	}
+	s.api.LogInfo("Audit", "userId", userID, "action", action, "teamId", teamID, "targetId", targetID, "allowed", allowed, "details", details)
+	if err := s.auditStore.AddAuditRecord(record); err != nil {
+		s.api.LogError("failed to add audit record", "record", record, "err", err)
+	}
+}
+
+// Gets the audit log, which only system admins can read.
+func (s *AuthorizationService) GetAuditRecords(userID string, options AuditFilterOptions) (GetAuditRecordsResults, error) {
+	if !s.api.HasPermissionTo(userID, mattermost.PermissionManageSystem) {
+		return GetAuditRecordsResults{}, errors.Wrapf(ErrForbidden, "user %s cannot read the audit log", userID)
+	}
+	records, err := s.auditStore.GetAuditRecords(options)
+	if err != nil {
+		return GetAuditRecordsResults{}, err
+	}
+	return GetAuditRecordsResults{Items: records}, nil
+}
+
+func (s *AuthorizationService) privilegedRoles() map[string]bool {
+	roles := map[string]bool{}
+	for _, role := range strings.Split(s.configuration.GetConfiguration().PrivilegedRoles, ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles[role] = true
+		}
+	}
+	return roles
+}
+
+// Returns the failures in the current window, which is a new one if the previous has ended
+func (s *AuthorizationService) getFailures(userID string) authFailures {
+	var failures authFailures
+	if err := kvGetJSON(s.api, authFailuresKeyPrefix+userID, &failures); err != nil {
+		s.api.LogWarn("failed to get unauthorized attempts", "userId", userID, "err", err)
+	}
+	if time.Since(time.UnixMilli(failures.WindowStart)) > authFailureWindow {
+		return authFailures{}
+	}
+	return failures
+}
+
+func (s *AuthorizationService) recordFailure(userID string, failures authFailures) {
+	if failures.Count == 0 {
+		failures.WindowStart = time.Now().UnixMilli()
+	}
+	failures.Count++
+	data, err := json.Marshal(failures)
+	if err != nil {
+		return
+	}
+	// The record expires with its window, so that blocked users are eventually let through
+	expiry := time.Until(time.UnixMilli(failures.WindowStart).Add(authFailureWindow))
+	if appErr := s.api.KVSetWithExpiry(authFailuresKeyPrefix+userID, data, int64(expiry.Seconds())+1); appErr != nil {
+		s.api.LogWarn("failed to record unauthorized attempt", "userId", userID, "err", appErr)
+	}
+}
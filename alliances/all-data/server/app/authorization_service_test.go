@@ -0,0 +1,121 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin/plugintest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+)
+
+// testAuditStore records the audited attempts
+type testAuditStore struct {
+	AuditStore
+	records []AuditRecord
+}
+
+func (s *testAuditStore) AddAuditRecord(record AuditRecord) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func TestAuthorize(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name             string
+		failures         *authFailures // Recorded before the attempt
+		systemAdmin      bool
+		teamAdmin        bool
+		roles            string
+		privilegedRoles  string
+		expectedErr      error
+		expectedFailures int
+	}{
+		{name: "system admin", systemAdmin: true},
+		{name: "team admin", teamAdmin: true},
+		{name: "privileged role", roles: "system_user csa_manager", privilegedRoles: "other, csa_manager"},
+		{name: "unprivileged user", roles: "system_user csa_manager", expectedErr: ErrForbidden, expectedFailures: 1},
+		{
+			name:             "unprivileged user failing again",
+			failures:         &authFailures{Count: maxAuthFailures - 1, WindowStart: now.Add(-time.Minute).UnixMilli()},
+			expectedErr:      ErrForbidden,
+			expectedFailures: maxAuthFailures,
+		},
+		{
+			name:             "blocked after too many failures",
+			failures:         &authFailures{Count: maxAuthFailures, WindowStart: now.Add(-time.Minute).UnixMilli()},
+			systemAdmin:      true,
+			expectedErr:      ErrTooManyAttempts,
+			expectedFailures: maxAuthFailures,
+		},
+		{
+			name:      "let through once the window has ended",
+			failures:  &authFailures{Count: maxAuthFailures, WindowStart: now.Add(-authFailureWindow - time.Minute).UnixMilli()},
+			teamAdmin: true,
+			// The failures of the ended window are left to expire
+			expectedFailures: maxAuthFailures,
+		},
+		{
+			name:             "failing in a new window once the previous has ended",
+			failures:         &authFailures{Count: maxAuthFailures, WindowStart: now.Add(-authFailureWindow - time.Minute).UnixMilli()},
+			expectedErr:      ErrForbidden,
+			expectedFailures: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kv := map[string][]byte{}
+			if test.failures != nil {
+				data, err := json.Marshal(test.failures)
+				require.NoError(t, err)
+				kv[authFailuresKeyPrefix+aliceID] = data
+			}
+
+			api := &plugintest.API{}
+			api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+				mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+			api.On("KVGet", mock.Anything).Return(func(key string) []byte {
+				return kv[key]
+			}, func(string) *mattermost.AppError {
+				return nil
+			})
+			api.On("KVSetWithExpiry", mock.Anything, mock.Anything, mock.Anything).Return(func(key string, data []byte, expireInSeconds int64) *mattermost.AppError {
+				assert.Positive(t, expireInSeconds)
+				kv[key] = data
+				return nil
+			}).Maybe()
+			api.On("HasPermissionTo", aliceID, mattermost.PermissionManageSystem).Return(test.systemAdmin).Maybe()
+			api.On("HasPermissionToTeam", aliceID, "teamid", mattermost.PermissionManageTeam).Return(test.teamAdmin).Maybe()
+			api.On("GetUser", aliceID).Return(&mattermost.User{Id: aliceID, Roles: test.roles}, nil).Maybe()
+			configuration := config.NewMattermostConfig(api)
+			configuration.SetConfiguration(&config.Configuration{PrivilegedRoles: test.privilegedRoles})
+			auditStore := &testAuditStore{}
+			service := NewAuthorizationService(api, auditStore, configuration)
+
+			err := service.Authorize(aliceID, "teamid", ActionCreateIssue, "sectionid", "create issue")
+			if test.expectedErr != nil {
+				assert.True(t, errors.Is(err, test.expectedErr), "unexpected error %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			require.Len(t, auditStore.records, 1)
+			assert.Equal(t, test.expectedErr == nil, auditStore.records[0].Allowed)
+			assert.Equal(t, ActionCreateIssue, auditStore.records[0].Action)
+
+			var failures authFailures
+			if data := kv[authFailuresKeyPrefix+aliceID]; data != nil {
+				require.NoError(t, json.Unmarshal(data, &failures))
+			}
+			assert.Equal(t, test.expectedFailures, failures.Count)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package app
+
+const (
+	BacklinkGraphPostNode    = "post"
+	BacklinkGraphElementNode = "element"
+)
+
+// BacklinkGraph is the bipartite graph of the backlinks, connecting posts to the platform elements they link.
+type BacklinkGraph struct {
+	Posts    []*BacklinkGraphPost    `json:"posts"`
+	Elements []*BacklinkGraphElement `json:"elements"`
+	Edges    []*BacklinkGraphEdge    `json:"edges"`
+}
+
+type BacklinkGraphPost struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	Message     string `json:"message"`
+	AuthorID    string `json:"authorId"`
+	AuthorName  string `json:"authorName"`
+	ChannelID   string `json:"channelId"`
+	ChannelName string `json:"channelName"`
+	CreateAt    int64  `json:"createAt"`
+	RootID      string `json:"rootId"`
+}
+
+// BacklinkGraphElement is a linked platform element, identified by its canonical key.
+type BacklinkGraphElement struct {
+	Key  string `json:"key"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// BacklinkGraphEdge connects a post to an element it links, using the text of the link as label.
+type BacklinkGraphEdge struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Text   string `json:"text"`
+}
@@ -0,0 +1,12 @@
+package app
+
+// OrganizationCategory links an organization to a sidebar category the plugin created for it, for a user in a team.
+// Each organization has a main category, with an empty rule id, and one for each sidebar rule applying to its channels.
+type OrganizationCategory struct {
+	UserID         string `json:"userId"`
+	TeamID         string `json:"teamId"`
+	OrganizationID string `json:"organizationId"`
+	RuleID         string `json:"ruleId"`
+	CategoryID     string `json:"categoryId"`
+	CreateAt       int64  `json:"createAt"`
+}
@@ -3,6 +3,7 @@ package app
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/mattermost/mattermost-server/v6/model"
 	"github.com/mattermost/mattermost-server/v6/plugin"
@@ -11,27 +12,33 @@ import (
 	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
 )
 
+// CategoryService manages the sidebar categories owned by the plugin for each user and team, following the sidebar rules in the config.
+// Categories are tracked by id, so that renaming organizations, categories or channels does not break them.
 type CategoryService struct {
 	api                    plugin.API
 	platformService        *config.PlatformService
 	channelStore           ChannelStore
 	categoryStore          CategoryStore
+	membershipStore        MembershipStore
 	mattermostChannelStore MattermostChannelStore
 }
 
-// NewCategoryService returns a new channels service
-func NewCategoryService(api plugin.API, platformService *config.PlatformService, channelStore ChannelStore, categoryStore CategoryStore, mattermostChannelStore MattermostChannelStore) *CategoryService {
+// NewCategoryService returns a new categories service
+func NewCategoryService(api plugin.API, platformService *config.PlatformService, channelStore ChannelStore, categoryStore CategoryStore, membershipStore MembershipStore, mattermostChannelStore MattermostChannelStore) *CategoryService {
 	return &CategoryService{
 		api:                    api,
 		platformService:        platformService,
 		channelStore:           channelStore,
 		categoryStore:          categoryStore,
+		membershipStore:        membershipStore,
 		mattermostChannelStore: mattermostChannelStore,
 	}
 }
 
-// cleanCategories deletes all the existing categories except the default one, which will contain all the channels previously in the deleted categories.
-func (s *CategoryService) cleanCategories(categories *model.OrderedSidebarCategories, teamID, userID string) error {
+// cleanCategories sets up the organization categories of a user: categories for each organization for users who selected all of them,
+// otherwise only for the ecosystem and the organizations the user belongs to. The categories of the other organizations are removed,
+// and their channels are moved to the Mattermost default category.
+func (s *CategoryService) cleanCategories(teamID, userID string) error {
 	platformConfig, err := s.platformService.GetPlatformConfig()
 	if err != nil {
 		return err
@@ -47,10 +54,9 @@ func (s *CategoryService) cleanCategories(categories *model.OrderedSidebarCatego
 		return fmt.Errorf("couldn't get all organizations channels: %s", xerr.Error())
 	}
 
-	// Old organization categories, those have no explicit org association, but they follow a naming convention we can use to migrate them
+	// Old organization channels have no explicit org association, but they follow a naming convention we can use to migrate them
 	s.migrateImplicitOrgChannels(allOrganizationsChannels.Items, allChannels.Items, platformConfig.Organizations)
 
-	// choose migration strategy based on the selected org id
 	user, userErr := s.api.GetUser(userID)
 	if userErr != nil {
 		return errors.Wrap(userErr, "could not fetch user to set orgID prop")
@@ -61,328 +67,359 @@ func (s *CategoryService) cleanCategories(categories *model.OrderedSidebarCatego
 		s.api.LogWarn("couldn't setup categories: the user has not selected an organization yet", "userID", userID)
 		return nil
 	}
-	if orgID == config.OrganizationIDAll {
-		if setupErr := s.setupOrganizationCategories(allOrganizationsChannels.Items, userID, teamID); setupErr != nil {
-			return errors.Wrap(setupErr, "error while setting organization categories")
-		}
-	} else {
-		if setupErr := s.purgeNonEcosystemCategories(categories.Categories, platformConfig, userID, teamID); setupErr != nil {
-			return errors.Wrap(setupErr, "error while purging organization categories")
-		}
+	organizations, err := s.getUserCategoryOrganizations(platformConfig, userID, orgID)
+	if err != nil {
+		return errors.Wrap(err, "could not get the organizations to set categories for")
+	}
+	if err := s.setupOrganizationCategories(platformConfig, allOrganizationsChannels.Items, organizations, userID, teamID); err != nil {
+		return errors.Wrap(err, "error while setting organization categories")
 	}
-
 	return nil
 }
 
-func (s *CategoryService) buildOrganizationCategory(teamID, userID string, organization config.Organization) (*model.SidebarCategoryWithChannels, error) {
-	channels, err := s.api.GetChannelsForTeamForUser(teamID, userID, true)
+// Returns the organizations a user gets a category for
+func (s *CategoryService) getUserCategoryOrganizations(platformConfig *config.PlatformConfig, userID, orgID string) ([]config.Organization, error) {
+	if orgID == config.OrganizationIDAll {
+		return platformConfig.Organizations, nil
+	}
+	memberships, err := s.membershipStore.GetMembershipsByUserID(userID)
 	if err != nil {
-		channels = []*model.Channel{}
+		return nil, err
 	}
-	organizationChannelIds := []string{}
-	for _, channel := range channels {
-		formattedOrganizationName := strings.ToLower(strings.ReplaceAll(organization.Name, " ", "-"))
-		if strings.Contains(strings.ToLower(channel.DisplayName), formattedOrganizationName) {
-			organizationChannelIds = append(organizationChannelIds, channel.Id)
-		}
+	organizationIDs := map[string]bool{orgID: true}
+	for _, membership := range memberships {
+		organizationIDs[membership.OrganizationID] = true
 	}
-
-	category := &model.SidebarCategoryWithChannels{
-		SidebarCategory: model.SidebarCategory{
-			UserId:      userID,
-			TeamId:      teamID,
-			Type:        model.SidebarCategoryChannels,
-			DisplayName: organization.Name,
-		},
-		Channels: organizationChannelIds,
+	organizations := []config.Organization{}
+	for _, organization := range platformConfig.Organizations {
+		if organization.IsEcosystem || organizationIDs[organization.ID] {
+			organizations = append(organizations, organization)
+		}
 	}
-	return category, nil
+	return organizations, nil
 }
 
-func (s *CategoryService) addChannelToEcosystemCategory(userID, teamID, channelID string) error {
-	platformConfig, err := s.platformService.GetPlatformConfig()
+// Setup the categories of the given organizations following the sidebar rules, moving there the channels of the user, and remove
+// any other category owned by the plugin. Categories are created through the plugin API and tracked by id in the organization categories table.
+func (s *CategoryService) setupOrganizationCategories(platformConfig *config.PlatformConfig, orgChannels []Channel, organizations []config.Organization, userID, teamID string) error {
+	s.api.LogInfo("Setting up categories", "userId", userID, "teamId", teamID)
+	categories, appErr := s.api.GetChannelSidebarCategories(userID, teamID)
+	if appErr != nil {
+		return errors.Wrapf(appErr, "couldn't get categories for user %s", userID)
+	}
+	categoryIDs, err := s.getOrganizationCategoryIDs(userID, teamID, platformConfig.Organizations, categories)
 	if err != nil {
 		return err
 	}
-
-	for _, organization := range platformConfig.Organizations {
-		if organization.IsEcosystem {
-			return s.addChannelToCategory(userID, teamID, channelID, organization.Name)
-		}
-	}
-	return fmt.Errorf("ecosystem not found")
-}
-
-func (s *CategoryService) addChannelToCategoryByOrganizationID(userID, teamID, channelID, orgID string) error {
-	platformConfig, err := s.platformService.GetPlatformConfig()
+	userChannels, err := s.getUserOrganizationChannels(orgChannels, userID, teamID)
 	if err != nil {
 		return err
 	}
+	targets := buildCategoryTargets(platformConfig, organizations, userChannels)
 
-	for _, organization := range platformConfig.Organizations {
-		if organization.ID == orgID {
-			return s.addChannelToCategory(userID, teamID, channelID, organization.Name)
+	categoriesByID := make(map[string]*model.SidebarCategoryWithChannels, len(categories.Categories))
+	for _, category := range categories.Categories {
+		categoriesByID[category.Id] = category
+	}
+	// Channels are moved to their category from the default one and from the other categories owned by the plugin,
+	// while those the user moved to categories of their own are left there
+	defaultCategory := getDefaultCategory(categories)
+	sourceCategories := []*model.SidebarCategoryWithChannels{}
+	if defaultCategory != nil {
+		sourceCategories = append(sourceCategories, defaultCategory)
+	}
+	for _, categoryID := range categoryIDs {
+		if category, found := categoriesByID[categoryID]; found {
+			sourceCategories = append(sourceCategories, category)
 		}
 	}
-	return fmt.Errorf("organization not found not found")
-}
-
-// Matches category based on name.
-func (s *CategoryService) addChannelToCategory(userID, teamID, channelID, categoryName string) error {
-	var targetCategory *model.SidebarCategoryWithChannels
-
-	categories, err := s.api.GetChannelSidebarCategories(userID, teamID)
-	if err != nil {
-		return fmt.Errorf("couldn't get categories for user %s", userID)
+	isWanted := make(map[categoryKey]bool, len(targets))
+	for _, target := range targets {
+		isWanted[target.key] = true
 	}
 
-	for _, category := range categories.Categories {
-		// TODO use custom SidebarCategoryType?
-		if category.DisplayName == categoryName {
-			targetCategory = category
-			break
+	// Empty the categories no longer needed, so that they can be deleted
+	categoriesToRemove := []*model.SidebarCategoryWithChannels{}
+	keysToRemove := []categoryKey{}
+	for key, categoryID := range categoryIDs {
+		if isWanted[key] {
+			continue
+		}
+		keysToRemove = append(keysToRemove, key)
+		category, found := categoriesByID[categoryID]
+		if !found {
+			continue
 		}
+		if defaultCategory != nil {
+			defaultCategory.Channels = append(defaultCategory.Channels, category.Channels...)
+		}
+		category.Channels = []string{}
+		categoriesToRemove = append(categoriesToRemove, category)
 	}
 
-	if targetCategory == nil {
-		return fmt.Errorf("category with name %s not found for user %s", categoryName, userID)
+	// Keep the existing categories up to date, moving there their channels
+	missingTargets := []*categoryTarget{}
+	for _, target := range targets {
+		category, found := categoriesByID[categoryIDs[target.key]]
+		if !found {
+			missingTargets = append(missingTargets, target)
+			continue
+		}
+		category.DisplayName = target.name
+		category.Channels = append(category.Channels, takeChannels(sourceCategories, category, target.channelIDs)...)
+		if target.sorting == config.SidebarSortingConfig && category.Sorting != model.SidebarCategorySortAlphabetical && category.Sorting != model.SidebarCategorySortRecent {
+			orderChannels(category, target.channelIDs)
+		}
+	}
+	if _, appErr := s.api.UpdateChannelSidebarCategories(userID, teamID, categories.Categories); appErr != nil {
+		return errors.Wrap(appErr, "could not update categories for team")
 	}
 
-	targetCategory.Channels = append(targetCategory.Channels, channelID)
-
-	if _, err := s.api.UpdateChannelSidebarCategories(userID, teamID, categories.Categories); err != nil {
-		return errors.Wrap(err, "could not update categories for team")
+	if err := s.categoryStore.DeleteCategories(categoriesToRemove); err != nil {
+		return errors.Wrap(err, "could not delete leftover categories")
+	}
+	for _, key := range keysToRemove {
+		if err := s.categoryStore.DeleteOrganizationCategory(userID, teamID, key.organizationID, key.ruleID); err != nil {
+			s.api.LogWarn("could not delete organization category", "orgId", key.organizationID, "ruleId", key.ruleID, "userId", userID, "err", err)
+		}
 	}
 
+	for _, target := range missingTargets {
+		channelIDs := takeChannels(sourceCategories, nil, target.channelIDs)
+		if _, err := s.createCategory(userID, teamID, target, channelIDs); err != nil {
+			s.api.LogError("Could not create sidebar category", "orgId", target.key.organizationID, "ruleId", target.key.ruleID, "userId", userID, "err", err)
+		}
+	}
 	return nil
 }
 
-func (s *CategoryService) setupCategories(userID, teamID string) error {
-	s.api.LogInfo("Setting up categories")
-	config, err := s.platformService.GetPlatformConfig()
+// Returns the ids of the categories owned by the plugin. Main categories of organizations created before they were tracked
+// are recognized by their name, the only time it is used, and tracked from then on.
+func (s *CategoryService) getOrganizationCategoryIDs(userID, teamID string, organizations []config.Organization, categories *model.OrderedSidebarCategories) (map[categoryKey]string, error) {
+	organizationCategories, err := s.categoryStore.GetOrganizationCategories(userID, teamID)
 	if err != nil {
-		return err
+		return nil, errors.Wrap(err, "could not get organization categories")
 	}
-	categories, catErr := s.api.GetChannelSidebarCategories(userID, teamID)
-	if catErr != nil {
-		s.api.LogError(fmt.Sprintf("Could not get sidebar categories due to %s", catErr.Error()))
-		return catErr
+	categoryIDs := make(map[categoryKey]string, len(organizationCategories))
+	trackedCategoryIDs := make(map[string]bool, len(organizationCategories))
+	for _, organizationCategory := range organizationCategories {
+		categoryIDs[categoryKey{organizationID: organizationCategory.OrganizationID, ruleID: organizationCategory.RuleID}] = organizationCategory.CategoryID
+		trackedCategoryIDs[organizationCategory.CategoryID] = true
 	}
-	if s.hasEachOrganizationCategory(config, categories) {
-		return nil
-	}
-	for _, organization := range config.Organizations {
-		if s.hasOrganizationCategory(organization, categories) {
-			continue
-		}
-		category, err := s.buildOrganizationCategory(teamID, userID, organization)
-		if err != nil {
-			continue
-		}
-		if _, err := s.api.CreateChannelSidebarCategory(userID, teamID, category); err != nil {
-			s.api.LogError(fmt.Sprintf("Could not create sidebar category due to %s", err.Error()))
-			continue
-		}
-	}
-	return nil
-}
 
-func (s *CategoryService) hasEachOrganizationCategory(config *config.PlatformConfig, categories *model.OrderedSidebarCategories) bool {
-	organizations := config.Organizations
-	matches := 0
 	for _, organization := range organizations {
+		key := categoryKey{organizationID: organization.ID}
+		if _, found := categoryIDs[key]; found {
+			continue
+		}
 		for _, category := range categories.Categories {
-			if strings.Contains(strings.ToLower(category.DisplayName), strings.ToLower(organization.Name)) {
-				matches++
+			if category.Type != model.SidebarCategoryCustom || trackedCategoryIDs[category.Id] || !strings.EqualFold(category.DisplayName, organization.Name) {
+				continue
+			}
+			if err := s.saveOrganizationCategory(userID, teamID, key, category.Id); err != nil {
+				s.api.LogWarn("could not track existing organization category", "orgId", organization.ID, "categoryId", category.Id, "err", err)
 				break
 			}
+			categoryIDs[key] = category.Id
+			trackedCategoryIDs[category.Id] = true
+			break
 		}
 	}
-	return matches == len(organizations)
+	return categoryIDs, nil
 }
 
-func (s *CategoryService) hasOrganizationCategory(organization config.Organization, categories *model.OrderedSidebarCategories) bool {
-	for _, category := range categories.Categories {
-		if strings.Contains(strings.ToLower(category.DisplayName), strings.ToLower(organization.Name)) {
-			return true
+// Returns the organization channels of the team the user is a member of
+func (s *CategoryService) getUserOrganizationChannels(orgChannels []Channel, userID, teamID string) ([]Channel, error) {
+	teamChannels, appErr := s.api.GetChannelsForTeamForUser(teamID, userID, false)
+	if appErr != nil {
+		return nil, errors.Wrapf(appErr, "couldn't get channels of user %s", userID)
+	}
+	isTeamChannel := make(map[string]bool, len(teamChannels))
+	for _, channel := range teamChannels {
+		isTeamChannel[channel.Id] = true
+	}
+	userChannels := []Channel{}
+	for _, orgChannel := range orgChannels {
+		if orgChannel.OrganizationID != "" && isTeamChannel[orgChannel.ChannelID] {
+			userChannels = append(userChannels, orgChannel)
 		}
 	}
-	return false
+	return userChannels, nil
 }
 
-// Setup one category per organization, where the org's channels will reside. We assume the user is already a member of all the channels passed as argument.
-// The channel's name is used to figure out which organization it is related to (org name as substring of the channel name).
-func (s *CategoryService) setupOrganizationCategories(orgChannels []Channel, userID, teamID string) error {
-	if err := s.setupCategories(userID, teamID); err != nil {
-		return errors.Wrapf(err, "Could not setup categories")
+// Adds a channel to the category it belongs to according to the sidebar rules, creating the category if the user has none for it yet.
+func (s *CategoryService) addChannelToOrganizationCategory(userID, teamID string, channel Channel) error {
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return err
+	}
+	var organization *config.Organization
+	for i := range platformConfig.Organizations {
+		if platformConfig.Organizations[i].ID == channel.OrganizationID {
+			organization = &platformConfig.Organizations[i]
+			break
+		}
+	}
+	if organization == nil {
+		return fmt.Errorf("organization %s not found", channel.OrganizationID)
+	}
+	var target *categoryTarget
+	for _, candidate := range buildCategoryTargets(platformConfig, []config.Organization{*organization}, []Channel{channel}) {
+		if len(candidate.channelIDs) > 0 {
+			target = candidate
+			break
+		}
+	}
+	if target == nil {
+		return fmt.Errorf("no category found for channel %s", channel.ChannelID)
 	}
 
-	categories, err := s.api.GetChannelSidebarCategories(userID, teamID)
-	if err != nil {
+	categories, appErr := s.api.GetChannelSidebarCategories(userID, teamID)
+	if appErr != nil {
 		return fmt.Errorf("couldn't get categories for user %s", userID)
 	}
-
-	config, configErr := s.platformService.GetPlatformConfig()
-	if configErr != nil {
-		return fmt.Errorf("couldn't get config")
+	categoryIDs, err := s.getOrganizationCategoryIDs(userID, teamID, []config.Organization{*organization}, categories)
+	if err != nil {
+		return err
 	}
 
-	var skippedIds []string
+	var targetCategory *model.SidebarCategoryWithChannels
 	for _, category := range categories.Categories {
-		// Remove organization channels from the default category
-		if category.Type == model.SidebarCategoryChannels {
-			var channelIds []string
-			for _, channelID := range category.Channels {
-				found := false
-				for _, orgChannel := range orgChannels {
-					// An empty org id is only possible for channels with names that do not have the org name as substring, and which need to be migrated manually.
-					if orgChannel.ChannelID == channelID {
-						found = true
-						if orgChannel.OrganizationID == "" {
-							channelIds = append(channelIds, channelID)
-						} else {
-							skippedIds = append(skippedIds, channelID)
-						}
-						break
-					}
-				}
-				if !found {
-					channelIds = append(channelIds, channelID)
-				}
-			}
-			category.Channels = channelIds
+		if category.Id == categoryIDs[target.key] {
+			targetCategory = category
 			break
 		}
 	}
-
-	for _, category := range categories.Categories {
-		// Identify the organization of this category by the name
-		formattedCategoryName := strings.ToLower(strings.ReplaceAll(category.DisplayName, " ", "-"))
-		for _, organization := range config.Organizations {
-			formattedOrganizationName := strings.ToLower(strings.ReplaceAll(organization.Name, " ", "-"))
-			if formattedOrganizationName == formattedCategoryName {
-				// We got the corresponding organization id, now we can add all the related channels
-				for _, channel := range orgChannels {
-					// Skip channels that weren't in the default category
-					// this is useful to avoid adding channels from other teams since we do not track the team ID yet (we should)
-					valid := false
-					for _, cid := range skippedIds {
-						if cid == channel.ChannelID {
-							valid = true
-							break
-						}
-					}
-					if !valid {
-						continue
-					}
-
-					// Skip channels already under this categories
-					contained := false
-					for _, channelID := range category.Channels {
-						if channel.ChannelID == channelID {
-							contained = true
-							break
-						}
-					}
-					if contained {
-						continue
-					}
-
-					// Skip channels unrelated to this organization
-					if channel.OrganizationID != organization.ID {
-						continue
-					}
-
-					category.Channels = append(category.Channels, channel.ChannelID)
-				}
-			}
+	if targetCategory == nil {
+		_, err := s.createCategory(userID, teamID, target, target.channelIDs)
+		return err
+	}
+	for _, categoryChannelID := range targetCategory.Channels {
+		if categoryChannelID == channel.ChannelID {
+			return nil
 		}
 	}
 
-	if _, err := s.api.UpdateChannelSidebarCategories(userID, teamID, categories.Categories); err != nil {
-		return errors.Wrap(err, "could not update categories for team to add channel")
+	// Mattermost removes the channel from the category it was in
+	targetCategory.Channels = append(targetCategory.Channels, channel.ChannelID)
+	if _, err := s.api.UpdateChannelSidebarCategories(userID, teamID, []*model.SidebarCategoryWithChannels{targetCategory}); err != nil {
+		return errors.Wrap(err, "could not update categories for team")
 	}
-
 	return nil
 }
 
-// Purge all organization specific categories, bar the ecosystem category which is kept. All the organization channels will be moved in the Mattermost default Channels category.
-func (s *CategoryService) purgeNonEcosystemCategories(categories model.SidebarCategoriesWithChannels, config *config.PlatformConfig, userID, teamID string) error {
-	var ecosystemCategory *model.SidebarCategoryWithChannels
-	var categoriesToRemove []*model.SidebarCategoryWithChannels
-
-	ecosystem, ecosystemFound := config.GetEcosystem()
-	if !ecosystemFound {
-		return fmt.Errorf("couldn't get ecosystem")
+// Moves a channel out of the categories owned by the plugin, back to the Mattermost default category.
+func (s *CategoryService) removeChannelFromOrganizationCategories(userID, teamID, channelID string) error {
+	categories, appErr := s.api.GetChannelSidebarCategories(userID, teamID)
+	if appErr != nil {
+		return fmt.Errorf("couldn't get categories for user %s", userID)
 	}
-
-	for _, category := range categories {
-		// TODO use custom SidebarCategoryType?
-		if category.DisplayName == "Ecosystem" {
-			ecosystemCategory = category
-			break
-		}
+	organizationCategories, err := s.categoryStore.GetOrganizationCategories(userID, teamID)
+	if err != nil {
+		return errors.Wrap(err, "could not get organization categories")
+	}
+	isOwned := make(map[string]bool, len(organizationCategories))
+	for _, organizationCategory := range organizationCategories {
+		isOwned[organizationCategory.CategoryID] = true
 	}
 
-	// Create if absent
-	if ecosystemCategory == nil {
-		ecosystemCategory, _ = s.buildOrganizationCategory(teamID, userID, *ecosystem)
-
-		if _, catErr := s.api.CreateChannelSidebarCategory(userID, teamID, ecosystemCategory); catErr != nil {
-			return errors.Wrap(catErr, "Could not create sidebar category")
+	var ownerCategory *model.SidebarCategoryWithChannels
+	for _, category := range categories.Categories {
+		if !isOwned[category.Id] {
+			continue
 		}
+		for _, categoryChannelID := range category.Channels {
+			if categoryChannelID == channelID {
+				ownerCategory = category
+				break
+			}
+		}
+	}
+	defaultCategory := getDefaultCategory(categories)
+	if ownerCategory == nil || defaultCategory == nil {
+		return nil
 	}
+	takeChannels([]*model.SidebarCategoryWithChannels{ownerCategory}, nil, []string{channelID})
+	defaultCategory.Channels = append(defaultCategory.Channels, channelID)
+	if _, appErr := s.api.UpdateChannelSidebarCategories(userID, teamID, []*model.SidebarCategoryWithChannels{ownerCategory, defaultCategory}); appErr != nil {
+		return errors.Wrap(appErr, "could not update categories for team")
+	}
+	return nil
+}
 
-	allOrganizationsChannels, xerr := s.channelStore.GetAllChannels()
-	if xerr != nil {
-		return fmt.Errorf("couldn't get all organizations channels: %s", xerr.Error())
+func (s *CategoryService) createCategory(userID, teamID string, target *categoryTarget, channelIDs []string) (*model.SidebarCategoryWithChannels, error) {
+	category, appErr := s.api.CreateChannelSidebarCategory(userID, teamID, &model.SidebarCategoryWithChannels{
+		SidebarCategory: model.SidebarCategory{
+			UserId:      userID,
+			TeamId:      teamID,
+			Type:        model.SidebarCategoryCustom,
+			DisplayName: target.name,
+			Sorting:     toCategorySorting(target.sorting),
+			Collapsed:   target.collapsed,
+		},
+		Channels: channelIDs,
+	})
+	if appErr != nil {
+		return nil, errors.Wrapf(appErr, "could not create category %s for organization %s", target.name, target.key.organizationID)
+	}
+	if err := s.saveOrganizationCategory(userID, teamID, target.key, category.Id); err != nil {
+		return nil, err
 	}
+	return category, nil
+}
 
-	for _, category := range categories {
-		if category.Type == model.SidebarCategoryChannels {
-			var channelIds []string
-			for _, categoryChannelID := range category.Channels {
-				for _, orgChannel := range allOrganizationsChannels.Items {
-					if orgChannel.ChannelID == categoryChannelID {
-						if orgChannel.OrganizationID == ecosystem.ID {
-							ecosystemCategory.Channels = append(ecosystemCategory.Channels, categoryChannelID)
-						} else {
-							channelIds = append(channelIds, categoryChannelID)
-						}
-					}
-				}
-			}
+func (s *CategoryService) saveOrganizationCategory(userID, teamID string, key categoryKey, categoryID string) error {
+	return s.categoryStore.SaveOrganizationCategory(OrganizationCategory{
+		UserID:         userID,
+		TeamID:         teamID,
+		OrganizationID: key.organizationID,
+		RuleID:         key.ruleID,
+		CategoryID:     categoryID,
+		CreateAt:       time.Now().UnixMilli(),
+	})
+}
 
-			category.Channels = channelIds
-			continue
+// Returns the Mattermost default Channels category, if any
+func getDefaultCategory(categories *model.OrderedSidebarCategories) *model.SidebarCategoryWithChannels {
+	for _, category := range categories.Categories {
+		if category.Type == model.SidebarCategoryChannels {
+			return category
 		}
+	}
+	return nil
+}
 
-		// Ignore mattermost system categories
-		if category.Type != model.SidebarCategoryCustom {
+// Removes the given channels from the source categories other than the target one, returning those that were found.
+// Channels the user moved to other categories are left where they are.
+func takeChannels(sourceCategories []*model.SidebarCategoryWithChannels, target *model.SidebarCategoryWithChannels, channelIDs []string) []string {
+	taken := []string{}
+	if len(channelIDs) == 0 {
+		return taken
+	}
+	toTake := make(map[string]bool, len(channelIDs))
+	for _, channelID := range channelIDs {
+		toTake[channelID] = true
+	}
+	for _, category := range sourceCategories {
+		if category == target {
 			continue
 		}
-
-		if category.Id == ecosystemCategory.Id {
-			continue
+		kept := []string{}
+		for _, channelID := range category.Channels {
+			if toTake[channelID] {
+				taken = append(taken, channelID)
+			} else {
+				kept = append(kept, channelID)
+			}
 		}
-
-		category.Channels = []string{}
-		categoriesToRemove = append(categoriesToRemove, category)
-	}
-
-	if _, err := s.api.UpdateChannelSidebarCategories(userID, teamID, categories); err != nil {
-		return errors.Wrap(err, "could not update categories for team")
+		category.Channels = kept
 	}
-
-	if err := s.categoryStore.DeleteCategories(categoriesToRemove); err != nil {
-		return errors.Wrap(err, "could not delete leftover categories")
-	}
-
-	return nil
+	return taken
 }
 
+// Links the channels without an organization to the organization in their name, updating them in place
 func (s *CategoryService) migrateImplicitOrgChannels(orgChannels []Channel, channels []model.Channel, organizations []config.Organization) {
-	for _, orgChannel := range orgChannels {
+	for i, orgChannel := range orgChannels {
 		if orgChannel.OrganizationID != "" {
 			continue
 		}
@@ -396,6 +433,7 @@ func (s *CategoryService) migrateImplicitOrgChannels(orgChannels []Channel, chan
 						if err := s.channelStore.LinkChannelToOrganization(channel.Id, organization.ID); err != nil {
 							s.api.LogWarn("found a channel implicitly related to an organization but failed to make the link explicit", "channelID", channel.Id, "organizationID", organization.ID)
 						} else {
+							orgChannels[i].OrganizationID = organization.ID
 							s.api.LogInfo("organization channel without an explicit orgID migrated successfully", "channelID", channel.Id, "organizationID", organization.ID)
 						}
 						break
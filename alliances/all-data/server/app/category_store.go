@@ -3,5 +3,10 @@ package app
 import "github.com/mattermost/mattermost-server/v6/model"
 
 type CategoryStore interface {
+	// Gets the categories owned by the plugin for a user in a team
+	GetOrganizationCategories(userID, teamID string) ([]OrganizationCategory, error)
+	// Adds a category of an organization, replacing the previous one for the same rule if any
+	SaveOrganizationCategory(organizationCategory OrganizationCategory) error
+	DeleteOrganizationCategory(userID, teamID, organizationID, ruleID string) error
 	DeleteCategories(categories []*model.SidebarCategoryWithChannels) error
 }
@@ -57,6 +57,37 @@ type AddChannelResult struct {
 	SectionID string `json:"sectionId"`
 }
 
+// Parameters for recreating an exported channel, which is registered like the channels added with AddChannelParams
+type ImportChannelParams struct {
+	ChannelName         string `json:"channelName"` // Defaults to the name of the exported channel
+	CreatePublicChannel bool   `json:"createPublicChannel"`
+	ParentID            string `json:"parentId"`
+	SectionID           string `json:"sectionId"`
+	TeamID              string `json:"teamId"`
+	OrganizationID      string `json:"organizationId"`
+	MapAuthors          bool   `json:"mapAuthors"` // Whether to post as the users matching the authors, only allowed to privileged users
+}
+
+type ImportChannelResult struct {
+	ChannelID       string   `json:"channelId"`
+	ParentID        string   `json:"parentId"`
+	SectionID       string   `json:"sectionId"`
+	PostsCount      int      `json:"postsCount"`
+	FailedCount     int      `json:"failedCount"`
+	UnmappedAuthors []string `json:"unmappedAuthors"` // Authors without a matching user when mapping authors, whose posts are made by the bot
+}
+
+type RenameChannelParams struct {
+	DisplayName string `json:"displayName"`
+}
+
+// Parameters for moving a channel to another section, where the parent id is the section in the config and the section id its element
+type MoveChannelParams struct {
+	ParentID       string `json:"parentId"`
+	SectionID      string `json:"sectionId"`
+	OrganizationID string `json:"organizationId"`
+}
+
 type ArchiveChannelsParams struct {
 	SectionID string `json:"sectionId"`
 }
@@ -64,15 +95,21 @@ type ArchiveChannelsParams struct {
 type Backlink struct {
 	ID          string `json:"id"`
 	Message     string `json:"message"`
+	AuthorID    string `json:"authorId"`
 	AuthorName  string `json:"authorName"`
 	ChannelName string `json:"channelName"`
 	SectionName string `json:"sectionName"`
 	CreateAt    int64  `json:"createAt"`
+	Stance      string `json:"stance"` // Empty if the post has not been tagged
 }
 
 type GetBacklinksResult struct {
-	Items        []Backlink       `json:"items"`
-	ChannelCount []*ChannelsCount `json:"channelsCount"`
+	Items        []Backlink        `json:"items"`
+	ChannelCount []*ChannelsCount  `json:"channelsCount"`
+	UserCount    []*UsersCount     `json:"usersCount"`
+	SectionCount []*SectionsCount  `json:"sectionsCount"`
+	StanceCount  []*StancesCount   `json:"stancesCount"`
+	Histogram    []*HistogramCount `json:"histogram"`
 }
 
 type ChannelsCount struct {
@@ -81,15 +118,86 @@ type ChannelsCount struct {
 	SectionName string `json:"sectionName"`
 }
 
+type UsersCount struct {
+	UserID string `json:"userId"`
+	Name   string `json:"name"`
+	Count  int    `json:"count"`
+}
+
+type SectionsCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Number of backlinks tagged with a stance, untagged ones are counted with an empty stance
+type StancesCount struct {
+	Stance string `json:"stance"`
+	Count  int    `json:"count"`
+}
+
+// Number of backlinks created in a single day, identified by its date in the YYYY-MM-DD format (UTC)
+type HistogramCount struct {
+	Date  string `json:"date"`
+	Count int    `json:"count"`
+}
+
+type ElementsCount struct {
+	Name  string `json:"name"`
+	Link  string `json:"link"`
+	Count int    `json:"count"`
+}
+
+type GetTopBacklinksResult struct {
+	Elements []*ElementsCount `json:"elements"`
+	Users    []*UsersCount    `json:"users"`
+}
+
+// Stances of a post towards an element it links, i.e. of a claim towards the data it is grounded in
+const (
+	StanceSupports      = "supports"
+	StanceContradicts   = "contradicts"
+	StanceQuestions     = "questions"
+	StanceMisrepresents = "misrepresents"
+)
+
+// Stances lists the stances in the order they are counted in
+var Stances = []string{StanceSupports, StanceContradicts, StanceQuestions, StanceMisrepresents}
+
+// BacklinkStance is how a post relates to an element it links, as tagged by a user
+type BacklinkStance struct {
+	PostID          string `json:"postId"`
+	ElementLinkPart string `json:"elementLinkPart"` // Canonical key of the linked element
+	Stance          string `json:"stance"`
+	UserID          string `json:"userId"` // User who last tagged the backlink
+	UpdateAt        int64  `json:"updateAt"`
+}
+
+// SetBacklinkStanceParams tags the backlink of a post to an element, an empty stance removes the tag
+type SetBacklinkStanceParams struct {
+	ElementURL string `json:"elementUrl"`
+	Stance     string `json:"stance"`
+}
+
+type GetBacklinkStancesResult struct {
+	Items []BacklinkStance `json:"items"`
+}
+
 type BacklinkData struct {
 	MarkdownText string
 	MarkdownLink string
 }
 
 type BacklinkEntity struct {
-	ID        string
-	PostID    string
-	ChannelID string
+	ID                  string
+	PostID              string
+	ChannelID           string
+	UserID              string
+	RootID              string
+	CreateAt            int64
+	Message             string
+	OrganizationID      string
+	ElementLinkPart     string
+	ElementMarkdownPath string
 }
 
 type ExportReference struct {
@@ -102,4 +210,12 @@ type ExportChannelParams struct {
 	Format     string            `json:"format"`
 	PinnedOnly bool              `json:"pinnedOnly"`
 	References []ExportReference `json:"references"`
+
+	// Filters on the exported posts, each applied to roots and replies alike. Roots are kept with their matching replies for context.
+	Since          int64    `json:"since"`          // Creation time in milliseconds, inclusive
+	Until          int64    `json:"until"`          // Creation time in milliseconds, inclusive
+	Authors        []string `json:"authors"`        // User ids or usernames
+	Elements       []string `json:"elements"`       // URLs or canonical keys of elements the posts link, including anything nested in them
+	Reactions      []string `json:"reactions"`      // Emoji names
+	ExcludeReplies bool     `json:"excludeReplies"` // Exports root posts only
 }
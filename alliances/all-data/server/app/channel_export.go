@@ -0,0 +1,58 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+)
+
+// ChannelExport holds everything needed to export a channel, independently from the export format.
+type ChannelExport struct {
+	Channel    *mattermost.Channel
+	Team       *mattermost.Team // Nil for channels not belonging to a team, e.g. direct messages
+	SiteURL    string
+	Threads    []*ExportThread
+	Users      map[string]*mattermost.User
+	FileLinks  map[string]string            // Public links of the files attached to the posts, by file id
+	Backlinks  map[string][]*ExportBacklink // Elements linked by the posts, by post id
+	References []ExportReference
+	ExportedAt int64
+
+	filter *exportFilter // Selects the posts to write, when the threads are read from the database
+}
+
+// ExportThread is a root post together with its replies, sorted by creation time.
+type ExportThread struct {
+	Root    *mattermost.Post
+	Replies []*mattermost.Post
+}
+
+// ExportBacklink is an element linked by an exported post.
+type ExportBacklink struct {
+	Key  string
+	Name string
+	URL  string
+}
+
+// PostURL returns the permalink of a post of the exported channel.
+func (e *ChannelExport) PostURL(postID string) string {
+	if e.Team == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/%s/pl/%s", strings.TrimSuffix(e.SiteURL, "/"), e.Team.Name, postID)
+}
+
+// UserName returns the display name of a post author, falling back to the user id for unknown users.
+func (e *ChannelExport) UserName(userID string) string {
+	if user, found := e.Users[userID]; found && user != nil {
+		return user.GetDisplayName(mattermost.ShowNicknameFullName)
+	}
+	return userID
+}
+
+// Formats a timestamp in milliseconds for humans, in UTC
+func exportTime(millis int64) string {
+	return time.UnixMilli(millis).UTC().Format("2006-01-02 15:04 MST")
+}
@@ -0,0 +1,225 @@
+package app
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+)
+
+const (
+	// Mattermost max length for channel display names
+	channelDisplayNameMaxLength = 64
+	// Marks the channels archived along with their element, so that only those get restored with it
+	elementArchivedChannelKeyPrefix = "element_archived_channel_"
+)
+
+// Renames a section channel. Categories track channels by id, so they need no update.
+func (s *ChannelService) RenameChannel(userID, channelID string, params RenameChannelParams) (GetChannelByIDResult, error) {
+	displayName := strings.TrimSpace(params.DisplayName)
+	if displayName == "" || utf8.RuneCountInString(displayName) > channelDisplayNameMaxLength {
+		return GetChannelByIDResult{}, errors.Wrapf(ErrInvalidInput, "display name must be between 1 and %d characters", channelDisplayNameMaxLength)
+	}
+	channel, err := s.getSectionChannel(userID, channelID, mattermost.PermissionManagePublicChannelProperties, mattermost.PermissionManagePrivateChannelProperties)
+	if err != nil {
+		return GetChannelByIDResult{}, err
+	}
+	if channel.DeleteAt != 0 {
+		return GetChannelByIDResult{}, errors.Wrapf(ErrInvalidInput, "channel %s is archived", channelID)
+	}
+
+	s.api.LogInfo("Renaming channel", "channelId", channelID, "userId", userID, "displayName", displayName)
+	channel.DisplayName = displayName
+	if _, appErr := s.api.UpdateChannel(channel); appErr != nil {
+		return GetChannelByIDResult{}, errors.Wrapf(appErr, "could not rename channel %s", channelID)
+	}
+	return s.GetChannelByID(channelID)
+}
+
+// Moves a channel to another section, possibly of another organization, moving it to the matching category of its members.
+// Members of the previous organization are left in the channel until the memberships reconciliation runs.
+func (s *ChannelService) MoveChannel(userID, channelID string, params MoveChannelParams) (GetChannelByIDResult, error) {
+	if params.ParentID == "" || params.SectionID == "" || params.OrganizationID == "" {
+		return GetChannelByIDResult{}, errors.Wrap(ErrInvalidInput, "parentId, sectionId and organizationId are required")
+	}
+	channel, err := s.getSectionChannel(userID, channelID, mattermost.PermissionManagePublicChannelProperties, mattermost.PermissionManagePrivateChannelProperties)
+	if err != nil {
+		return GetChannelByIDResult{}, err
+	}
+	if err := s.checkSection(params.OrganizationID, params.ParentID); err != nil {
+		return GetChannelByIDResult{}, err
+	}
+
+	s.api.LogInfo("Moving channel", "channelId", channelID, "userId", userID, "params", params)
+	if err := s.store.MoveChannel(channelID, params); err != nil {
+		return GetChannelByIDResult{}, err
+	}
+	movedChannel := Channel{
+		ChannelID:      channelID,
+		ParentID:       params.ParentID,
+		SectionID:      params.SectionID,
+		OrganizationID: params.OrganizationID,
+	}
+	s.forEachChannelMember(channelID, func(memberID string) {
+		if err := s.categoryService.addChannelToOrganizationCategory(memberID, channel.TeamId, movedChannel); err != nil {
+			s.api.LogWarn("couldn't move channel to organization category", "channelId", channelID, "userId", memberID, "err", err)
+		}
+	})
+	return s.GetChannelByID(channelID)
+}
+
+// Removes a channel from its section without deleting it, moving it back to the default category of its members.
+func (s *ChannelService) UnlinkChannel(userID, channelID string) error {
+	channel, err := s.getSectionChannel(userID, channelID, mattermost.PermissionManagePublicChannelProperties, mattermost.PermissionManagePrivateChannelProperties)
+	if err != nil {
+		return err
+	}
+
+	s.api.LogInfo("Unlinking channel", "channelId", channelID, "userId", userID)
+	if err := s.store.UnlinkChannel(channelID); err != nil {
+		return err
+	}
+	s.forEachChannelMember(channelID, func(memberID string) {
+		if err := s.categoryService.removeChannelFromOrganizationCategories(memberID, channel.TeamId, channelID); err != nil {
+			s.api.LogWarn("couldn't remove channel from organization categories", "channelId", channelID, "userId", memberID, "err", err)
+		}
+	})
+	return nil
+}
+
+// Archives a single section channel, which stays linked to its section so that it can be restored.
+func (s *ChannelService) ArchiveChannel(userID, channelID string) error {
+	channel, err := s.getSectionChannel(userID, channelID, mattermost.PermissionDeletePublicChannel, mattermost.PermissionDeletePrivateChannel)
+	if err != nil {
+		return err
+	}
+	if channel.DeleteAt != 0 {
+		return nil
+	}
+	s.api.LogInfo("Archiving channel", "channelId", channelID, "userId", userID)
+	if appErr := s.api.DeleteChannel(channelID); appErr != nil {
+		return errors.Wrapf(appErr, "could not archive channel %s", channelID)
+	}
+	return nil
+}
+
+// Restores an archived section channel, in the section it was archived from.
+func (s *ChannelService) RestoreChannel(userID, channelID string) (GetChannelByIDResult, error) {
+	channel, err := s.getSectionChannel(userID, channelID, mattermost.PermissionDeletePublicChannel, mattermost.PermissionDeletePrivateChannel)
+	if err != nil {
+		return GetChannelByIDResult{}, err
+	}
+	if channel.DeleteAt != 0 {
+		s.api.LogInfo("Restoring channel", "channelId", channelID, "userId", userID)
+		if err := s.restoreChannel(channel); err != nil {
+			return GetChannelByIDResult{}, err
+		}
+		if appErr := s.api.KVDelete(elementArchivedChannelKeyPrefix + channelID); appErr != nil {
+			s.api.LogWarn("couldn't clear archived element marker", "channelId", channelID, "err", appErr)
+		}
+	}
+	return s.GetChannelByID(channelID)
+}
+
+// Archives a channel because its element was deleted
+func (s *ChannelService) archiveElementChannel(channelID string) error {
+	if appErr := s.api.DeleteChannel(channelID); appErr != nil {
+		return errors.Wrapf(appErr, "could not archive channel %s", channelID)
+	}
+	if appErr := s.api.KVSet(elementArchivedChannelKeyPrefix+channelID, []byte("true")); appErr != nil {
+		return errors.Wrapf(appErr, "could not mark channel %s as archived along with its element", channelID)
+	}
+	return nil
+}
+
+// Restores a channel archived along with its element, leaving alone the ones archived by users. Returns whether it was restored.
+func (s *ChannelService) restoreElementChannel(channel *mattermost.Channel) (bool, error) {
+	marker, appErr := s.api.KVGet(elementArchivedChannelKeyPrefix + channel.Id)
+	if appErr != nil {
+		return false, errors.Wrapf(appErr, "could not check whether channel %s was archived along with its element", channel.Id)
+	}
+	if marker == nil {
+		return false, nil
+	}
+	if err := s.restoreChannel(channel); err != nil {
+		return false, err
+	}
+	if appErr := s.api.KVDelete(elementArchivedChannelKeyPrefix + channel.Id); appErr != nil {
+		s.api.LogWarn("couldn't clear archived element marker", "channelId", channel.Id, "err", appErr)
+	}
+	return true, nil
+}
+
+// The plugin API lacks a method to restore channels, but updating them as not deleted restores them
+func (s *ChannelService) restoreChannel(channel *mattermost.Channel) error {
+	channel.DeleteAt = 0
+	if _, appErr := s.api.UpdateChannel(channel); appErr != nil {
+		return errors.Wrapf(appErr, "could not restore channel %s", channel.Id)
+	}
+	return nil
+}
+
+// Gets the Mattermost channel of a section channel, checking that the user has the permission for its type
+func (s *ChannelService) getSectionChannel(userID, channelID string, publicPermission, privatePermission *mattermost.Permission) (*mattermost.Channel, error) {
+	if _, err := s.store.GetChannelByID(channelID); err != nil {
+		return nil, err
+	}
+	channel, appErr := s.api.GetChannel(channelID)
+	if appErr != nil {
+		return nil, errors.Wrapf(ErrNotFound, "channel %s not found", channelID)
+	}
+	permission := privatePermission
+	if channel.Type == mattermost.ChannelTypeOpen {
+		permission = publicPermission
+	}
+	if !s.api.HasPermissionToChannel(userID, channelID, permission) {
+		return nil, errors.Wrapf(ErrForbidden, "user %s cannot manage channel %s", userID, channelID)
+	}
+	return channel, nil
+}
+
+// Checks that a section of the config belongs to an organization
+func (s *ChannelService) checkSection(organizationID, parentID string) error {
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return errors.Wrap(err, "couldn't get config")
+	}
+	for _, organization := range platformConfig.Organizations {
+		if organization.ID != organizationID {
+			continue
+		}
+		if hasSection(organization.Sections, parentID) {
+			return nil
+		}
+		return errors.Wrapf(ErrInvalidInput, "section %s not found in organization %s", parentID, organizationID)
+	}
+	return errors.Wrapf(ErrInvalidInput, "organization %s not found", organizationID)
+}
+
+func hasSection(sections []config.Section, sectionID string) bool {
+	for _, section := range sections {
+		if section.ID == sectionID || hasSection(section.Sections, sectionID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ChannelService) forEachChannelMember(channelID string, apply func(userID string)) {
+	for page := 0; ; page++ {
+		members, appErr := s.api.GetChannelMembers(channelID, page, channelMembersPerPage)
+		if appErr != nil {
+			s.api.LogWarn("couldn't get channel members", "channelId", channelID, "page", page, "err", appErr)
+			return
+		}
+		for _, member := range members {
+			apply(member.UserId)
+		}
+		if len(members) < channelMembersPerPage {
+			return
+		}
+	}
+}
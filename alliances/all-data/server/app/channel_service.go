@@ -2,17 +2,26 @@ package app
 
 import (
 	"fmt"
-	"net/url"
 	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/mattermost/mattermost-plugin-api/cluster"
 	mattermost "github.com/mattermost/mattermost-server/v6/model"
 	"github.com/mattermost/mattermost-server/v6/plugin"
 	"github.com/pkg/errors"
 
 	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
 
-	"regexp"
+const (
+	backlinksKeyVersionKey = "backlinks_key_version"
+	// Bump this whenever the format of the backlinks keys changes, so that stored backlinks get rebuilt on activation
+	backlinksKeyVersion = 1
+	// How often the backlinks of deleted posts are removed
+	deletedBacklinksInterval = 15 * time.Minute
 )
 
 type ChannelService struct {
@@ -21,18 +30,21 @@ type ChannelService struct {
 	mattermostChannelStore MattermostChannelStore
 	categoryService        *CategoryService
 	platformService        *config.PlatformService
-	markdownRegex          *regexp.Regexp // Regex for matching markdown links, e.g. [some text here](a link here). Only the outer structure matters, the link is not strictly checked to be an actual link.
+	linkService            *LinkService
+	templateService        *ChannelTemplateService
+	rebuildingBacklinks    int32 // Set to 1 while the backlinks rebuild job is running
 }
 
 // NewChannelService returns a new channels service
-func NewChannelService(api plugin.API, store ChannelStore, mattermostChannelStore MattermostChannelStore, categoryService *CategoryService, platformService *config.PlatformService) *ChannelService {
+func NewChannelService(api plugin.API, store ChannelStore, mattermostChannelStore MattermostChannelStore, categoryService *CategoryService, platformService *config.PlatformService, linkService *LinkService, templateService *ChannelTemplateService) *ChannelService {
 	return &ChannelService{
 		api:                    api,
 		store:                  store,
 		mattermostChannelStore: mattermostChannelStore,
 		categoryService:        categoryService,
 		platformService:        platformService,
-		markdownRegex:          regexp.MustCompile(`\[(.*?)\]\(([^\(]+)\)`),
+		linkService:            linkService,
+		templateService:        templateService,
 	}
 }
 
@@ -83,15 +95,32 @@ func (s *ChannelService) GetChannelsBySectionID(sectionID string) (GetChannelsRe
 }
 
 func (s *ChannelService) AddChannel(sectionID string, params AddChannelParams) (AddChannelResult, error) {
+	return s.addChannel(sectionID, params, true)
+}
+
+// Templates are not applied to imported channels, which come with their own header and posts
+func (s *ChannelService) addChannel(sectionID string, params AddChannelParams, applyTemplate bool) (AddChannelResult, error) {
 	s.api.LogInfo("Adding channel", "sectionId", sectionID, "params", params)
 	addChannelResult, err := s.store.AddChannel(sectionID, params)
 	if err != nil {
 		return addChannelResult, err
 	}
 
-	if catErr := s.categoryService.addChannelToCategoryByOrganizationID(params.UserID, params.TeamID, addChannelResult.ChannelID, params.OrganizationID); catErr != nil {
+	channel := Channel{
+		ChannelID:      addChannelResult.ChannelID,
+		ParentID:       addChannelResult.ParentID,
+		SectionID:      addChannelResult.SectionID,
+		OrganizationID: params.OrganizationID,
+	}
+	if catErr := s.categoryService.addChannelToOrganizationCategory(params.UserID, params.TeamID, channel); catErr != nil {
 		s.api.LogWarn("couldn't add channel to organization category", "channelID", addChannelResult.ChannelID, "orgID", params.OrganizationID)
 	}
+	// Templates only apply to new channels, existing ones already have their own setup
+	if applyTemplate && strings.TrimSpace(params.ChannelID) == "" {
+		if templateErr := s.templateService.ApplyTemplate(addChannelResult.ChannelID, channel); templateErr != nil {
+			s.api.LogWarn("couldn't apply channel template", "channelID", addChannelResult.ChannelID, "err", templateErr)
+		}
+	}
 	return addChannelResult, nil
 }
 
@@ -102,8 +131,8 @@ func (s *ChannelService) ArchiveChannels(params ArchiveChannelsParams) error {
 	}
 
 	for _, channel := range channels.Items {
-		if deleteErr := s.api.DeleteChannel(channel.ChannelID); deleteErr != nil {
-			s.api.LogWarn("Failed to delete channel", "channelID", channel)
+		if deleteErr := s.archiveElementChannel(channel.ChannelID); deleteErr != nil {
+			s.api.LogWarn("Failed to delete channel", "channelID", channel, "err", deleteErr)
 		}
 	}
 	return nil
@@ -111,63 +140,205 @@ func (s *ChannelService) ArchiveChannels(params ArchiveChannelsParams) error {
 
 // Checks a post's message for the presence of markdown links. In such case, they're added as backlinks.
 func (s *ChannelService) AddBacklinkIfPresent(post *mattermost.Post) {
-	serverConfig := s.api.GetConfig()
-	// ignore messages containing links not related to the plugin
-	siteURL := *serverConfig.ServiceSettings.SiteURL
+	backlinksToAdd := s.getBacklinksFromPost(post)
+	if len(backlinksToAdd) == 0 {
+		return
+	}
+
+	err := s.store.AddBacklinks(post.Id, backlinksToAdd)
+	if err != nil {
+		s.api.LogError("failed to add backlinks", "backlinks", backlinksToAdd, "post", post, "err", err)
+	}
+}
 
-	markdownMatches := s.markdownRegex.FindAllStringSubmatch(post.Message, -1)
-	if len(markdownMatches) == 0 {
+// Recomputes the backlinks of an edited post, so that removed or changed links do not leave stale backlinks behind.
+func (s *ChannelService) UpdateBacklinks(post *mattermost.Post) {
+	if post.DeleteAt != 0 {
+		if err := s.DeleteBacklinks(post.Id); err != nil {
+			s.api.LogError("failed to delete backlinks", "post", post, "err", err)
+		}
 		return
 	}
-	backlinksToAdd := []BacklinkData{}
 
-	for _, match := range markdownMatches {
-		markdownText := match[1]
-		markdownLink := match[2]
+	backlinks := s.getBacklinksFromPost(post)
+	if err := s.store.SetBacklinks(post.Id, backlinks); err != nil {
+		s.api.LogError("failed to update backlinks", "backlinks", backlinks, "post", post, "err", err)
+	}
+}
+
+func (s *ChannelService) DeleteBacklinks(postID string) error {
+	s.api.LogInfo("Deleting backlinks", "postId", postID)
+	return s.store.DeleteBacklinksByPostID(postID)
+}
+
+// Starts the job removing the backlinks of deleted posts, since Mattermost has no hook for post deletion
+func (s *ChannelService) StartDeletedBacklinksJob() (*cluster.Job, error) {
+	return cluster.Schedule(s.api, "CSA_deletedBacklinksJob", cluster.MakeWaitForInterval(deletedBacklinksInterval), func() {
+		deleted, err := s.store.DeleteBacklinksOfDeletedPosts()
+		if err != nil {
+			s.api.LogError("failed to delete backlinks of deleted posts", "err", err)
+			return
+		}
+		if deleted > 0 {
+			s.api.LogInfo("Deleted backlinks of deleted posts", "backlinks", deleted)
+		}
+	})
+}
 
-		parsedURL, err := url.Parse(markdownLink)
-		if err == nil {
-			if strings.Contains(siteURL, parsedURL.Host) {
-				queryAndFragment := parsedURL.RawQuery
-				if parsedURL.Fragment != "" {
-					queryAndFragment = fmt.Sprintf("%s#%s", parsedURL.RawQuery, parsedURL.Fragment)
+// Starts a background job rebuilding all the backlinks from the existing posts history. Only system admins can run it.
+func (s *ChannelService) RebuildBacklinks(userID string) error {
+	if !s.api.HasPermissionTo(userID, mattermost.PermissionManageSystem) {
+		return errors.Wrapf(ErrForbidden, "user %s cannot rebuild backlinks", userID)
+	}
+	return s.startBacklinksRebuild(userID)
+}
+
+// Rebuilds the backlinks in background if they were stored with an outdated key format, e.g. before links were canonicalized.
+func (s *ChannelService) RebuildOutdatedBacklinks() error {
+	var keyVersion int
+	if err := kvGetJSON(s.api, backlinksKeyVersionKey, &keyVersion); err != nil {
+		return errors.Wrap(err, "unable to get backlinks key version")
+	}
+	if keyVersion >= backlinksKeyVersion {
+		return nil
+	}
+	s.api.LogInfo("Backlinks have an outdated key format, rebuilding them", "keyVersion", keyVersion)
+	return s.startBacklinksRebuild("")
+}
+
+func (s *ChannelService) startBacklinksRebuild(userID string) error {
+	if !atomic.CompareAndSwapInt32(&s.rebuildingBacklinks, 0, 1) {
+		return errors.Wrap(ErrAlreadyRunning, "backlinks are already being rebuilt")
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&s.rebuildingBacklinks, 0)
+
+		mutex, err := cluster.NewMutex(s.api, "CSA_backlinksMutex")
+		if err != nil {
+			s.api.LogError("failed creating cluster mutex to rebuild backlinks", "err", err)
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		postsCount, backlinksCount, err := s.rebuildBacklinks()
+		if err != nil {
+			s.api.LogError("failed to rebuild backlinks", "userId", userID, "err", err)
+			return
+		}
+		if err := kvSetJSON(s.api, backlinksKeyVersionKey, backlinksKeyVersion); err != nil {
+			s.api.LogWarn("failed to store backlinks key version", "err", err)
+		}
+		s.api.LogInfo("Backlinks rebuilt", "userId", userID, "posts", postsCount, "backlinks", backlinksCount)
+	}()
+	return nil
+}
+
+func (s *ChannelService) rebuildBacklinks() (int, int, error) {
+	teams, err := s.api.GetTeams()
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "unable to get teams while rebuilding backlinks")
+	}
+
+	// All the backlinks are replaced, so direct and group channels are scanned too, which belong to no team
+	teamIDs := []string{""}
+	for _, team := range teams {
+		teamIDs = append(teamIDs, team.Id)
+	}
+
+	backlinksByPostID := make(map[string][]BacklinkData)
+	postsCount := 0
+	backlinksCount := 0
+	for _, teamID := range teamIDs {
+		channels, err := s.mattermostChannelStore.GetChannelsForTeam(teamID)
+		if err != nil {
+			return 0, 0, errors.Wrapf(err, "unable to get channels of team %s while rebuilding backlinks", teamID)
+		}
+		for _, channel := range channels.Items {
+			page := 0
+			perPage := 1000
+			for {
+				postList, err := s.api.GetPostsForChannel(channel.Id, page, perPage)
+				if err != nil {
+					return 0, 0, errors.Wrapf(err, "unable to get posts of channel %s while rebuilding backlinks", channel.Id)
+				}
+				if len(postList.Order) == 0 {
+					break
 				}
-				// This can happen for organization links for example
-				if queryAndFragment == "" {
-					queryAndFragment = parsedURL.Path
+				for _, postID := range postList.Order {
+					post := postList.Posts[postID]
+					// ignore original text for edited messages
+					if post.OriginalId != "" || post.DeleteAt != 0 {
+						continue
+					}
+					postsCount++
+					if backlinks := s.getBacklinksFromPost(post); len(backlinks) > 0 {
+						backlinksByPostID[post.Id] = backlinks
+						backlinksCount += len(backlinks)
+					}
 				}
-				backlinksToAdd = append(backlinksToAdd, BacklinkData{MarkdownText: markdownText, MarkdownLink: queryAndFragment})
+				page++
 			}
 		}
 	}
 
-	if len(backlinksToAdd) == 0 {
-		return
+	if err := s.store.ResetBacklinks(backlinksByPostID); err != nil {
+		return 0, 0, errors.Wrap(err, "unable to store rebuilt backlinks")
 	}
+	return postsCount, backlinksCount, nil
+}
 
-	err := s.store.AddBacklinks(post.Id, backlinksToAdd)
+// Extracts the backlinks from the links in a post's message, attachments and props, ignoring links not related to the plugin.
+// Backlinks are stored by the canonical key of the linked element, so the same element always gets the same key.
+// Links rendered without a text, e.g. bare URLs, are stored without a text and get the name of the linked element when read.
+func (s *ChannelService) getBacklinksFromPost(post *mattermost.Post) []BacklinkData {
+	backlinks := []BacklinkData{}
+	links := link.ExtractPostLinks(post)
+	if len(links) == 0 {
+		return backlinks
+	}
+
+	parser, resolver, err := s.linkService.GetLinkModel()
 	if err != nil {
-		s.api.LogError("failed to add backlinks", "backlinks", backlinksToAdd, "post", post, "err", err)
+		s.api.LogError("failed to get backlinks from post", "postId", post.Id, "err", err)
+		return backlinks
 	}
+	backlinksByKey := map[string]int{}
+	for _, postLink := range links {
+		// ignore links not related to the plugin
+		reference, ok := parser.Parse(postLink.URL)
+		if !ok {
+			continue
+		}
+		key := resolver.Canonicalize(reference).Key()
+
+		// the same element linked multiple times is a single backlink, keeping the first available text
+		if index, found := backlinksByKey[key]; found {
+			if backlinks[index].MarkdownText == "" {
+				backlinks[index].MarkdownText = postLink.Text
+			}
+			continue
+		}
+		backlinksByKey[key] = len(backlinks)
+		backlinks = append(backlinks, BacklinkData{MarkdownText: postLink.Text, MarkdownLink: key})
+	}
+	return backlinks
 }
 
 // Fetches the backlinks of an element identified by its full URL, sorted by most recent first
 func (s *ChannelService) GetBacklinks(elementURL string, userID string) (GetBacklinksResult, error) {
 	s.api.LogInfo("Getting backlinks for url", "url", elementURL)
-	parsedURL, err := url.Parse(elementURL)
+	reference, ok, err := s.linkService.ParseURL(elementURL)
 	if err != nil {
 		s.api.LogError("failed to get backlinks", "couldn't parse url", err)
 		return GetBacklinksResult{}, err
 	}
-	queryAndFragment := parsedURL.RawQuery
-	if parsedURL.Fragment != "" {
-		queryAndFragment = fmt.Sprintf("%s#%s", parsedURL.RawQuery, parsedURL.Fragment)
-	}
-	if queryAndFragment == "" {
-		queryAndFragment = parsedURL.Path
+	if !ok {
+		return GetBacklinksResult{}, errors.Wrapf(ErrNotFound, "%s is not a link to the platform", elementURL)
 	}
 
-	dbBacklinks, err := s.store.GetBacklinks(queryAndFragment)
+	dbBacklinks, err := s.store.GetBacklinks(reference.Key())
 	if err != nil {
 		return GetBacklinksResult{}, err
 	}
@@ -175,6 +346,14 @@ func (s *ChannelService) GetBacklinks(elementURL string, userID string) (GetBack
 	if err != nil {
 		return GetBacklinksResult{}, err
 	}
+	stances, err := s.store.GetBacklinkStances(reference.Key())
+	if err != nil {
+		return GetBacklinksResult{}, err
+	}
+	stancesByPostID := make(map[string]string, len(stances))
+	for _, stance := range stances {
+		stancesByPostID[stance.PostID] = stance.Stance
+	}
 
 	backlinks := []Backlink{}
 	for _, backlink := range dbBacklinks {
@@ -222,10 +401,12 @@ func (s *ChannelService) GetBacklinks(elementURL string, userID string) (GetBack
 		backlinks = append(backlinks, Backlink{
 			ID:          backlink.PostID,
 			Message:     post.Message,
+			AuthorID:    user.Id,
 			AuthorName:  user.GetDisplayName(mattermost.ShowNicknameFullName),
 			ChannelName: channel.DisplayName,
 			SectionName: sectionName,
 			CreateAt:    post.CreateAt,
+			Stance:      stancesByPostID[backlink.PostID],
 		})
 	}
 
@@ -234,77 +415,434 @@ func (s *ChannelService) GetBacklinks(elementURL string, userID string) (GetBack
 		return backlinks[i].CreateAt > backlinks[j].CreateAt
 	})
 
-	channelsCountMap := make(map[string]*ChannelsCount)
-	for _, backlink := range backlinks {
-		if count, found := channelsCountMap[backlink.ChannelName]; found {
-			count.Count++
-		} else {
-			channelsCountMap[backlink.ChannelName] = &ChannelsCount{backlink.ChannelName, 1, backlink.SectionName}
+	channelsCount := countBacklinks(backlinks, func(backlink Backlink) string {
+		return backlink.ChannelName
+	}, func(name string, group []Backlink) *ChannelsCount {
+		return &ChannelsCount{name, len(group), group[0].SectionName}
+	})
+	usersCount := countBacklinks(backlinks, func(backlink Backlink) string {
+		return backlink.AuthorID
+	}, func(userID string, group []Backlink) *UsersCount {
+		return &UsersCount{userID, group[0].AuthorName, len(group)}
+	})
+	sectionsCount := countBacklinks(backlinks, func(backlink Backlink) string {
+		return backlink.SectionName
+	}, func(name string, group []Backlink) *SectionsCount {
+		return &SectionsCount{name, len(group)}
+	})
+
+	// Order by count desc
+	sort.SliceStable(channelsCount, func(i, j int) bool {
+		return channelsCount[i].Count > channelsCount[j].Count
+	})
+	sort.SliceStable(usersCount, func(i, j int) bool {
+		return usersCount[i].Count > usersCount[j].Count
+	})
+	sort.SliceStable(sectionsCount, func(i, j int) bool {
+		return sectionsCount[i].Count > sectionsCount[j].Count
+	})
+
+	return GetBacklinksResult{
+		Items:        backlinks,
+		ChannelCount: channelsCount,
+		UserCount:    usersCount,
+		SectionCount: sectionsCount,
+		StanceCount:  s.getBacklinksStancesCount(backlinks),
+		Histogram:    s.getBacklinksHistogram(backlinks),
+	}, nil
+}
+
+// Counts the backlinks per stance, in the order of the stances and followed by the untagged ones.
+// All stances are counted, even if no backlink has them, so that misalignment shows up as a missing bar.
+func (s *ChannelService) getBacklinksStancesCount(backlinks []Backlink) []*StancesCount {
+	return countBacklinks(backlinks, func(backlink Backlink) string {
+		return backlink.Stance
+	}, func(stance string, group []Backlink) *StancesCount {
+		return &StancesCount{stance, len(group)}
+	}, append(Stances, "")...)
+}
+
+// Tags the backlink of a post to an element with the stance of the post towards it, or removes the tag if the stance is empty.
+// Any member of the channel of the post can tag it, the last one tagging a backlink replaces the previous stance.
+func (s *ChannelService) SetBacklinkStance(userID, postID string, params SetBacklinkStanceParams) (BacklinkStance, error) {
+	if params.Stance != "" && !isStance(params.Stance) {
+		return BacklinkStance{}, errors.Wrapf(ErrInvalidInput, "invalid stance %s, use one of %s", params.Stance, strings.Join(Stances, ", "))
+	}
+	reference, ok, err := s.linkService.ParseURL(params.ElementURL)
+	if err != nil {
+		return BacklinkStance{}, err
+	}
+	if !ok {
+		return BacklinkStance{}, errors.Wrapf(ErrInvalidInput, "%s is not a link to the platform", params.ElementURL)
+	}
+	if err := s.checkCanReadPost(userID, postID); err != nil {
+		return BacklinkStance{}, err
+	}
+
+	key := reference.Key()
+	dbBacklinks, err := s.store.GetBacklinks(key)
+	if err != nil {
+		return BacklinkStance{}, err
+	}
+	linked := false
+	for _, backlink := range dbBacklinks {
+		if backlink.PostID == postID {
+			linked = true
+			break
 		}
 	}
+	if !linked {
+		return BacklinkStance{}, errors.Wrapf(ErrInvalidInput, "post %s does not link %s", postID, params.ElementURL)
+	}
 
-	channelsCount := []*ChannelsCount{}
-	for _, count := range channelsCountMap {
-		channelsCount = append(channelsCount, count)
+	stance := BacklinkStance{
+		PostID:          postID,
+		ElementLinkPart: key,
+		Stance:          params.Stance,
+		UserID:          userID,
+		UpdateAt:        mattermost.GetMillis(),
+	}
+	if params.Stance == "" {
+		if err := s.store.DeleteBacklinkStance(postID, key); err != nil {
+			return BacklinkStance{}, err
+		}
+		return stance, nil
 	}
+	if err := s.store.SetBacklinkStance(stance); err != nil {
+		return BacklinkStance{}, err
+	}
+	s.api.LogInfo("Tagged backlink stance", "postId", postID, "key", key, "stance", params.Stance, "userId", userID)
+	return stance, nil
+}
 
-	// Order by count desc
-	sort.Slice(channelsCount, func(i, j int) bool {
-		return channelsCount[i].Count > channelsCount[j].Count
+// Fetches the stances of a post towards the elements it links, only tagged backlinks are returned
+func (s *ChannelService) GetBacklinkStances(userID, postID string) (GetBacklinkStancesResult, error) {
+	if err := s.checkCanReadPost(userID, postID); err != nil {
+		return GetBacklinkStancesResult{}, err
+	}
+	stances, err := s.store.GetBacklinkStancesByPostID(postID)
+	if err != nil {
+		return GetBacklinkStancesResult{}, err
+	}
+	return GetBacklinkStancesResult{Items: stances}, nil
+}
+
+func (s *ChannelService) checkCanReadPost(userID, postID string) error {
+	post, appErr := s.api.GetPost(postID)
+	if appErr != nil {
+		return errors.Wrapf(ErrNotFound, "post %s not found", postID)
+	}
+	if !s.api.HasPermissionToChannel(userID, post.ChannelId, mattermost.PermissionReadChannel) {
+		return errors.Wrapf(ErrForbidden, "user %s cannot read posts in channel %s", userID, post.ChannelId)
+	}
+	return nil
+}
+
+func isStance(stance string) bool {
+	for _, existing := range Stances {
+		if existing == stance {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts the backlinks created per day, oldest day first
+func (s *ChannelService) getBacklinksHistogram(backlinks []Backlink) []*HistogramCount {
+	histogram := countBacklinks(backlinks, func(backlink Backlink) string {
+		return time.UnixMilli(backlink.CreateAt).UTC().Format("2006-01-02")
+	}, func(date string, group []Backlink) *HistogramCount {
+		return &HistogramCount{date, len(group)}
 	})
 
-	// TODO: add user backlinks here similar to channel backlinks
+	// Order by date asc
+	sort.Slice(histogram, func(i, j int) bool {
+		return histogram[i].Date < histogram[j].Date
+	})
+	return histogram
+}
+
+// Groups the backlinks by key and builds the count of each group, in the order the keys are first found.
+// The given keys come first and are counted even if no backlink has them, with an empty group.
+func countBacklinks[T any](backlinks []Backlink, keyOf func(Backlink) string, newCount func(key string, group []Backlink) *T, keys ...string) []*T {
+	keys = append([]string{}, keys...)
+	groups := make(map[string][]Backlink, len(keys))
+	for _, key := range keys {
+		groups[key] = []Backlink{}
+	}
+	for _, backlink := range backlinks {
+		key := keyOf(backlink)
+		if _, found := groups[key]; !found {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], backlink)
+	}
 
-	return GetBacklinksResult{Items: backlinks, ChannelCount: channelsCount}, nil
+	counts := make([]*T, 0, len(keys))
+	for _, key := range keys {
+		counts = append(counts, newCount(key, groups[key]))
+	}
+	return counts
 }
 
-func (s *ChannelService) ExportChannel(channelID string, params ExportChannelParams) (*STIXChannel, error) {
-	s.api.LogInfo("Exporting channel", "channelID", channelID)
-	channel, err := s.api.GetChannel(channelID)
+// Fetches the most referenced elements and the users referencing elements the most in the channels of an organization.
+// Only channels the user is a member of are taken into account.
+func (s *ChannelService) GetTopBacklinks(organizationID string, userID string, limit int) (GetTopBacklinksResult, error) {
+	s.api.LogInfo("Getting top backlinks for organization", "organizationId", organizationID, "limit", limit)
+	dbBacklinks, err := s.store.GetBacklinksByOrganizationID(organizationID)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to call GetChannel during channel export")
+		return GetTopBacklinksResult{}, err
 	}
 
-	var STIXPosts []*STIXPost
-	page := 0
-	perPage := 1000
+	membershipCache := make(map[string]bool)
+	elementsCountMap := make(map[string]*ElementsCount)
+	usersCountMap := make(map[string]*UsersCount)
+	for _, backlink := range dbBacklinks {
+		isMember, found := membershipCache[backlink.ChannelID]
+		if !found {
+			_, membershipErr := s.api.GetChannelMember(backlink.ChannelID, userID)
+			isMember = membershipErr == nil
+			membershipCache[backlink.ChannelID] = isMember
+		}
+		// Do not count backlinks from channels the user isn't in
+		if !isMember {
+			continue
+		}
 
-	for {
-		postList, err := s.api.GetPostsForChannel(channelID, page, perPage)
-		if err != nil {
-			return nil, errors.Wrap(err, "unable to call GetPostsForChannel during channel export")
+		if count, found := elementsCountMap[backlink.ElementLinkPart]; found {
+			count.Count++
+			if count.Name == "" {
+				count.Name = backlink.ElementMarkdownPath
+			}
+		} else {
+			elementsCountMap[backlink.ElementLinkPart] = &ElementsCount{backlink.ElementMarkdownPath, backlink.ElementLinkPart, 1}
 		}
+		if count, found := usersCountMap[backlink.UserID]; found {
+			count.Count++
+		} else {
+			usersCountMap[backlink.UserID] = &UsersCount{UserID: backlink.UserID, Count: 1}
+		}
+	}
 
-		if postList.ToSlice() == nil {
-			break
+	elementsCount := []*ElementsCount{}
+	for _, count := range elementsCountMap {
+		elementsCount = append(elementsCount, count)
+	}
+
+	// Order by count desc, then by link so that ties are cut at the limit the same way every time
+	sort.SliceStable(elementsCount, func(i, j int) bool {
+		if elementsCount[i].Count != elementsCount[j].Count {
+			return elementsCount[i].Count > elementsCount[j].Count
 		}
+		return elementsCount[i].Link < elementsCount[j].Link
+	})
+	if limit > 0 && len(elementsCount) > limit {
+		elementsCount = elementsCount[:limit]
+	}
+	s.resolveElementsCountNames(elementsCount)
 
-		STIXPostsPerPage := make([]*STIXPost, 0, len(postList.Order))
-		usersCache := make(map[string]*mattermost.User)
+	usersCount := []*UsersCount{}
+	for _, count := range usersCountMap {
+		usersCount = append(usersCount, count)
+	}
+
+	// Order by count desc, then by user id
+	sort.SliceStable(usersCount, func(i, j int) bool {
+		if usersCount[i].Count != usersCount[j].Count {
+			return usersCount[i].Count > usersCount[j].Count
+		}
+		return usersCount[i].UserID < usersCount[j].UserID
+	})
+	if limit > 0 && len(usersCount) > limit {
+		usersCount = usersCount[:limit]
+	}
+	for _, count := range usersCount {
+		user, err := s.api.GetUser(count.UserID)
+		if err != nil {
+			s.api.LogWarn("failed to fetch user while fetching top backlinks", "userId", count.UserID, "err", err)
+			count.Name = count.UserID
+			continue
+		}
+		count.Name = user.GetDisplayName(mattermost.ShowNicknameFullName)
+	}
 
-		for _, key := range postList.Order {
-			post := postList.Posts[key]
+	return GetTopBacklinksResult{Elements: elementsCount, Users: usersCount}, nil
+}
 
-			// ignore original text for edited messages
-			if post.OriginalId != "" {
-				continue
+// Names the elements only linked without a text, which are resolved when read rather than when posted
+func (s *ChannelService) resolveElementsCountNames(elementsCount []*ElementsCount) {
+	var resolver *link.Resolver
+	for _, count := range elementsCount {
+		if count.Name != "" {
+			continue
+		}
+		count.Name = count.Link
+		if resolver == nil {
+			var err error
+			if _, resolver, err = s.linkService.GetLinkModel(); err != nil {
+				s.api.LogWarn("failed to resolve names of top backlinks", "err", err)
+				return
 			}
+		}
+		if name, err := s.linkService.ResolveName(resolver, count.Link); err == nil {
+			count.Name = name
+		}
+	}
+}
+
+// Builds the bipartite graph of the backlinks of an organization, or of all organizations if no organization is provided.
+// Only backlinks from channels the user is a member of are included.
+func (s *ChannelService) GetBacklinkGraph(organizationID string, userID string) (*BacklinkGraph, error) {
+	s.api.LogInfo("Getting backlink graph", "organizationId", organizationID)
+	var dbBacklinks []BacklinkEntity
+	var err error
+	if organizationID != "" {
+		dbBacklinks, err = s.store.GetBacklinksByOrganizationID(organizationID)
+	} else {
+		dbBacklinks, err = s.store.GetAllBacklinks()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	parser, resolver, err := s.linkService.GetLinkModel()
+	if err != nil {
+		return nil, err
+	}
+	siteURL := *s.api.GetConfig().ServiceSettings.SiteURL
+
+	graph := &BacklinkGraph{Posts: []*BacklinkGraphPost{}, Elements: []*BacklinkGraphElement{}, Edges: []*BacklinkGraphEdge{}}
+	channelsCache := make(map[string]*mattermost.Channel)
+	teamNamesCache := make(map[string]string)
+	usersCache := make(map[string]*mattermost.User)
+	membershipCache := make(map[string]bool)
+	postsMap := make(map[string]bool)
+	elementsMap := make(map[string]*BacklinkGraphElement)
+	for _, backlink := range dbBacklinks {
+		isMember, found := membershipCache[backlink.ChannelID]
+		if !found {
+			_, membershipErr := s.api.GetChannelMember(backlink.ChannelID, userID)
+			isMember = membershipErr == nil
+			membershipCache[backlink.ChannelID] = isMember
+		}
+		// Do not include backlinks from channels the user isn't in
+		if !isMember {
+			continue
+		}
 
-			// Ignore thread messages - we'll get them once we find the root message
-			if post.RootId != "" {
+		if !postsMap[backlink.PostID] {
+			post, err := s.getBacklinkGraphPost(backlink, siteURL, channelsCache, teamNamesCache, usersCache)
+			if err != nil {
+				s.api.LogWarn("failed to get post for backlink graph", "postId", backlink.PostID, "err", err)
 				continue
 			}
+			graph.Posts = append(graph.Posts, post)
+			postsMap[backlink.PostID] = true
+		}
+		element, found := elementsMap[backlink.ElementLinkPart]
+		if !found {
+			element = s.getBacklinkGraphElement(backlink, parser, resolver)
+			graph.Elements = append(graph.Elements, element)
+			elementsMap[backlink.ElementLinkPart] = element
+		}
+		text := backlink.ElementMarkdownPath
+		if text == "" {
+			text = element.Name
+		}
+		graph.Edges = append(graph.Edges, &BacklinkGraphEdge{
+			ID:     backlink.ID,
+			Source: backlink.PostID,
+			Target: backlink.ElementLinkPart,
+			Text:   text,
+		})
+	}
+	return graph, nil
+}
 
-			STIXPost := ToStixPost(s.api, post, true, params.PinnedOnly, usersCache)
-			// post can be nil if pinned only is true and the post is not pinned
-			if STIXPost != nil {
-				STIXPostsPerPage = append(STIXPostsPerPage, STIXPost)
-			}
+func (s *ChannelService) getBacklinkGraphPost(
+	backlink BacklinkEntity,
+	siteURL string,
+	channelsCache map[string]*mattermost.Channel,
+	teamNamesCache map[string]string,
+	usersCache map[string]*mattermost.User,
+) (*BacklinkGraphPost, error) {
+	channel, found := channelsCache[backlink.ChannelID]
+	if !found {
+		var appErr *mattermost.AppError
+		if channel, appErr = s.api.GetChannel(backlink.ChannelID); appErr != nil {
+			return nil, errors.Wrap(appErr, "unable to get channel")
 		}
+		channelsCache[backlink.ChannelID] = channel
+	}
+	teamName, found := teamNamesCache[channel.TeamId]
+	if !found {
+		team, appErr := s.api.GetTeam(channel.TeamId)
+		if appErr != nil {
+			return nil, errors.Wrap(appErr, "unable to get team")
+		}
+		teamName = team.Name
+		teamNamesCache[channel.TeamId] = teamName
+	}
+	user, found := usersCache[backlink.UserID]
+	if !found {
+		var appErr *mattermost.AppError
+		if user, appErr = s.api.GetUser(backlink.UserID); appErr != nil {
+			return nil, errors.Wrap(appErr, "unable to get user")
+		}
+		usersCache[backlink.UserID] = user
+	}
+	return &BacklinkGraphPost{
+		ID:          backlink.PostID,
+		URL:         fmt.Sprintf("%s/%s/pl/%s", strings.TrimSuffix(siteURL, "/"), teamName, backlink.PostID),
+		Message:     backlink.Message,
+		AuthorID:    backlink.UserID,
+		AuthorName:  user.GetDisplayName(mattermost.ShowNicknameFullName),
+		ChannelID:   backlink.ChannelID,
+		ChannelName: channel.DisplayName,
+		CreateAt:    backlink.CreateAt,
+		RootID:      backlink.RootID,
+	}, nil
+}
 
-		STIXPosts = append(STIXPosts, STIXPostsPerPage...)
-		page++
+func (s *ChannelService) getBacklinkGraphElement(backlink BacklinkEntity, parser *link.Parser, resolver *link.Resolver) *BacklinkGraphElement {
+	element := &BacklinkGraphElement{Key: backlink.ElementLinkPart, Name: backlink.ElementMarkdownPath}
+	reference, err := link.ParseKey(backlink.ElementLinkPart)
+	if err != nil {
+		s.api.LogWarn("failed to parse backlink key", "key", backlink.ElementLinkPart, "err", err)
+		return element
+	}
+	element.URL = parser.URL(resolver.Canonicalize(reference))
+	if name, err := s.linkService.ResolveName(resolver, backlink.ElementLinkPart); err == nil {
+		element.Name = name
+	} else if element.Name == "" {
+		element.Name = element.URL
 	}
+	return element
+}
 
-	return ToStixChannel(channel, STIXPosts, params.References), nil
+// Fetches the elements linked by the posts of a channel, by post id, resolving each element once
+func (s *ChannelService) GetExportBacklinks(channelID string) (map[string][]*ExportBacklink, error) {
+	backlinks := make(map[string][]*ExportBacklink)
+	dbBacklinks, err := s.store.GetBacklinksByChannelID(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if len(dbBacklinks) == 0 {
+		return backlinks, nil
+	}
+	parser, resolver, err := s.linkService.GetLinkModel()
+	if err != nil {
+		return nil, err
+	}
+	elements := make(map[string]*ExportBacklink)
+	for _, backlink := range dbBacklinks {
+		element, found := elements[backlink.ElementLinkPart]
+		if !found {
+			graphElement := s.getBacklinkGraphElement(backlink, parser, resolver)
+			element = &ExportBacklink{Key: graphElement.Key, Name: graphElement.Name, URL: graphElement.URL}
+			elements[backlink.ElementLinkPart] = element
+		}
+		backlinks[backlink.PostID] = append(backlinks[backlink.PostID], element)
+	}
+	return backlinks, nil
 }
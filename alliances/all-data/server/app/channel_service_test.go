@@ -0,0 +1,88 @@
+package app
+
+import (
+	"testing"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountBacklinks(t *testing.T) {
+	backlinks := []Backlink{
+		{AuthorID: "bob", AuthorName: "Bob", ChannelName: "incident", SectionName: "Issues", Stance: StanceQuestions, CreateAt: 1680000000000},
+		{AuthorID: "alice", AuthorName: "Alice", ChannelName: "flood", SectionName: "Issues", CreateAt: 1680000060000},
+		{AuthorID: "bob", AuthorName: "Bob", ChannelName: "incident", SectionName: "Incidents", Stance: StanceQuestions, CreateAt: 1680100000000},
+	}
+
+	t.Run("counts the groups in the order they are found", func(t *testing.T) {
+		usersCount := countBacklinks(backlinks, func(backlink Backlink) string {
+			return backlink.AuthorID
+		}, func(userID string, group []Backlink) *UsersCount {
+			return &UsersCount{userID, group[0].AuthorName, len(group)}
+		})
+		assert.Equal(t, []*UsersCount{{"bob", "Bob", 2}, {"alice", "Alice", 1}}, usersCount)
+	})
+
+	t.Run("counts no group without backlinks", func(t *testing.T) {
+		sectionsCount := countBacklinks(nil, func(backlink Backlink) string {
+			return backlink.SectionName
+		}, func(name string, group []Backlink) *SectionsCount {
+			return &SectionsCount{name, len(group)}
+		})
+		assert.Empty(t, sectionsCount)
+	})
+
+	t.Run("counts every stance followed by the untagged backlinks", func(t *testing.T) {
+		s := &ChannelService{}
+		assert.Equal(t, []*StancesCount{
+			{StanceSupports, 0},
+			{StanceContradicts, 0},
+			{StanceQuestions, 2},
+			{StanceMisrepresents, 0},
+			{"", 1},
+		}, s.getBacklinksStancesCount(backlinks))
+	})
+
+	t.Run("counts the backlinks per day", func(t *testing.T) {
+		s := &ChannelService{}
+		assert.Equal(t, []*HistogramCount{{"2023-03-28", 2}, {"2023-03-29", 1}}, s.getBacklinksHistogram(backlinks))
+	})
+}
+
+// testChannelStore serves the backlinks of an organization
+type testChannelStore struct {
+	ChannelStore
+	backlinks []BacklinkEntity
+}
+
+func (s *testChannelStore) GetBacklinksByOrganizationID(string) ([]BacklinkEntity, error) {
+	return s.backlinks, nil
+}
+
+func TestGetTopBacklinks(t *testing.T) {
+	backlink := func(userID, key string) BacklinkEntity {
+		return BacklinkEntity{ChannelID: "channelid", UserID: userID, ElementLinkPart: key, ElementMarkdownPath: "Org." + key}
+	}
+	store := &testChannelStore{backlinks: []BacklinkEntity{
+		backlink("carol", "c"), backlink("bob", "b"), backlink("carol", "a"), backlink("alice", "a"),
+	}}
+	api := &plugintest.API{}
+	api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	api.On("LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	api.On("GetChannelMember", "channelid", aliceID).Return(&mattermost.ChannelMember{}, nil)
+	api.On("GetUser", "alice").Return(&mattermost.User{Id: "alice", Username: "alice"}, nil)
+	api.On("GetUser", "carol").Return(nil, mattermost.NewAppError("GetUser", "app.user.missing", nil, "", 404))
+	service := &ChannelService{api: api, store: store}
+
+	// Ties are broken by link and user id, so that the same ones are kept at the limit every time
+	for i := 0; i < 10; i++ {
+		result, err := service.GetTopBacklinks("organizationid", aliceID, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []*ElementsCount{{Name: "Org.a", Link: "a", Count: 2}, {Name: "Org.b", Link: "b", Count: 1}}, result.Elements)
+		// Users that cannot be fetched are named by id
+		assert.Equal(t, []*UsersCount{{UserID: "carol", Name: "carol", Count: 2}, {UserID: "alice", Name: "alice", Count: 1}}, result.Users)
+	}
+}
@@ -22,9 +22,47 @@ type ChannelStore interface {
 
 	LinkChannelToOrganization(channelID, organizationID string) error
 
+	// MoveChannel links a channel to another section, possibly of another organization
+	MoveChannel(channelID string, params MoveChannelParams) error
+
+	// UnlinkChannel removes a channel from its section, leaving the Mattermost channel untouched
+	UnlinkChannel(channelID string) error
+
 	AddBacklinks(postID string, backlinks []BacklinkData) error
 
+	// SetBacklinks replaces the backlinks of a post with the given ones
+	SetBacklinks(postID string, backlinks []BacklinkData) error
+
+	DeleteBacklinksByPostID(postID string) error
+
+	// ResetBacklinks replaces all the stored backlinks with the given ones, mapped by post id
+	ResetBacklinks(backlinksByPostID map[string][]BacklinkData) error
+
 	GetBacklinks(elementLinkPart string) ([]BacklinkEntity, error)
 
+	// GetBacklinksByOrganizationID retrieves the backlinks of the posts in the channels of an organization, along with their author and channel
+	GetBacklinksByOrganizationID(organizationID string) ([]BacklinkEntity, error)
+
+	// GetAllBacklinks retrieves the backlinks of the posts in all the organization channels, along with their author and channel
+	GetAllBacklinks() ([]BacklinkEntity, error)
+
+	// GetBacklinksByChannelID retrieves the backlinks of the non deleted posts in a channel
+	GetBacklinksByChannelID(channelID string) ([]BacklinkEntity, error)
+
 	DeleteBacklink(ID string) error
+
+	// DeleteBacklinksOfDeletedPosts removes the backlinks, and their stances, of the posts that have been deleted, returning how many backlinks were removed
+	DeleteBacklinksOfDeletedPosts() (int64, error)
+
+	// SetBacklinkStance tags the backlink of a post to an element with a stance, replacing the previous one
+	SetBacklinkStance(stance BacklinkStance) error
+
+	// DeleteBacklinkStance removes the stance of the backlink of a post to an element
+	DeleteBacklinkStance(postID, elementLinkPart string) error
+
+	// GetBacklinkStancesByPostID retrieves the stances of the backlinks of a post
+	GetBacklinkStancesByPostID(postID string) ([]BacklinkStance, error)
+
+	// GetBacklinkStances retrieves the stances of the backlinks to an element
+	GetBacklinkStances(elementLinkPart string) ([]BacklinkStance, error)
 }
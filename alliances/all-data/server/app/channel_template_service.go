@@ -0,0 +1,225 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/util"
+)
+
+const (
+	// Mattermost max lengths for channel headers and purposes
+	channelHeaderMaxLength  = 1024
+	channelPurposeMaxLength = 250
+	// Post prop marking the posts made by the bot when applying a template
+	channelTemplatePostProp = "channel_template"
+)
+
+type ChannelTemplateService struct {
+	api            plugin.API
+	linkService    *LinkService
+	providerClient *link.ProviderClient
+	botID          string
+}
+
+// NewChannelTemplateService returns a new service applying the channel templates of the sections
+func NewChannelTemplateService(api plugin.API, linkService *LinkService, providerClient *link.ProviderClient, botID string) *ChannelTemplateService {
+	return &ChannelTemplateService{
+		api:            api,
+		linkService:    linkService,
+		providerClient: providerClient,
+		botID:          botID,
+	}
+}
+
+// Applies the template of the section a channel has just been created for, if any.
+// Each step is best effort, so that a failing one does not prevent the others nor the channel creation.
+func (s *ChannelTemplateService) ApplyTemplate(channelID string, channel Channel) error {
+	parser, resolver, err := s.linkService.GetLinkModel()
+	if err != nil {
+		return err
+	}
+	organization, found := resolver.FindOrganization(channel.OrganizationID)
+	if !found {
+		return nil
+	}
+	section, found := resolver.FindSection(channel.OrganizationID, channel.ParentID)
+	if !found || section.ChannelTemplate == nil {
+		return nil
+	}
+	mattermostChannel, appErr := s.api.GetChannel(channelID)
+	if appErr != nil {
+		return errors.Wrapf(appErr, "unable to get channel %s to apply its template", channelID)
+	}
+
+	s.api.LogInfo("Applying channel template", "channelId", channelID, "sectionId", section.ID)
+	template := section.ChannelTemplate
+	reference := link.Reference{
+		OrganizationID: organization.ID,
+		SectionID:      section.ID,
+		SectionName:    link.FormatName(section.Name),
+		ElementID:      channel.SectionID,
+	}
+	elementURL := parser.URL(reference)
+	// Channels can be named differently from their element, so the name is the one served by the provider of the section
+	elementName, err := s.providerClient.FetchElementName(section.URL, channel.SectionID)
+	if err != nil || elementName == "" {
+		s.api.LogWarn("Unable to fetch the element name for the channel template", "channelId", channelID, "elementId", channel.SectionID, "err", err)
+		elementName = mattermostChannel.DisplayName
+	}
+	replacer := strings.NewReplacer(
+		"{{elementLink}}", fmt.Sprintf("[%s](%s)", elementName, elementURL),
+		"{{elementUrl}}", elementURL,
+		"{{elementName}}", elementName,
+		"{{sectionName}}", section.Name,
+		"{{organizationName}}", organization.Name,
+	)
+
+	s.setHeaderAndPurpose(mattermostChannel, replacer.Replace(template.Header), replacer.Replace(template.Purpose))
+	s.addMembers(mattermostChannel, template)
+	if _, appErr := s.api.AddChannelMember(channelID, s.botID); appErr != nil {
+		s.api.LogWarn("Unable to add the bot to the channel", "channelId", channelID, "err", appErr)
+	}
+	if intro := s.buildIntroMessage(parser, reference, section, replacer.Replace(template.IntroMessage)); intro != "" {
+		s.createPinnedPost(channelID, intro)
+	}
+	for _, checklist := range template.Checklists {
+		s.createPinnedPost(channelID, buildChecklistMessage(replacer.Replace(checklist.Title), checklist.Items))
+	}
+	return nil
+}
+
+func (s *ChannelTemplateService) setHeaderAndPurpose(channel *mattermost.Channel, header, purpose string) {
+	if header == "" && purpose == "" {
+		return
+	}
+	if header != "" {
+		channel.Header = util.Substr(header, 0, channelHeaderMaxLength)
+	}
+	if purpose != "" {
+		channel.Purpose = util.Substr(purpose, 0, channelPurposeMaxLength)
+	}
+	if _, appErr := s.api.UpdateChannel(channel); appErr != nil {
+		s.api.LogWarn("Unable to set the channel header and purpose", "channelId", channel.Id, "err", appErr)
+	}
+}
+
+// Adds the members listed by username, then the team members with any of the roles, either system roles or team roles
+func (s *ChannelTemplateService) addMembers(channel *mattermost.Channel, template *config.ChannelTemplate) {
+	for _, username := range template.Members {
+		user, appErr := s.api.GetUserByUsername(strings.TrimPrefix(username, "@"))
+		if appErr != nil {
+			s.api.LogWarn("Unable to find channel template member", "username", username, "err", appErr)
+			continue
+		}
+		s.addMember(channel.Id, user.Id)
+	}
+	if len(template.Roles) == 0 {
+		return
+	}
+
+	roles := make(map[string]bool, len(template.Roles))
+	for _, role := range template.Roles {
+		roles[role] = true
+	}
+	teamRoles, err := s.getTeamRoles(channel.TeamId)
+	if err != nil {
+		s.api.LogWarn("Unable to get team roles for channel template roles", "teamId", channel.TeamId, "err", err)
+		return
+	}
+	for page := 0; ; page++ {
+		users, appErr := s.api.GetUsersInTeam(channel.TeamId, page, membershipsUsersPerPage)
+		if appErr != nil {
+			s.api.LogWarn("Unable to get team members for channel template roles", "teamId", channel.TeamId, "err", appErr)
+			return
+		}
+		for _, user := range users {
+			for _, role := range append(strings.Fields(user.Roles), teamRoles[user.Id]...) {
+				if roles[role] {
+					s.addMember(channel.Id, user.Id)
+					break
+				}
+			}
+		}
+		if len(users) < membershipsUsersPerPage {
+			return
+		}
+	}
+}
+
+// Gets the roles of the members of a team in the team, e.g. team_admin, by user id
+func (s *ChannelTemplateService) getTeamRoles(teamID string) (map[string][]string, error) {
+	teamRoles := map[string][]string{}
+	for page := 0; ; page++ {
+		members, appErr := s.api.GetTeamMembers(teamID, page, membershipsUsersPerPage)
+		if appErr != nil {
+			return nil, appErr
+		}
+		for _, member := range members {
+			teamRoles[member.UserId] = member.GetRoles()
+		}
+		if len(members) < membershipsUsersPerPage {
+			return teamRoles, nil
+		}
+	}
+}
+
+func (s *ChannelTemplateService) addMember(channelID, userID string) {
+	if _, appErr := s.api.AddChannelMember(channelID, userID); appErr != nil {
+		s.api.LogWarn("Unable to add channel template member", "channelId", channelID, "userId", userID, "err", appErr)
+	}
+}
+
+// The intro message is followed by links to the named widgets of the section, which point to the element of the channel
+func (s *ChannelTemplateService) buildIntroMessage(parser *link.Parser, reference link.Reference, section *config.Section, intro string) string {
+	var widgetLinks []string
+	for _, widget := range section.Widgets {
+		if widget.Name == "" {
+			continue
+		}
+		widgetReference := reference
+		hash := fmt.Sprintf("%s-%s-%s-widget", link.FormatName(widget.Name), reference.ElementID, reference.SectionID)
+		widgetReference.Fragment = link.ParseFragment(hash, widgetReference)
+		widgetLinks = append(widgetLinks, fmt.Sprintf("- [%s](%s)", widget.Name, parser.URL(widgetReference)))
+	}
+	if len(widgetLinks) == 0 {
+		return intro
+	}
+	if intro == "" {
+		return strings.Join(widgetLinks, "\n")
+	}
+	return fmt.Sprintf("%s\n\n%s", intro, strings.Join(widgetLinks, "\n"))
+}
+
+func buildChecklistMessage(title string, items []string) string {
+	var message strings.Builder
+	if title != "" {
+		message.WriteString(fmt.Sprintf("#### %s\n", title))
+	}
+	for _, item := range items {
+		message.WriteString(fmt.Sprintf("- [ ] %s\n", item))
+	}
+	return strings.TrimSuffix(message.String(), "\n")
+}
+
+func (s *ChannelTemplateService) createPinnedPost(channelID, message string) {
+	if message == "" {
+		return
+	}
+	post := &mattermost.Post{
+		UserId:    s.botID,
+		ChannelId: channelID,
+		Message:   message,
+		IsPinned:  true,
+	}
+	post.AddProp(channelTemplatePostProp, true)
+	if _, appErr := s.api.CreatePost(post); appErr != nil {
+		s.api.LogWarn("Unable to create channel template post", "channelId", channelID, "err", appErr)
+	}
+}
@@ -0,0 +1,362 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/util"
+)
+
+var markdownLinkRegex = regexp.MustCompile(`^\[[^\]]+\]\([^)\s]+\)$`)
+
+const (
+	commandBacklinksLimit      = 20
+	commandBacklinkMessageSize = 100
+	commandSearchLimit         = 10
+	commandAutocompleteLimit   = 25
+)
+
+// CommandService backs the hood slash command, formatting its responses as markdown and providing its autocomplete lists.
+type CommandService struct {
+	api                  plugin.API
+	platformService      *config.PlatformService
+	channelService       *ChannelService
+	linkService          *LinkService
+	providerClient       *link.ProviderClient
+	exportService        *ExportService
+	issueChannelService  *IssueChannelService
+	linkSearchService    *LinkSearchService
+	authorizationService *AuthorizationService
+}
+
+// NewCommandService returns a new service for the hood slash command
+func NewCommandService(api plugin.API, platformService *config.PlatformService, channelService *ChannelService, linkService *LinkService, providerClient *link.ProviderClient, exportService *ExportService, issueChannelService *IssueChannelService, linkSearchService *LinkSearchService, authorizationService *AuthorizationService) *CommandService {
+	return &CommandService{
+		api:                  api,
+		platformService:      platformService,
+		channelService:       channelService,
+		linkService:          linkService,
+		providerClient:       providerClient,
+		exportService:        exportService,
+		issueChannelService:  issueChannelService,
+		linkSearchService:    linkSearchService,
+		authorizationService: authorizationService,
+	}
+}
+
+func (s *CommandService) ListOrganizations() (string, error) {
+	parser, _, err := s.linkService.GetLinkModel()
+	if err != nil {
+		return "", err
+	}
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't get config")
+	}
+	if len(platformConfig.Organizations) == 0 {
+		return "There are no organizations.", nil
+	}
+
+	var text strings.Builder
+	text.WriteString("###### Organizations\n")
+	for _, organization := range platformConfig.Organizations {
+		url := parser.URL(link.Reference{OrganizationID: organization.ID})
+		text.WriteString(fmt.Sprintf("- [%s](%s) `%s`\n", organization.Name, url, link.FormatName(organization.Name)))
+	}
+	return strings.TrimSuffix(text.String(), "\n"), nil
+}
+
+// Lists the sections of the organization with the given id or URL formatted name, nested sections included
+func (s *CommandService) ListSections(organizationArg string) (string, error) {
+	parser, _, err := s.linkService.GetLinkModel()
+	if err != nil {
+		return "", err
+	}
+	organization, err := s.findOrganization(organizationArg)
+	if err != nil {
+		return "", err
+	}
+	if len(organization.Sections) == 0 {
+		return fmt.Sprintf("%s has no sections.", organization.Name), nil
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("###### Sections of %s\n", organization.Name))
+	var writeSections func(sections []config.Section, depth int)
+	writeSections = func(sections []config.Section, depth int) {
+		for _, section := range sections {
+			url := parser.URL(link.Reference{
+				OrganizationID: organization.ID,
+				SectionID:      section.ID,
+				SectionName:    link.FormatName(section.Name),
+			})
+			text.WriteString(fmt.Sprintf("%s- [%s](%s)\n", strings.Repeat("  ", depth), section.Name, url))
+			writeSections(section.Sections, depth+1)
+		}
+	}
+	writeSections(organization.Sections, 0)
+	return strings.TrimSuffix(text.String(), "\n"), nil
+}
+
+// Gets the canonical markdown link of an element, given either its URL or its canonical key
+func (s *CommandService) GetLink(element string) (string, error) {
+	var result ResolveLinkResult
+	var err error
+	if strings.HasPrefix(element, "http://") || strings.HasPrefix(element, "https://") {
+		result, err = s.linkService.ResolveLink(element, "")
+	} else {
+		result, err = s.linkService.ResolveLink("", element)
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("[%s](%s)", result.Name, result.URL), nil
+}
+
+// Lists the most recent posts linking an element, among those in channels the user belongs to
+func (s *CommandService) ListBacklinks(userID, teamID, elementURL string) (string, error) {
+	result, err := s.channelService.GetBacklinks(elementURL, userID)
+	if err != nil {
+		return "", err
+	}
+	if len(result.Items) == 0 {
+		return "No posts link this element.", nil
+	}
+	permalinkPrefix := strings.TrimSuffix(*s.api.GetConfig().ServiceSettings.SiteURL, "/")
+	if team, appErr := s.api.GetTeam(teamID); appErr == nil {
+		permalinkPrefix = fmt.Sprintf("%s/%s", permalinkPrefix, team.Name)
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("###### %d posts link %s\n", len(result.Items), elementURL))
+	for i, backlink := range result.Items {
+		if i == commandBacklinksLimit {
+			text.WriteString(fmt.Sprintf("- and %d more\n", len(result.Items)-commandBacklinksLimit))
+			break
+		}
+		message := strings.Join(strings.Fields(backlink.Message), " ")
+		if len([]rune(message)) > commandBacklinkMessageSize {
+			message = util.Substr(message, 0, commandBacklinkMessageSize) + "…"
+		}
+		text.WriteString(fmt.Sprintf(
+			"- **%s** in %s ([permalink](%s/pl/%s)): %s\n",
+			backlink.AuthorName,
+			backlink.ChannelName,
+			permalinkPrefix,
+			backlink.ID,
+			message,
+		))
+	}
+	return strings.TrimSuffix(text.String(), "\n"), nil
+}
+
+// Starts exporting a channel in the background, the bot sends the export to the user once done
+func (s *CommandService) StartExport(userID, channelID, format string) (string, error) {
+	if format == "" {
+		format = MarkdownFormat
+	}
+	if _, found := GetExporter(format); !found {
+		return "", errors.Wrapf(ErrInvalidInput, "unknown format %s, use one of %s", format, strings.Join(GetExportFormats(), ", "))
+	}
+	if _, err := s.exportService.StartExportJob(ExportScopeChannel, channelID, userID, ExportChannelParams{Format: format}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Exporting this channel as %s, the bot will send you the export once done.", format), nil
+}
+
+// Finds the element to link by name. A single match is returned as a markdown link ready to be posted, while many matches are listed to pick from.
+// Links picked from the autocomplete are returned as they are.
+func (s *CommandService) FindLink(userID, query string) (string, bool, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", false, errors.Wrap(ErrInvalidInput, "a name is required")
+	}
+	if markdownLinkRegex.MatchString(query) {
+		return query, true, nil
+	}
+	results, err := s.linkSearchService.Search(userID, query, commandSearchLimit+1)
+	if err != nil {
+		return "", false, err
+	}
+	switch len(results) {
+	case 0:
+		return fmt.Sprintf("Nothing is named like %s.", query), false, nil
+	case 1:
+		return results[0].Markdown, true, nil
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("###### Many elements are named like %s, pick one from the autocomplete\n", query))
+	for i, result := range results {
+		if i == commandSearchLimit {
+			text.WriteString("- and more\n")
+			break
+		}
+		text.WriteString(fmt.Sprintf("- %s in %s\n", result.Markdown, result.Path))
+	}
+	return strings.TrimSuffix(text.String(), "\n"), false, nil
+}
+
+// Creates an issue in the first issues section of the ecosystem, then its channel in the given team.
+// Creating issues is a privileged action, recorded in the audit log.
+func (s *CommandService) CreateIssue(userID string, params CreateIssueParams) (CreateIssueResult, error) {
+	params.Name = strings.TrimSpace(params.Name)
+	if params.Name == "" || params.TeamID == "" {
+		return CreateIssueResult{}, errors.Wrap(ErrInvalidInput, "name and teamId are required")
+	}
+	parser, _, err := s.linkService.GetLinkModel()
+	if err != nil {
+		return CreateIssueResult{}, err
+	}
+	ecosystem, section, found := s.issueChannelService.getIssuesSection()
+	if !found {
+		return CreateIssueResult{}, errors.Wrap(ErrNotFound, "no ecosystem issues section found")
+	}
+	if err := s.authorizationService.Authorize(userID, params.TeamID, ActionCreateIssue, section.ID, "create issue "+params.Name); err != nil {
+		return CreateIssueResult{}, err
+	}
+
+	s.api.LogInfo("Creating issue", "userId", userID, "name", params.Name)
+	var saved struct {
+		ID    string `json:"id"`
+		Error string `json:"error"`
+	}
+	if err := s.providerClient.PostJSON(section.URL, Issue{Name: params.Name, ObjectivesAndResearchArea: params.ObjectivesAndResearchArea}, &saved); err != nil {
+		if saved.Error != "" {
+			return CreateIssueResult{}, errors.Wrap(ErrInvalidInput, saved.Error)
+		}
+		return CreateIssueResult{}, err
+	}
+	channel, err := s.issueChannelService.SyncIssueChannel(userID, saved.ID, SyncIssueChannelParams{
+		TeamID:              params.TeamID,
+		ParentID:            section.ID,
+		OrganizationID:      ecosystem.ID,
+		CreatePublicChannel: true,
+	})
+	if err != nil {
+		return CreateIssueResult{}, errors.Wrapf(err, "issue %s created, but its channel could not be", saved.ID)
+	}
+	return CreateIssueResult{
+		IssueID:   saved.ID,
+		ChannelID: channel.ChannelID,
+		URL: parser.URL(link.Reference{
+			OrganizationID: ecosystem.ID,
+			SectionID:      section.ID,
+			SectionName:    link.FormatName(section.Name),
+			ElementID:      saved.ID,
+		}),
+	}, nil
+}
+
+// Tells who is editing the ecosystem graph, the provider serving the first issues section of the ecosystem holds the lock while a user edits it
+func (s *CommandService) GetGraphLockStatus() (string, error) {
+	_, section, found := s.issueChannelService.getIssuesSection()
+	if !found {
+		return "", errors.Wrap(ErrNotFound, "no ecosystem issues section found")
+	}
+	var lockStatus GraphLockStatus
+	if err := s.providerClient.FetchJSON(fmt.Sprintf("%s/ecosystem_graph/lock", strings.TrimSuffix(section.URL, "/")), &lockStatus); err != nil {
+		return "", err
+	}
+	if !lockStatus.Locked {
+		return "Nobody is editing the ecosystem graph.", nil
+	}
+	owner := "Someone"
+	if user, appErr := s.api.GetUser(lockStatus.Owner); appErr == nil {
+		owner = "@" + user.Username
+	}
+	minutes := int(math.Ceil(time.Until(time.Unix(lockStatus.ExpiresAt, 0)).Minutes()))
+	return fmt.Sprintf("%s is editing the ecosystem graph, the lock expires in %d minutes unless refreshed.", owner, minutes), nil
+}
+
+// Gets the organizations for the autocomplete, identified by their URL formatted name
+func (s *CommandService) GetOrganizationItems() ([]mattermost.AutocompleteListItem, error) {
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get config")
+	}
+	items := []mattermost.AutocompleteListItem{}
+	for _, organization := range platformConfig.Organizations {
+		items = append(items, mattermost.AutocompleteListItem{
+			Item:     link.FormatName(organization.Name),
+			HelpText: organization.Name,
+		})
+	}
+	return items, nil
+}
+
+// Gets the links for the autocomplete, searching the elements by name. The typed text is expected to start as the link does, with a bracket.
+func (s *CommandService) GetLinkItems(userID, userInput string) ([]mattermost.AutocompleteListItem, error) {
+	results, err := s.linkSearchService.Search(userID, strings.TrimPrefix(strings.TrimSpace(userInput), "["), commandAutocompleteLimit)
+	if err != nil {
+		return nil, err
+	}
+	items := []mattermost.AutocompleteListItem{}
+	for _, result := range results {
+		items = append(items, mattermost.AutocompleteListItem{
+			Item:     result.Markdown,
+			Hint:     result.Kind,
+			HelpText: result.Path,
+		})
+	}
+	return items, nil
+}
+
+// Gets the elements for the autocomplete, identified by their canonical key. Organizations and sections come from the config,
+// while the elements of a section are only listed once the user input starts with the key of the section, from the link search index.
+func (s *CommandService) GetElementItems(userID, userInput string) ([]mattermost.AutocompleteListItem, error) {
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get config")
+	}
+	items := []mattermost.AutocompleteListItem{}
+	for _, organization := range platformConfig.Organizations {
+		organizationKey := link.Reference{OrganizationID: organization.ID}.Key()
+		items = append(items, mattermost.AutocompleteListItem{Item: organizationKey, HelpText: organization.Name})
+		for _, section := range flattenSections(organization.Sections) {
+			sectionKey := link.Reference{OrganizationID: organization.ID, SectionID: section.ID}.Key()
+			helpText := fmt.Sprintf("%s › %s", organization.Name, section.Name)
+			items = append(items, mattermost.AutocompleteListItem{Item: sectionKey, HelpText: helpText})
+			if !strings.HasPrefix(userInput, sectionKey+"/") {
+				continue
+			}
+			elements, err := s.linkSearchService.GetSectionElements(userID, organization.ID, section.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, element := range elements {
+				items = append(items, mattermost.AutocompleteListItem{
+					Item:     element.reference.Key(),
+					HelpText: fmt.Sprintf("%s › %s", helpText, element.Name),
+				})
+			}
+		}
+	}
+	return items, nil
+}
+
+// Finds an organization by id or URL formatted name
+func (s *CommandService) findOrganization(organizationArg string) (*config.Organization, error) {
+	if organizationArg == "" {
+		return nil, errors.Wrap(ErrInvalidInput, "an organization is required")
+	}
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get config")
+	}
+	for i, organization := range platformConfig.Organizations {
+		if organization.ID == organizationArg || strings.EqualFold(link.FormatName(organization.Name), organizationArg) {
+			return &platformConfig.Organizations[i], nil
+		}
+	}
+	return nil, errors.Wrapf(ErrNotFound, "organization %s not found", organizationArg)
+}
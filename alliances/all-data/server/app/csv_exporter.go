@@ -0,0 +1,90 @@
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+)
+
+// CSV exports one row per post, in conversation order, each with the id of its thread root.
+type CSV struct{}
+
+type csvWriter struct {
+	writer *csv.Writer
+	export *ChannelExport
+}
+
+func (e *CSV) FileName(name string) string {
+	return fmt.Sprintf("%s.csv", name)
+}
+
+func (e *CSV) ContentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (e *CSV) NewWriter(w io.Writer, export *ChannelExport) (ExportWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{
+		"post_id", "thread_root_id", "created_at", "author_id", "author_name", "pinned", "message",
+		"attachments", "backlinked_element_names", "backlinked_element_urls",
+	}); err != nil {
+		return nil, err
+	}
+	return &csvWriter{writer: writer, export: export}, nil
+}
+
+func (w *csvWriter) WriteThread(thread *ExportThread) error {
+	for _, post := range append([]*mattermost.Post{thread.Root}, thread.Replies...) {
+		if err := w.writer.Write(toCSVRecord(w.export, thread.Root.Id, post)); err != nil {
+			return err
+		}
+	}
+	// Flush each thread, so that rows are not held in memory
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+func (w *csvWriter) Close() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// Multiple attachments and backlinked elements are separated by new lines within the same cell
+func toCSVRecord(export *ChannelExport, rootID string, post *mattermost.Post) []string {
+	attachments := []string{}
+	for _, fileID := range post.FileIds {
+		if fileLink, found := export.FileLinks[fileID]; found {
+			attachments = append(attachments, fileLink)
+		}
+	}
+	backlinkNames := []string{}
+	backlinkURLs := []string{}
+	for _, backlink := range export.Backlinks[post.Id] {
+		backlinkNames = append(backlinkNames, backlink.Name)
+		backlinkURLs = append(backlinkURLs, backlink.URL)
+	}
+	return []string{
+		post.Id,
+		rootID,
+		stixTimestamp(post.CreateAt),
+		post.UserId,
+		escapeCSVFormula(export.UserName(post.UserId)),
+		strconv.FormatBool(post.IsPinned),
+		escapeCSVFormula(post.Message),
+		strings.Join(attachments, "\n"),
+		escapeCSVFormula(strings.Join(backlinkNames, "\n")),
+		strings.Join(backlinkURLs, "\n"),
+	}
+}
+
+// Prevents spreadsheet applications from evaluating user provided text as a formula
+func escapeCSVFormula(value string) string {
+	if value != "" && strings.ContainsAny(value[:1], "=+-@\t\r") {
+		return "'" + value
+	}
+	return value
+}
@@ -4,3 +4,18 @@ import "github.com/pkg/errors"
 
 // ErrNotFound is used when an entity is not found.
 var ErrNotFound = errors.New("not found")
+
+// ErrForbidden is used when a user lacks the permissions to perform an operation.
+var ErrForbidden = errors.New("forbidden")
+
+// ErrAlreadyRunning is used when a job is started while another instance of it is still running.
+var ErrAlreadyRunning = errors.New("already running")
+
+// ErrInvalidInput is used when the parameters of an operation are not valid.
+var ErrInvalidInput = errors.New("invalid input")
+
+// ErrAlreadyExists is used when creating an entity that already exists.
+var ErrAlreadyExists = errors.New("already exists")
+
+// ErrTooManyAttempts is used when a user is temporarily blocked after too many unauthorized attempts.
+var ErrTooManyAttempts = errors.New("too many attempts")
@@ -6,10 +6,9 @@ type UserAddedParams struct {
 }
 
 type SetOrganizationParams struct {
-	TeamID   string `json:"teamId"`
-	UserID   string `json:"userId"`
-	OrgID    string `json:"orgId"`
-	Password string `json:"password"`
+	TeamID string `json:"teamId"`
+	UserID string `json:"userId"`
+	OrgID  string `json:"orgId"`
 }
 
 type GetUserPropsParams struct {
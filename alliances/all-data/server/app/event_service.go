@@ -1,7 +1,6 @@
 package app
 
 import (
-	"encoding/base64"
 	"fmt"
 
 	"github.com/mattermost/mattermost-server/v6/model"
@@ -12,23 +11,25 @@ import (
 )
 
 type EventService struct {
-	api             plugin.API
-	platformService *config.PlatformService
-	channelService  *ChannelService
-	categoryService *CategoryService
-	botID           string
-	configuration   *config.MattermostConfig
+	api                  plugin.API
+	platformService      *config.PlatformService
+	channelService       *ChannelService
+	categoryService      *CategoryService
+	membershipService    *MembershipService
+	authorizationService *AuthorizationService
+	botID                string
 }
 
 // NewEventService returns a new platform config service
-func NewEventService(api plugin.API, platformService *config.PlatformService, channelService *ChannelService, categoryService *CategoryService, botID string, configuration *config.MattermostConfig) *EventService {
+func NewEventService(api plugin.API, platformService *config.PlatformService, channelService *ChannelService, categoryService *CategoryService, membershipService *MembershipService, authorizationService *AuthorizationService, botID string) *EventService {
 	return &EventService{
-		api:             api,
-		platformService: platformService,
-		channelService:  channelService,
-		categoryService: categoryService,
-		botID:           botID,
-		configuration:   configuration,
+		api:                  api,
+		platformService:      platformService,
+		channelService:       channelService,
+		categoryService:      categoryService,
+		membershipService:    membershipService,
+		authorizationService: authorizationService,
+		botID:                botID,
 	}
 }
 
@@ -40,12 +41,7 @@ func (s *EventService) UserAdded(params UserAddedParams) error {
 		return fmt.Errorf("missing params data")
 	}
 
-	categories, err := s.api.GetChannelSidebarCategories(params.UserID, params.TeamID)
-	if err != nil {
-		return fmt.Errorf("couldn't get categories for user %s", params.UserID)
-	}
-
-	if err := s.categoryService.cleanCategories(categories, params.TeamID, params.UserID); err != nil {
+	if err := s.categoryService.cleanCategories(params.TeamID, params.UserID); err != nil {
 		return errors.Wrap(err, "could not clean categories for team to add channel")
 	}
 
@@ -108,13 +104,19 @@ func (s *EventService) UserAdded(params UserAddedParams) error {
 	return nil
 }
 
-// Set the organization the user will be related to. This will automatically join and leave the organization channels based on the org ID passed.
-func (s *EventService) SetOrganizations(params SetOrganizationParams) error {
+// Set the organization the user will be related to, adding the user to it. This will automatically join and leave the organization channels
+// based on the organizations the user belongs to, or join all of them if all organizations are selected.
+// Selecting all organizations, or setting the organization of another user, are privileged actions.
+func (s *EventService) SetOrganizations(actorID string, params SetOrganizationParams) error {
 	s.api.LogInfo("Params on setOrganization", "params", params)
 
 	if params.OrgID == config.OrganizationIDAll {
-		if params.Password == "" || base64.StdEncoding.EncodeToString([]byte(params.Password)) != s.configuration.GetConfiguration().AdminPassword {
-			return fmt.Errorf("used an incorrect password while allowing all organizations to be shown to user %s", params.UserID)
+		if err := s.authorizationService.Authorize(actorID, params.TeamID, ActionViewAllOrganizations, params.UserID, ""); err != nil {
+			return err
+		}
+	} else if actorID != params.UserID {
+		if err := s.authorizationService.Authorize(actorID, params.TeamID, ActionSetUserOrganization, params.UserID, "set organization "+params.OrgID); err != nil {
+			return err
 		}
 	}
 
@@ -152,6 +154,16 @@ func (s *EventService) SetOrganizations(params SetOrganizationParams) error {
 		return fmt.Errorf("couldn't get ecosystem")
 	}
 
+	if params.OrgID != config.OrganizationIDAll {
+		if err := s.membershipService.ensureMembership(params.UserID, params.OrgID); err != nil {
+			return errors.Wrapf(err, "couldn't add user %s to organization %s", params.UserID, params.OrgID)
+		}
+	}
+	userOrgIDs, orgErr := s.membershipService.getUserOrganizationIDs(params.UserID)
+	if orgErr != nil {
+		return errors.Wrapf(orgErr, "couldn't get organizations of user %s", params.UserID)
+	}
+
 	for _, channel := range channels.Items {
 		for _, orgChannel := range allOrgChannels.Items {
 			if channel.Id == orgChannel.ChannelID {
@@ -159,7 +171,7 @@ func (s *EventService) SetOrganizations(params SetOrganizationParams) error {
 					continue
 				}
 
-				if params.OrgID == config.OrganizationIDAll || orgChannel.OrganizationID == params.OrgID {
+				if params.OrgID == config.OrganizationIDAll || userOrgIDs[orgChannel.OrganizationID] {
 					_, _ = s.api.AddChannelMember(channel.Id, params.UserID)
 				} else {
 					_ = s.api.DeleteChannelMember(channel.Id, params.UserID)
@@ -174,12 +186,7 @@ func (s *EventService) SetOrganizations(params SetOrganizationParams) error {
 	}
 
 	// Also needed to actually refresh the channel order in the left sidebar, or else it'll happen when the user switches the channel the first time after setting the org
-	categories, err := s.api.GetChannelSidebarCategories(params.UserID, params.TeamID)
-	if err != nil {
-		return fmt.Errorf("couldn't get categories for user %s", params.UserID)
-	}
-
-	if err := s.categoryService.cleanCategories(categories, params.TeamID, params.UserID); err != nil {
+	if err := s.categoryService.cleanCategories(params.TeamID, params.UserID); err != nil {
 		return errors.Wrap(err, "could not update categories for team to add channel")
 	}
 
@@ -0,0 +1,330 @@
+package app
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+const (
+	bundleManifestFileName  = "manifest.json"
+	bundleBacklinksFileName = "backlinks.jsonld"
+)
+
+// ExportBundleManifest describes the content of an export bundle, which is a zip archive of the channels of one or more sections,
+// the data of the sections as returned by their providers, and the backlinks from the channels to the platform elements.
+type ExportBundleManifest struct {
+	OrganizationID   string                 `json:"organizationId"`
+	OrganizationName string                 `json:"organizationName"`
+	Format           string                 `json:"format"`
+	ExportedAt       int64                  `json:"exportedAt"`
+	Backlinks        string                 `json:"backlinks"`
+	Sections         []*ExportBundleSection `json:"sections"`
+}
+
+type ExportBundleSection struct {
+	ID            string                 `json:"id"`
+	Name          string                 `json:"name"`
+	URL           string                 `json:"url"`
+	Snapshot      string                 `json:"snapshot,omitempty"`
+	SnapshotError string                 `json:"snapshotError,omitempty"`
+	Channels      []*ExportBundleChannel `json:"channels"`
+}
+
+type ExportBundleChannel struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	DisplayName  string `json:"displayName"`
+	ElementID    string `json:"elementId"`
+	File         string `json:"file"`
+	Element      string `json:"element,omitempty"`
+	ElementError string `json:"elementError,omitempty"`
+}
+
+// Everything needed to write a bundle, collected before starting the job so that errors are reported to the caller
+type exportBundle struct {
+	userID       string
+	organization *config.Organization
+	sections     []*exportBundleSection
+	exporter     Exporter
+	params       ExportChannelParams
+	threadsTotal int
+}
+
+type exportBundleSection struct {
+	section  *config.Section
+	path     string
+	channels []*bundledChannel
+}
+
+type bundledChannel struct {
+	elementID string
+	export    *ChannelExport
+}
+
+// Prepares the export of a section, including its nested sections.
+func (s *ExportService) prepareSectionExport(sectionID, userID string, params ExportChannelParams) (*PreparedExport, error) {
+	organization, section, err := s.findSection(sectionID)
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := s.newExportBundle(userID, organization, []config.Section{*section}, params)
+	if err != nil {
+		return nil, err
+	}
+	return s.prepareBundleExport(fmt.Sprintf("%s %s", organization.Name, section.Name), bundle), nil
+}
+
+// Prepares the export of all the sections of an organization.
+func (s *ExportService) prepareOrganizationExport(organizationID, userID string, params ExportChannelParams) (*PreparedExport, error) {
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get platform config to export organization")
+	}
+	organization, found := link.NewResolver(platformConfig, nil, nil).FindOrganization(organizationID)
+	if !found {
+		return nil, errors.Wrapf(ErrNotFound, "organization %s not found", organizationID)
+	}
+	bundle, err := s.newExportBundle(userID, organization, organization.Sections, params)
+	if err != nil {
+		return nil, err
+	}
+	return s.prepareBundleExport(organization.Name, bundle), nil
+}
+
+func (s *ExportService) prepareBundleExport(name string, bundle *exportBundle) *PreparedExport {
+	return &PreparedExport{
+		Name:         name,
+		FileName:     bundleFileName(name) + ".zip",
+		ThreadsTotal: bundle.threadsTotal,
+		write: func(w io.Writer, progress func(threadsDone int)) error {
+			return s.writeExportBundle(w, bundle, progress)
+		},
+	}
+}
+
+func (s *ExportService) findSection(sectionID string) (*config.Organization, *config.Section, error) {
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to get platform config to export section")
+	}
+	resolver := link.NewResolver(platformConfig, nil, nil)
+	for i := range platformConfig.Organizations {
+		organization := &platformConfig.Organizations[i]
+		if section, found := resolver.FindSection(organization.ID, sectionID); found {
+			return organization, section, nil
+		}
+	}
+	return nil, nil, errors.Wrapf(ErrNotFound, "section %s not found", sectionID)
+}
+
+// Collects the channels of the given sections and of their nested sections. Channels the user cannot read are left out.
+func (s *ExportService) newExportBundle(userID string, organization *config.Organization, sections []config.Section, params ExportChannelParams) (*exportBundle, error) {
+	exporter, found := GetExporter(params.Format)
+	if !found {
+		return nil, errors.Errorf("unsupported export format %s", params.Format)
+	}
+	channels, err := s.channelService.GetChannelsByOrganizationID(organization.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get organization channels to export")
+	}
+	channelsBySection := make(map[string][]Channel)
+	for _, channel := range channels.Items {
+		channelsBySection[channel.ParentID] = append(channelsBySection[channel.ParentID], channel)
+	}
+
+	bundle := &exportBundle{userID: userID, organization: organization, exporter: exporter, params: params}
+	paths := make(map[string]bool)
+	for _, section := range flattenSections(sections) {
+		bundleSection := &exportBundleSection{section: section, path: uniqueBundlePath(paths, section), channels: []*bundledChannel{}}
+		for _, channel := range channelsBySection[section.ID] {
+			export, _, err := s.NewChannelExport(channel.ChannelID, userID, params)
+			if errors.Is(err, ErrForbidden) || errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			threads, err := s.mattermostPostStore.CountRootPostsForChannel(channel.ChannelID, export.filter.rootPostsOptions())
+			if err != nil {
+				return nil, err
+			}
+			bundle.threadsTotal += threads
+			bundleSection.channels = append(bundleSection.channels, &bundledChannel{elementID: channel.SectionID, export: export})
+		}
+		bundle.sections = append(bundle.sections, bundleSection)
+	}
+	return bundle, nil
+}
+
+func (s *ExportService) writeExportBundle(w io.Writer, bundle *exportBundle, progress func(threadsDone int)) error {
+	archive := zip.NewWriter(w)
+	manifest := &ExportBundleManifest{
+		OrganizationID:   bundle.organization.ID,
+		OrganizationName: bundle.organization.Name,
+		Format:           bundle.params.Format,
+		ExportedAt:       time.Now().UnixMilli(),
+		Backlinks:        bundleBacklinksFileName,
+		Sections:         []*ExportBundleSection{},
+	}
+
+	threadsDone := 0
+	channelIDs := make(map[string]bool)
+	for _, bundleSection := range bundle.sections {
+		manifestSection := &ExportBundleSection{
+			ID:       bundleSection.section.ID,
+			Name:     bundleSection.section.Name,
+			URL:      bundleSection.section.URL,
+			Channels: []*ExportBundleChannel{},
+		}
+		manifestSection.Snapshot, manifestSection.SnapshotError = s.writeProviderSnapshot(archive, path.Join(bundleSection.path, "section.json"), bundleSection.section.URL)
+		// Elements can have more channels, while their snapshot is written once
+		elementSnapshots := make(map[string]providerSnapshot)
+
+		for _, channel := range bundleSection.channels {
+			manifestChannel := &ExportBundleChannel{
+				ID:          channel.export.Channel.Id,
+				Name:        channel.export.Channel.Name,
+				DisplayName: channel.export.Channel.DisplayName,
+				ElementID:   channel.elementID,
+				File:        path.Join(bundleSection.path, "channels", bundle.exporter.FileName(channel.export.Channel.Name)),
+			}
+			if channel.elementID != "" {
+				elementURL := fmt.Sprintf("%s/%s", strings.TrimSuffix(bundleSection.section.URL, "/"), channel.elementID)
+				elementPath := path.Join(bundleSection.path, "elements", bundleFileName(channel.elementID)+".json")
+				snapshot, found := elementSnapshots[elementPath]
+				if !found {
+					snapshot.file, snapshot.err = s.writeProviderSnapshot(archive, elementPath, elementURL)
+					elementSnapshots[elementPath] = snapshot
+				}
+				manifestChannel.Element, manifestChannel.ElementError = snapshot.file, snapshot.err
+			}
+
+			file, err := archive.Create(manifestChannel.File)
+			if err != nil {
+				return errors.Wrap(err, "unable to add channel to export bundle")
+			}
+			threadsBefore := threadsDone
+			if err := s.WriteChannelExport(file, bundle.exporter, channel.export, func(channelThreadsDone int) {
+				threadsDone = threadsBefore + channelThreadsDone
+				progress(threadsDone)
+			}); err != nil {
+				return err
+			}
+			channelIDs[channel.export.Channel.Id] = true
+			manifestSection.Channels = append(manifestSection.Channels, manifestChannel)
+		}
+		manifest.Sections = append(manifest.Sections, manifestSection)
+	}
+
+	if err := s.writeBundleBacklinks(archive, bundle, channelIDs); err != nil {
+		return err
+	}
+	file, err := archive.Create(bundleManifestFileName)
+	if err != nil {
+		return errors.Wrap(err, "unable to add manifest to export bundle")
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return errors.Wrap(err, "unable to write export bundle manifest")
+	}
+	return archive.Close()
+}
+
+// Writes the backlinks from the bundled channels, as a graph connecting posts to the elements they link
+func (s *ExportService) writeBundleBacklinks(archive *zip.Writer, bundle *exportBundle, channelIDs map[string]bool) error {
+	graph, err := s.channelService.GetBacklinkGraph(bundle.organization.ID, bundle.userID)
+	if err != nil {
+		return errors.Wrap(err, "unable to get backlinks for export bundle")
+	}
+	graphExporter, _ := GetGraphExporter(JSONLDFormat)
+	file, err := archive.Create(bundleBacklinksFileName)
+	if err != nil {
+		return errors.Wrap(err, "unable to add backlinks to export bundle")
+	}
+	return graphExporter.Export(file, filterBacklinkGraph(graph, channelIDs))
+}
+
+// providerSnapshot is the file a provider snapshot was written to, or the error preventing it
+type providerSnapshot struct {
+	file string
+	err  string
+}
+
+// Stores the data returned by a provider URL as is. Failures are reported in the manifest rather than failing the whole bundle,
+// since providers may be temporarily unavailable.
+func (s *ExportService) writeProviderSnapshot(archive *zip.Writer, filePath, url string) (string, string) {
+	body, err := s.providerClient.Get(url)
+	if err != nil {
+		s.api.LogWarn("Unable to fetch provider data for export bundle", "url", url, "err", err)
+		return "", err.Error()
+	}
+	defer body.Close()
+	file, err := archive.Create(filePath)
+	if err != nil {
+		return "", err.Error()
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		return "", err.Error()
+	}
+	return filePath, ""
+}
+
+// Keeps the backlinks from the posts of the given channels, along with the elements they link
+func filterBacklinkGraph(graph *BacklinkGraph, channelIDs map[string]bool) *BacklinkGraph {
+	filtered := &BacklinkGraph{Posts: []*BacklinkGraphPost{}, Elements: []*BacklinkGraphElement{}, Edges: []*BacklinkGraphEdge{}}
+	posts := make(map[string]bool)
+	for _, post := range graph.Posts {
+		if channelIDs[post.ChannelID] {
+			filtered.Posts = append(filtered.Posts, post)
+			posts[post.ID] = true
+		}
+	}
+	elements := make(map[string]bool)
+	for _, edge := range graph.Edges {
+		if posts[edge.Source] {
+			filtered.Edges = append(filtered.Edges, edge)
+			elements[edge.Target] = true
+		}
+	}
+	for _, element := range graph.Elements {
+		if elements[element.Key] {
+			filtered.Elements = append(filtered.Elements, element)
+		}
+	}
+	return filtered
+}
+
+// Lists the given sections followed by their nested sections, depth first
+func flattenSections(sections []config.Section) []*config.Section {
+	flattened := []*config.Section{}
+	for i := range sections {
+		flattened = append(flattened, &sections[i])
+		flattened = append(flattened, flattenSections(sections[i].Sections)...)
+	}
+	return flattened
+}
+
+// Sections are stored in folders named after them, which are disambiguated with the section id when names clash
+func uniqueBundlePath(paths map[string]bool, section *config.Section) string {
+	sectionPath := path.Join("sections", bundleFileName(section.Name))
+	if paths[sectionPath] {
+		sectionPath = fmt.Sprintf("%s-%s", sectionPath, bundleFileName(section.ID))
+	}
+	paths[sectionPath] = true
+	return sectionPath
+}
+
+func bundleFileName(name string) string {
+	return strings.NewReplacer("/", "-", "\\", "-", ".", "-").Replace(link.FormatName(name))
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"strings"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+// exportFilter selects the posts of a channel export, see ExportChannelParams for the meaning of each filter.
+type exportFilter struct {
+	pinnedOnly     bool
+	since          int64
+	until          int64
+	authors        map[string]bool
+	elementKeys    []string
+	reactions      map[string]bool
+	excludeReplies bool
+}
+
+// Validates the filters of an export, resolving usernames to ids and elements to their canonical keys
+func (s *ExportService) newExportFilter(params ExportChannelParams) (*exportFilter, error) {
+	if params.Since > 0 && params.Until > 0 && params.Since > params.Until {
+		return nil, errors.Wrap(ErrInvalidInput, "since must not be after until")
+	}
+	filter := &exportFilter{
+		pinnedOnly:     params.PinnedOnly,
+		since:          params.Since,
+		until:          params.Until,
+		elementKeys:    []string{},
+		excludeReplies: params.ExcludeReplies,
+	}
+
+	if len(params.Authors) > 0 {
+		filter.authors = make(map[string]bool, len(params.Authors))
+		for _, author := range params.Authors {
+			author = strings.TrimPrefix(strings.TrimSpace(author), "@")
+			if !mattermost.IsValidId(author) {
+				user, appErr := s.api.GetUserByUsername(author)
+				if appErr != nil {
+					return nil, errors.Wrapf(ErrInvalidInput, "unknown author %s", author)
+				}
+				author = user.Id
+			}
+			filter.authors[author] = true
+		}
+	}
+
+	if len(params.Elements) > 0 {
+		parser, resolver, err := s.linkService.GetLinkModel()
+		if err != nil {
+			return nil, err
+		}
+		for _, element := range params.Elements {
+			reference, ok := parser.Parse(element)
+			if !ok {
+				parsedKey, err := link.ParseKey(element)
+				if err != nil {
+					return nil, errors.Wrapf(ErrInvalidInput, "%s is neither a link to the platform nor an element key", element)
+				}
+				reference = parsedKey
+			}
+			filter.elementKeys = append(filter.elementKeys, resolver.Canonicalize(reference).Key())
+		}
+	}
+
+	if len(params.Reactions) > 0 {
+		filter.reactions = make(map[string]bool, len(params.Reactions))
+		for _, reaction := range params.Reactions {
+			filter.reactions[strings.Trim(strings.TrimSpace(reaction), ":")] = true
+		}
+	}
+	return filter, nil
+}
+
+// Replies cannot be created before their root, so the end of the window is the only filter that can restrict the roots to read
+func (f *exportFilter) rootPostsOptions() RootPostsOptions {
+	return RootPostsOptions{PinnedOnly: f.pinnedOnly, Until: f.until}
+}
+
+func (f *exportFilter) needsReactions() bool {
+	return len(f.reactions) > 0
+}
+
+// Keeps the matching replies of a thread, returning false if neither the root nor any reply matches
+func (f *exportFilter) filterThread(thread *ExportThread, backlinks map[string][]*ExportBacklink, reactions map[string][]string) (*ExportThread, bool) {
+	filtered := &ExportThread{Root: thread.Root, Replies: []*mattermost.Post{}}
+	if !f.excludeReplies {
+		for _, reply := range thread.Replies {
+			if f.matches(reply, backlinks[reply.Id], reactions[reply.Id]) {
+				filtered.Replies = append(filtered.Replies, reply)
+			}
+		}
+	}
+	return filtered, len(filtered.Replies) > 0 || f.matches(thread.Root, backlinks[thread.Root.Id], reactions[thread.Root.Id])
+}
+
+func (f *exportFilter) matches(post *mattermost.Post, backlinks []*ExportBacklink, reactions []string) bool {
+	if f.since > 0 && post.CreateAt < f.since {
+		return false
+	}
+	if f.until > 0 && post.CreateAt > f.until {
+		return false
+	}
+	if f.authors != nil && !f.authors[post.UserId] {
+		return false
+	}
+	if len(f.elementKeys) > 0 && !f.linksElements(backlinks) {
+		return false
+	}
+	if f.reactions != nil && !f.hasReactions(reactions) {
+		return false
+	}
+	return true
+}
+
+func (f *exportFilter) linksElements(backlinks []*ExportBacklink) bool {
+	for _, backlink := range backlinks {
+		for _, elementKey := range f.elementKeys {
+			if isNestedKey(backlink.Key, elementKey) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *exportFilter) hasReactions(reactions []string) bool {
+	for _, reaction := range reactions {
+		if f.reactions[reaction] {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks whether a key identifies the given element or something nested in it, e.g. one of its widgets
+func isNestedKey(key, parentKey string) bool {
+	return key == parentKey || strings.HasPrefix(key, parentKey+"/") || strings.HasPrefix(key, parentKey+"#")
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"testing"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin/plugintest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	aliceID = "aliceidaliceidaliceidalice"
+	bobID   = "bobidbobidbobidbobidbobidb"
+)
+
+func TestExportFilterMatches(t *testing.T) {
+	post := &mattermost.Post{Id: "postid", UserId: aliceID, CreateAt: 1680000000000}
+	sectionBacklinks := []*ExportBacklink{{Key: "organizations/1/sections/10/elements/abc"}}
+	widgetBacklinks := []*ExportBacklink{{Key: "organizations/1/sections/10/elements/abc#chart"}}
+
+	tests := []struct {
+		name      string
+		filter    exportFilter
+		backlinks []*ExportBacklink
+		reactions []string
+		expected  bool
+	}{
+		{name: "no filters", expected: true},
+		{name: "created at the start of the range", filter: exportFilter{since: 1680000000000}, expected: true},
+		{name: "created before the range", filter: exportFilter{since: 1680000000001}, expected: false},
+		{name: "created at the end of the range", filter: exportFilter{until: 1680000000000}, expected: true},
+		{name: "created after the range", filter: exportFilter{until: 1679999999999}, expected: false},
+		{name: "created within the range", filter: exportFilter{since: 1670000000000, until: 1690000000000}, expected: true},
+		{name: "written by an author", filter: exportFilter{authors: map[string]bool{aliceID: true, bobID: true}}, expected: true},
+		{name: "written by someone else", filter: exportFilter{authors: map[string]bool{bobID: true}}, expected: false},
+		{
+			name:      "linking the element",
+			filter:    exportFilter{elementKeys: []string{"organizations/1/sections/10/elements/abc"}},
+			backlinks: sectionBacklinks,
+			expected:  true,
+		},
+		{
+			name:      "linking something nested in the element",
+			filter:    exportFilter{elementKeys: []string{"organizations/1/sections/10/elements/abc"}},
+			backlinks: widgetBacklinks,
+			expected:  true,
+		},
+		{
+			name:      "linking the elements of the section",
+			filter:    exportFilter{elementKeys: []string{"organizations/1/sections/10"}},
+			backlinks: sectionBacklinks,
+			expected:  true,
+		},
+		{
+			name:      "linking another section",
+			filter:    exportFilter{elementKeys: []string{"organizations/1/sections/1"}},
+			backlinks: sectionBacklinks,
+			expected:  false,
+		},
+		{
+			name:     "linking no element",
+			filter:   exportFilter{elementKeys: []string{"organizations/1/sections/10"}},
+			expected: false,
+		},
+		{name: "with a reaction", filter: exportFilter{reactions: map[string]bool{"eyes": true}}, reactions: []string{"+1", "eyes"}, expected: true},
+		{name: "without the reactions", filter: exportFilter{reactions: map[string]bool{"eyes": true}}, reactions: []string{"+1"}, expected: false},
+		{
+			name:      "matching all the filters",
+			filter:    exportFilter{since: 1670000000000, authors: map[string]bool{aliceID: true}, elementKeys: []string{"organizations/1/sections/10"}},
+			backlinks: sectionBacklinks,
+			expected:  true,
+		},
+		{
+			name:     "matching only some of the filters",
+			filter:   exportFilter{since: 1670000000000, authors: map[string]bool{aliceID: true}, elementKeys: []string{"organizations/1/sections/10"}},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, test.filter.matches(post, test.backlinks, test.reactions))
+		})
+	}
+}
+
+func TestExportFilterThread(t *testing.T) {
+	root := &mattermost.Post{Id: "rootid", UserId: aliceID, CreateAt: 1680000000000}
+	reply := &mattermost.Post{Id: "replyid", UserId: bobID, RootId: "rootid", CreateAt: 1680000060000}
+	thread := &ExportThread{Root: root, Replies: []*mattermost.Post{reply}}
+
+	tests := []struct {
+		name            string
+		filter          exportFilter
+		expectedReplies []*mattermost.Post
+		expectedMatch   bool
+	}{
+		{name: "root and reply matching", expectedReplies: []*mattermost.Post{reply}, expectedMatch: true},
+		{name: "root only matching", filter: exportFilter{authors: map[string]bool{aliceID: true}}, expectedReplies: []*mattermost.Post{}, expectedMatch: true},
+		{name: "reply only matching keeps the root", filter: exportFilter{authors: map[string]bool{bobID: true}}, expectedReplies: []*mattermost.Post{reply}, expectedMatch: true},
+		{name: "replies excluded", filter: exportFilter{excludeReplies: true, authors: map[string]bool{bobID: true}}, expectedReplies: []*mattermost.Post{}, expectedMatch: false},
+		{name: "nothing matching", filter: exportFilter{until: 1670000000000}, expectedReplies: []*mattermost.Post{}, expectedMatch: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filtered, matched := test.filter.filterThread(thread, nil, nil)
+			assert.Equal(t, test.expectedMatch, matched)
+			assert.Equal(t, root, filtered.Root)
+			assert.Equal(t, test.expectedReplies, filtered.Replies)
+		})
+	}
+}
+
+func TestNewExportFilter(t *testing.T) {
+	api := &plugintest.API{}
+	api.On("GetUserByUsername", "bob").Return(&mattermost.User{Id: bobID}, nil)
+	api.On("GetUserByUsername", "nobody").Return(nil, mattermost.NewAppError("GetUserByUsername", "app.user.missing", nil, "", 404))
+	service := &ExportService{api: api}
+
+	t.Run("resolves the usernames of the authors", func(t *testing.T) {
+		filter, err := service.newExportFilter(ExportChannelParams{Authors: []string{aliceID, " @bob "}})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{aliceID: true, bobID: true}, filter.authors)
+	})
+
+	t.Run("rejects unknown authors", func(t *testing.T) {
+		_, err := service.newExportFilter(ExportChannelParams{Authors: []string{"nobody"}})
+		assert.True(t, errors.Is(err, ErrInvalidInput))
+	})
+
+	t.Run("rejects a range ending before it starts", func(t *testing.T) {
+		_, err := service.newExportFilter(ExportChannelParams{Since: 1680000000001, Until: 1680000000000})
+		assert.True(t, errors.Is(err, ErrInvalidInput))
+	})
+
+	t.Run("trims the colons of the reactions", func(t *testing.T) {
+		filter, err := service.newExportFilter(ExportChannelParams{Reactions: []string{":eyes:", "+1"}})
+		require.NoError(t, err)
+		assert.True(t, filter.needsReactions())
+		assert.Equal(t, map[string]bool{"eyes": true, "+1": true}, filter.reactions)
+	})
+}
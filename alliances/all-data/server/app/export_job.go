@@ -0,0 +1,43 @@
+package app
+
+import "io"
+
+const (
+	ExportScopeChannel      = "channel"
+	ExportScopeSection      = "section"
+	ExportScopeOrganization = "organization"
+)
+
+const (
+	ExportJobPending = "pending"
+	ExportJobRunning = "running"
+	ExportJobDone    = "done"
+	ExportJobFailed  = "failed"
+)
+
+// ExportJob is a channel export running in the background, whose output is delivered to the user by the bot.
+type ExportJob struct {
+	ID           string `json:"id"`
+	UserID       string `json:"userId"`
+	Scope        string `json:"scope"`
+	ScopeID      string `json:"scopeId"`
+	Format       string `json:"format"`
+	Status       string `json:"status"`
+	ThreadsDone  int    `json:"threadsDone"`
+	ThreadsTotal int    `json:"threadsTotal"`
+	FileID       string `json:"fileId,omitempty"`
+	FileName     string `json:"fileName,omitempty"`
+	DownloadURL  string `json:"downloadUrl,omitempty"`
+	Error        string `json:"error,omitempty"`
+	CreateAt     int64  `json:"createAt"`
+	UpdateAt     int64  `json:"updateAt"`
+}
+
+// PreparedExport is an export whose parameters have been checked, ready to be written.
+type PreparedExport struct {
+	Name         string
+	FileName     string
+	ThreadsTotal int
+
+	write func(w io.Writer, progress func(threadsDone int)) error
+}
@@ -0,0 +1,33 @@
+package app
+
+// ExportSchedule is an export run periodically, whose file is posted by the bot to a reporting channel.
+type ExportSchedule struct {
+	ID              string              `json:"id"`
+	Name            string              `json:"name"`
+	Cron            string              `json:"cron"` // Standard five fields cron expression, evaluated in UTC
+	Scope           string              `json:"scope"`
+	ScopeID         string              `json:"scopeId"`
+	Params          ExportChannelParams `json:"params"`
+	SinceLastRun    bool                `json:"sinceLastRun"` // Only exports the posts created since the previous run
+	ReportChannelID string              `json:"reportChannelId"`
+	CreatorID       string              `json:"creatorId"`
+	NextRunAt       int64               `json:"nextRunAt"`
+	LastRunAt       int64               `json:"lastRunAt"`
+	LastError       string              `json:"lastError"`
+	DisableAt       int64               `json:"disableAt"` // Set when the creator can no longer run the schedule, which then stops running
+	CreateAt        int64               `json:"createAt"`
+}
+
+type AddExportScheduleParams struct {
+	Name            string              `json:"name"`
+	Cron            string              `json:"cron"`
+	Scope           string              `json:"scope"`
+	ScopeID         string              `json:"scopeId"`
+	Params          ExportChannelParams `json:"params"`
+	SinceLastRun    bool                `json:"sinceLastRun"`
+	ReportChannelID string              `json:"reportChannelId"`
+}
+
+type GetExportSchedulesResults struct {
+	Items []ExportSchedule `json:"items"`
+}
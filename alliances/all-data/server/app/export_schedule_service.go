@@ -0,0 +1,204 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-api/cluster"
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin"
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/util"
+)
+
+// Schedules are checked every minute, which is the finest granularity of cron expressions
+const exportSchedulesInterval = time.Minute
+
+type ExportScheduleService struct {
+	api           plugin.API
+	store         ExportScheduleStore
+	exportService *ExportService
+	botID         string
+}
+
+// NewExportScheduleService returns a new export schedules service
+func NewExportScheduleService(api plugin.API, store ExportScheduleStore, exportService *ExportService, botID string) *ExportScheduleService {
+	return &ExportScheduleService{
+		api:           api,
+		store:         store,
+		exportService: exportService,
+		botID:         botID,
+	}
+}
+
+// Starts the job running the due schedules, which runs on a single server of the cluster at a time.
+func (s *ExportScheduleService) Start() (*cluster.Job, error) {
+	return cluster.Schedule(s.api, "CSA_exportSchedulesJob", cluster.MakeWaitForInterval(exportSchedulesInterval), s.RunDueExportSchedules)
+}
+
+// Adds a schedule, which runs with the permissions of the user creating it.
+func (s *ExportScheduleService) AddExportSchedule(userID string, params AddExportScheduleParams) (ExportSchedule, error) {
+	schedule, err := cron.ParseStandard(params.Cron)
+	if err != nil {
+		return ExportSchedule{}, errors.Wrapf(ErrInvalidInput, "invalid cron expression %s: %s", params.Cron, err.Error())
+	}
+	if _, found := GetExporter(params.Params.Format); !found {
+		return ExportSchedule{}, errors.Wrapf(ErrInvalidInput, "unsupported export format %s", params.Params.Format)
+	}
+	if !s.api.HasPermissionToChannel(userID, params.ReportChannelID, mattermost.PermissionCreatePost) {
+		return ExportSchedule{}, errors.Wrapf(ErrForbidden, "user %s cannot post to channel %s", userID, params.ReportChannelID)
+	}
+	// Checks the scope and the filters, without writing anything
+	if _, err := s.exportService.PrepareExport(params.Scope, params.ScopeID, userID, params.Params); err != nil {
+		return ExportSchedule{}, err
+	}
+
+	now := time.Now()
+	exportSchedule := ExportSchedule{
+		ID:              util.GenerateUUID(),
+		Name:            strings.TrimSpace(params.Name),
+		Cron:            params.Cron,
+		Scope:           params.Scope,
+		ScopeID:         params.ScopeID,
+		Params:          params.Params,
+		SinceLastRun:    params.SinceLastRun,
+		ReportChannelID: params.ReportChannelID,
+		CreatorID:       userID,
+		NextRunAt:       schedule.Next(now.UTC()).UnixMilli(),
+		CreateAt:        now.UnixMilli(),
+	}
+	if exportSchedule.Name == "" {
+		exportSchedule.Name = fmt.Sprintf("%s export", params.Scope)
+	}
+	if err := s.store.AddExportSchedule(exportSchedule); err != nil {
+		return ExportSchedule{}, err
+	}
+	return exportSchedule, nil
+}
+
+// Gets the schedules created by a user, or all of them for system admins.
+func (s *ExportScheduleService) GetExportSchedules(userID string) (GetExportSchedulesResults, error) {
+	creatorID := userID
+	if s.api.HasPermissionTo(userID, mattermost.PermissionManageSystem) {
+		creatorID = ""
+	}
+	schedules, err := s.store.GetExportSchedules(creatorID)
+	if err != nil {
+		return GetExportSchedulesResults{}, err
+	}
+	return GetExportSchedulesResults{Items: schedules}, nil
+}
+
+// Deletes a schedule, which only its creator and system admins can do.
+func (s *ExportScheduleService) DeleteExportSchedule(scheduleID, userID string) error {
+	schedule, err := s.store.GetExportSchedule(scheduleID)
+	if err != nil {
+		return err
+	}
+	if schedule.CreatorID != userID && !s.api.HasPermissionTo(userID, mattermost.PermissionManageSystem) {
+		return errors.Wrapf(ErrForbidden, "user %s cannot delete export schedule %s", userID, scheduleID)
+	}
+	return s.store.DeleteExportSchedule(scheduleID)
+}
+
+// Runs the schedules that are due. Runs are serialized through a cluster mutex, so that no schedule runs twice.
+func (s *ExportScheduleService) RunDueExportSchedules() {
+	mutex, err := cluster.NewMutex(s.api, "CSA_exportSchedulesMutex")
+	if err != nil {
+		s.api.LogError("failed creating cluster mutex to run export schedules", "err", err)
+		return
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	now := time.Now()
+	schedules, err := s.store.GetDueExportSchedules(now.UnixMilli())
+	if err != nil {
+		s.api.LogError("failed to get due export schedules", "err", err)
+		return
+	}
+	for _, schedule := range schedules {
+		s.runDueExportSchedule(schedule, now)
+	}
+}
+
+// Runs a due schedule and sets its next run, unless the schedule is disabled because its creator can no longer run it.
+func (s *ExportScheduleService) runDueExportSchedule(schedule ExportSchedule, now time.Time) {
+	lastError := ""
+	err := s.runExportSchedule(schedule, now)
+	if errors.Is(err, ErrForbidden) {
+		s.disableExportSchedule(schedule, now, err)
+		return
+	}
+	if err != nil {
+		s.api.LogWarn("Export schedule failed", "scheduleId", schedule.ID, "err", err)
+		lastError = err.Error()
+		_ = s.postToReportChannel(schedule, fmt.Sprintf("The scheduled export **%s** failed: %s", schedule.Name, lastError), nil)
+	}
+
+	cronSchedule, err := cron.ParseStandard(schedule.Cron)
+	if err != nil {
+		s.api.LogError("invalid cron expression of export schedule", "scheduleId", schedule.ID, "cron", schedule.Cron, "err", err)
+		return
+	}
+	nextRunAt := cronSchedule.Next(now.UTC()).UnixMilli()
+	if err := s.store.UpdateExportScheduleRun(schedule.ID, now.UnixMilli(), nextRunAt, lastError); err != nil {
+		s.api.LogError("failed to update export schedule run", "scheduleId", schedule.ID, "err", err)
+	}
+}
+
+// Runs a schedule with the permissions of its creator, failing with ErrForbidden when the creator can no longer run it.
+// The creator must still be active and allowed to post to the report channel, while reading the scope is checked by the export.
+func (s *ExportScheduleService) runExportSchedule(schedule ExportSchedule, now time.Time) error {
+	s.api.LogInfo("Running export schedule", "scheduleId", schedule.ID, "scope", schedule.Scope, "scopeId", schedule.ScopeID)
+	creator, appErr := s.api.GetUser(schedule.CreatorID)
+	if appErr != nil {
+		return errors.Wrapf(appErr, "unable to get creator %s", schedule.CreatorID)
+	}
+	if creator.DeleteAt != 0 {
+		return errors.Wrapf(ErrForbidden, "creator %s has been deactivated", creator.Username)
+	}
+	if !s.api.HasPermissionToChannel(creator.Id, schedule.ReportChannelID, mattermost.PermissionCreatePost) {
+		return errors.Wrapf(ErrForbidden, "creator %s cannot post to the report channel anymore", creator.Username)
+	}
+	params := schedule.Params
+	if schedule.SinceLastRun && schedule.LastRunAt > 0 {
+		params.Since = schedule.LastRunAt
+		params.Until = now.UnixMilli()
+	}
+	export, err := s.exportService.PrepareExport(schedule.Scope, schedule.ScopeID, schedule.CreatorID, params)
+	if err != nil {
+		return err
+	}
+	fileInfo, err := s.exportService.UploadExport(export, schedule.ReportChannelID, nil)
+	if err != nil {
+		return err
+	}
+	return s.postToReportChannel(schedule, fmt.Sprintf("Scheduled export **%s** of **%s**", schedule.Name, export.Name), []string{fileInfo.Id})
+}
+
+// Stops a schedule whose creator can no longer run it, which can only be deleted afterwards
+func (s *ExportScheduleService) disableExportSchedule(schedule ExportSchedule, now time.Time, reason error) {
+	s.api.LogWarn("Disabling export schedule", "scheduleId", schedule.ID, "err", reason)
+	if err := s.store.DisableExportSchedule(schedule.ID, now.UnixMilli(), reason.Error()); err != nil {
+		s.api.LogError("failed to disable export schedule", "scheduleId", schedule.ID, "err", err)
+		return
+	}
+	_ = s.postToReportChannel(schedule, fmt.Sprintf("The scheduled export **%s** has been disabled: %s", schedule.Name, reason.Error()), nil)
+}
+
+func (s *ExportScheduleService) postToReportChannel(schedule ExportSchedule, message string, fileIDs []string) error {
+	if _, appErr := s.api.CreatePost(&mattermost.Post{
+		UserId:    s.botID,
+		ChannelId: schedule.ReportChannelID,
+		Message:   message,
+		FileIds:   fileIDs,
+	}); appErr != nil {
+		s.api.LogWarn("Unable to post to the report channel", "scheduleId", schedule.ID, "channelId", schedule.ReportChannelID, "err", appErr)
+		return errors.Wrap(appErr, "unable to post to the report channel")
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin/plugintest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// testExportScheduleStore records the schedules disabled and the runs updated
+type testExportScheduleStore struct {
+	ExportScheduleStore
+	disabled map[string]string
+	updated  map[string]string
+}
+
+func (s *testExportScheduleStore) DisableExportSchedule(scheduleID string, _ int64, reason string) error {
+	s.disabled[scheduleID] = reason
+	return nil
+}
+
+func (s *testExportScheduleStore) UpdateExportScheduleRun(scheduleID string, _, _ int64, lastError string) error {
+	s.updated[scheduleID] = lastError
+	return nil
+}
+
+// Tests that schedules whose creator can no longer run them are disabled instead of running.
+func TestRunDueExportScheduleDisablesSchedule(t *testing.T) {
+	schedule := ExportSchedule{
+		ID:              "scheduleid",
+		Name:            "Weekly",
+		Cron:            "0 9 * * 1",
+		Scope:           ExportScopeChannel,
+		ScopeID:         "channelid",
+		Params:          ExportChannelParams{Format: MarkdownFormat},
+		ReportChannelID: "reportchannelid",
+		CreatorID:       aliceID,
+	}
+
+	tests := []struct {
+		name            string
+		deleteAt        int64
+		canPost         bool
+		canRead         bool
+		expectedMessage string
+	}{
+		{name: "deactivated creator", deleteAt: 1680000000000, canPost: true, canRead: true, expectedMessage: "creator alice has been deactivated"},
+		{name: "creator no longer posting to the report channel", canPost: false, canRead: true, expectedMessage: "creator alice cannot post to the report channel anymore"},
+		{name: "creator no longer reading the channel", canPost: true, canRead: false, expectedMessage: "user " + aliceID + " cannot read channel channelid"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			api := &plugintest.API{}
+			api.On("LogInfo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+			api.On("LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+			api.On("GetUser", aliceID).Return(&mattermost.User{Id: aliceID, Username: "alice", DeleteAt: test.deleteAt}, nil)
+			api.On("HasPermissionToChannel", aliceID, "reportchannelid", mattermost.PermissionCreatePost).Return(test.canPost).Maybe()
+			api.On("HasPermissionToChannel", aliceID, "channelid", mattermost.PermissionReadChannel).Return(test.canRead).Maybe()
+			api.On("CreatePost", mock.MatchedBy(func(post *mattermost.Post) bool {
+				return post.UserId == "botid" && post.ChannelId == "reportchannelid"
+			})).Return(&mattermost.Post{}, nil).Once()
+			store := &testExportScheduleStore{disabled: map[string]string{}, updated: map[string]string{}}
+			service := &ExportScheduleService{api: api, store: store, exportService: &ExportService{api: api}, botID: "botid"}
+
+			service.runDueExportSchedule(schedule, time.Now())
+
+			assert.Contains(t, store.disabled["scheduleid"], test.expectedMessage)
+			assert.Empty(t, store.updated)
+			api.AssertExpectations(t)
+		})
+	}
+}
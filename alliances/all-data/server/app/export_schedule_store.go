@@ -0,0 +1,22 @@
+package app
+
+// ExportScheduleStore is an interface for storing export schedules
+type ExportScheduleStore interface {
+	AddExportSchedule(schedule ExportSchedule) error
+
+	GetExportSchedule(scheduleID string) (ExportSchedule, error)
+
+	// GetExportSchedules retrieves all the schedules, or only the ones created by the given user if not empty
+	GetExportSchedules(creatorID string) ([]ExportSchedule, error)
+
+	// GetDueExportSchedules retrieves the enabled schedules whose next run is due at the given time
+	GetDueExportSchedules(now int64) ([]ExportSchedule, error)
+
+	// UpdateExportScheduleRun records the outcome of a run along with the time of the next one
+	UpdateExportScheduleRun(scheduleID string, lastRunAt, nextRunAt int64, lastError string) error
+
+	// DisableExportSchedule stops a schedule from running, recording the reason as its last error
+	DisableExportSchedule(scheduleID string, disableAt int64, reason string) error
+
+	DeleteExportSchedule(scheduleID string) error
+}
@@ -0,0 +1,407 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/util"
+)
+
+const (
+	exportJobKeyPrefix = "export_job_"
+	// Jobs are kept long enough for their users to check them, after that the export is still in the DM with the bot
+	exportJobExpiry = 7 * 24 * time.Hour
+	// Number of root posts read at once, which also sets how often the progress of a job is updated
+	exportPageSize = 200
+)
+
+type ExportService struct {
+	api                 plugin.API
+	channelService      *ChannelService
+	platformService     *config.PlatformService
+	linkService         *LinkService
+	mattermostPostStore MattermostPostStore
+	providerClient      *link.ProviderClient
+	botID               string
+	pluginID            string
+}
+
+// NewExportService returns a new exports service
+func NewExportService(api plugin.API, channelService *ChannelService, platformService *config.PlatformService, linkService *LinkService, providerClient *link.ProviderClient, mattermostPostStore MattermostPostStore, botID, pluginID string) *ExportService {
+	return &ExportService{
+		api:                 api,
+		channelService:      channelService,
+		platformService:     platformService,
+		linkService:         linkService,
+		mattermostPostStore: mattermostPostStore,
+		providerClient:      providerClient,
+		botID:               botID,
+		pluginID:            pluginID,
+	}
+}
+
+// Prepares the export of a channel, checking that the user can read it. Threads are then written with WriteChannelExport.
+func (s *ExportService) NewChannelExport(channelID, userID string, params ExportChannelParams) (*ChannelExport, Exporter, error) {
+	exporter, found := GetExporter(params.Format)
+	if !found {
+		return nil, nil, errors.Errorf("unsupported export format %s", params.Format)
+	}
+	if !s.api.HasPermissionToChannel(userID, channelID, mattermost.PermissionReadChannel) {
+		return nil, nil, errors.Wrapf(ErrForbidden, "user %s cannot read channel %s", userID, channelID)
+	}
+	filter, err := s.newExportFilter(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	channel, appErr := s.api.GetChannel(channelID)
+	if appErr != nil {
+		return nil, nil, errors.Wrap(ErrNotFound, appErr.Error())
+	}
+
+	export := &ChannelExport{
+		Channel:    channel,
+		SiteURL:    *s.api.GetConfig().ServiceSettings.SiteURL,
+		Threads:    []*ExportThread{},
+		Users:      make(map[string]*mattermost.User),
+		FileLinks:  make(map[string]string),
+		References: params.References,
+		ExportedAt: time.Now().UnixMilli(),
+		filter:     filter,
+	}
+	if channel.TeamId != "" {
+		if export.Team, appErr = s.api.GetTeam(channel.TeamId); appErr != nil {
+			return nil, nil, errors.Wrap(appErr, "unable to call GetTeam during channel export")
+		}
+	}
+	backlinks, err := s.channelService.GetExportBacklinks(channelID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to get backlinks during channel export")
+	}
+	export.Backlinks = backlinks
+	return export, exporter, nil
+}
+
+// Writes the threads of a channel in conversation order, a page at a time, so that the export never needs to be all in memory.
+// The progress function, if any, is called with the number of threads read after each page, including those left out by filters.
+func (s *ExportService) WriteChannelExport(w io.Writer, exporter Exporter, export *ChannelExport, progress func(threadsDone int)) error {
+	writer, err := exporter.NewWriter(w, export)
+	if err != nil {
+		return errors.Wrap(err, "unable to start writing channel export")
+	}
+
+	threadsDone := 0
+	cursor := PostCursor{}
+	for {
+		roots, err := s.mattermostPostStore.GetRootPostsForChannel(export.Channel.Id, cursor, exportPageSize, export.filter.rootPostsOptions())
+		if err != nil {
+			return err
+		}
+		if len(roots) == 0 {
+			break
+		}
+		threads, err := s.getExportThreads(export, roots)
+		if err != nil {
+			return err
+		}
+		for _, thread := range threads {
+			if err := writer.WriteThread(thread); err != nil {
+				return errors.Wrap(err, "unable to write channel export thread")
+			}
+		}
+
+		threadsDone += len(roots)
+		if progress != nil {
+			progress(threadsDone)
+		}
+		last := roots[len(roots)-1]
+		cursor = PostCursor{CreateAt: last.CreateAt, PostID: last.Id}
+	}
+	return writer.Close()
+}
+
+// Prepares an export of the given scope, which can be a channel, a section or an organization.
+func (s *ExportService) PrepareExport(scope, scopeID, userID string, params ExportChannelParams) (*PreparedExport, error) {
+	switch scope {
+	case ExportScopeChannel:
+		return s.prepareChannelExport(scopeID, userID, params)
+	case ExportScopeSection:
+		return s.prepareSectionExport(scopeID, userID, params)
+	case ExportScopeOrganization:
+		return s.prepareOrganizationExport(scopeID, userID, params)
+	default:
+		return nil, errors.Wrapf(ErrInvalidInput, "unknown export scope %s", scope)
+	}
+}
+
+func (s *ExportService) prepareChannelExport(channelID, userID string, params ExportChannelParams) (*PreparedExport, error) {
+	export, exporter, err := s.NewChannelExport(channelID, userID, params)
+	if err != nil {
+		return nil, err
+	}
+	threadsTotal, err := s.mattermostPostStore.CountRootPostsForChannel(channelID, export.filter.rootPostsOptions())
+	if err != nil {
+		return nil, err
+	}
+	return &PreparedExport{
+		Name:         export.Channel.DisplayName,
+		FileName:     exporter.FileName(export.Channel.Name),
+		ThreadsTotal: threadsTotal,
+		write: func(w io.Writer, progress func(threadsDone int)) error {
+			return s.WriteChannelExport(w, exporter, export, progress)
+		},
+	}, nil
+}
+
+// Starts an export in the background. Once done, the bot sends the export to the user in a direct message.
+func (s *ExportService) StartExportJob(scope, scopeID, userID string, params ExportChannelParams) (*ExportJob, error) {
+	export, err := s.PrepareExport(scope, scopeID, userID, params)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UnixMilli()
+	job := &ExportJob{
+		ID:           util.GenerateUUID(),
+		UserID:       userID,
+		Scope:        scope,
+		ScopeID:      scopeID,
+		Format:       params.Format,
+		Status:       ExportJobPending,
+		ThreadsTotal: export.ThreadsTotal,
+		FileName:     export.FileName,
+		CreateAt:     now,
+		UpdateAt:     now,
+	}
+	if err := s.saveExportJob(job); err != nil {
+		return nil, err
+	}
+	go s.runExportJob(job, export)
+	return job, nil
+}
+
+// Writes an export and uploads it to a channel on behalf of the bot, without posting it.
+// Upload sessions need the size of the file upfront, so the export is written to a temporary file first, which is removed whatever the outcome.
+func (s *ExportService) UploadExport(export *PreparedExport, channelID string, progress func(threadsDone int)) (*mattermost.FileInfo, error) {
+	file, err := os.CreateTemp("", "hood-export-*")
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create temporary export file")
+	}
+	defer func() {
+		_ = file.Close()
+		if err := os.Remove(file.Name()); err != nil {
+			s.api.LogWarn("Unable to remove temporary export file", "file", file.Name(), "err", err.Error())
+		}
+	}()
+
+	if progress == nil {
+		progress = func(int) {}
+	}
+	if err := export.write(file, progress); err != nil {
+		return nil, err
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get export file size")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "unable to rewind export file")
+	}
+
+	uploadSession, err := s.api.CreateUploadSession(&mattermost.UploadSession{
+		Id:        mattermost.NewId(),
+		Type:      mattermost.UploadTypeAttachment,
+		UserId:    s.botID,
+		ChannelId: channelID,
+		Filename:  export.FileName,
+		FileSize:  size,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create upload session for export")
+	}
+	fileInfo, err := s.api.UploadData(uploadSession, file)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to upload export")
+	}
+	return fileInfo, nil
+}
+
+// Gets an export job, which can only be seen by the user who started it and by system admins.
+func (s *ExportService) GetExportJob(jobID, userID string) (*ExportJob, error) {
+	var job *ExportJob
+	if err := kvGetJSON(s.api, exportJobKeyPrefix+jobID, &job); err != nil {
+		return nil, errors.Wrap(err, "unable to get export job")
+	}
+	if job == nil {
+		return nil, errors.Wrapf(ErrNotFound, "export job %s not found", jobID)
+	}
+	if job.UserID != userID && !s.api.HasPermissionTo(userID, mattermost.PermissionManageSystem) {
+		return nil, errors.Wrapf(ErrForbidden, "user %s cannot see export job %s", userID, jobID)
+	}
+	return job, nil
+}
+
+// Returns the URL the file of a completed export job can be downloaded from.
+func (s *ExportService) GetExportJobFileURL(jobID, userID string) (string, error) {
+	job, err := s.GetExportJob(jobID, userID)
+	if err != nil {
+		return "", err
+	}
+	if job.Status != ExportJobDone || job.FileID == "" {
+		return "", errors.Wrapf(ErrNotFound, "export job %s has no file yet", jobID)
+	}
+	siteURL := strings.TrimSuffix(*s.api.GetConfig().ServiceSettings.SiteURL, "/")
+	return fmt.Sprintf("%s/api/v4/files/%s?download=1", siteURL, job.FileID), nil
+}
+
+func (s *ExportService) runExportJob(job *ExportJob, export *PreparedExport) {
+	s.api.LogInfo("Running export job", "jobId", job.ID, "name", export.Name, "format", job.Format)
+	job.Status = ExportJobRunning
+	s.updateExportJob(job)
+
+	if err := s.exportToDirectMessage(job, export); err != nil {
+		s.api.LogError("Export job failed", "jobId", job.ID, "err", err)
+		job.Status = ExportJobFailed
+		job.Error = err.Error()
+		s.updateExportJob(job)
+		_ = s.notifyExportJob(job, fmt.Sprintf("The export of **%s** failed: %s", export.Name, job.Error), nil)
+		return
+	}
+	job.Status = ExportJobDone
+	s.updateExportJob(job)
+	s.api.LogInfo("Export job done", "jobId", job.ID, "threads", job.ThreadsDone)
+}
+
+// Uploads the export to the direct channel between the bot and the user, then posts the download link there
+func (s *ExportService) exportToDirectMessage(job *ExportJob, export *PreparedExport) error {
+	directChannel, appErr := s.api.GetDirectChannel(s.botID, job.UserID)
+	if appErr != nil {
+		return errors.Wrap(appErr, "unable to get direct channel with the bot")
+	}
+	fileInfo, err := s.UploadExport(export, directChannel.Id, func(threadsDone int) {
+		job.ThreadsDone = threadsDone
+		s.updateExportJob(job)
+	})
+	if err != nil {
+		return err
+	}
+
+	siteURL := strings.TrimSuffix(*s.api.GetConfig().ServiceSettings.SiteURL, "/")
+	job.FileID = fileInfo.Id
+	job.DownloadURL = fmt.Sprintf("%s/plugins/%s/api/v0/exports/%s/download", siteURL, s.pluginID, job.ID)
+	message := fmt.Sprintf("The export of **%s** is ready: [%s](%s)", export.Name, job.FileName, job.DownloadURL)
+	return s.notifyExportJob(job, message, []string{fileInfo.Id})
+}
+
+func (s *ExportService) notifyExportJob(job *ExportJob, message string, fileIDs []string) error {
+	directChannel, appErr := s.api.GetDirectChannel(s.botID, job.UserID)
+	if appErr != nil {
+		s.api.LogWarn("Unable to get direct channel to notify export job", "jobId", job.ID, "err", appErr)
+		return errors.Wrap(appErr, "unable to get direct channel with the bot")
+	}
+	if _, appErr := s.api.CreatePost(&mattermost.Post{
+		UserId:    s.botID,
+		ChannelId: directChannel.Id,
+		Message:   message,
+		FileIds:   fileIDs,
+	}); appErr != nil {
+		s.api.LogWarn("Unable to notify export job", "jobId", job.ID, "err", appErr)
+		return errors.Wrap(appErr, "unable to post export")
+	}
+	return nil
+}
+
+// Progress updates are best effort, a failure to store them does not stop the job
+func (s *ExportService) updateExportJob(job *ExportJob) {
+	job.UpdateAt = time.Now().UnixMilli()
+	if err := s.saveExportJob(job); err != nil {
+		s.api.LogWarn("Unable to update export job", "jobId", job.ID, "err", err)
+	}
+}
+
+func (s *ExportService) saveExportJob(job *ExportJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal export job")
+	}
+	if appErr := s.api.KVSetWithExpiry(exportJobKeyPrefix+job.ID, data, int64(exportJobExpiry.Seconds())); appErr != nil {
+		return errors.Wrap(appErr, "unable to save export job")
+	}
+	return nil
+}
+
+// Collects the replies to a page of root posts with a single query and applies the export filters,
+// then fetches the authors and attached files of the posts left
+func (s *ExportService) getExportThreads(export *ChannelExport, roots []*mattermost.Post) ([]*ExportThread, error) {
+	postIDs := make([]string, 0, len(roots))
+	threads := make([]*ExportThread, 0, len(roots))
+	threadsByRootID := make(map[string]*ExportThread, len(roots))
+	for _, root := range roots {
+		thread := &ExportThread{Root: root, Replies: []*mattermost.Post{}}
+		postIDs = append(postIDs, root.Id)
+		threads = append(threads, thread)
+		threadsByRootID[root.Id] = thread
+	}
+
+	replies, err := s.mattermostPostStore.GetRepliesForRootPosts(postIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, reply := range replies {
+		if thread, found := threadsByRootID[reply.RootId]; found {
+			thread.Replies = append(thread.Replies, reply)
+			postIDs = append(postIDs, reply.Id)
+		}
+	}
+
+	reactions := make(map[string][]string)
+	if export.filter.needsReactions() {
+		postReactions, err := s.mattermostPostStore.GetReactionsForPosts(postIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, reaction := range postReactions {
+			reactions[reaction.PostId] = append(reactions[reaction.PostId], reaction.EmojiName)
+		}
+	}
+
+	filtered := make([]*ExportThread, 0, len(threads))
+	for _, thread := range threads {
+		thread, found := export.filter.filterThread(thread, export.Backlinks, reactions)
+		if !found {
+			continue
+		}
+		for _, post := range append([]*mattermost.Post{thread.Root}, thread.Replies...) {
+			s.addExportPostDetails(export, post)
+		}
+		filtered = append(filtered, thread)
+	}
+	return filtered, nil
+}
+
+// Fetches the author and the file links of a post, unless already fetched for another post
+func (s *ExportService) addExportPostDetails(export *ChannelExport, post *mattermost.Post) {
+	if _, found := export.Users[post.UserId]; !found {
+		user, err := s.api.GetUser(post.UserId)
+		if err != nil {
+			s.api.LogWarn("failed retrieving post author during channel export", "userId", post.UserId, "err", err)
+		}
+		export.Users[post.UserId] = user
+	}
+	for _, fileID := range post.FileIds {
+		fileLink, err := s.api.GetFileLink(fileID)
+		if err != nil {
+			s.api.LogWarn("Failed to get URL for file during channel export", "fileId", fileID, "err", err)
+			continue
+		}
+		export.FileLinks[fileID] = fileLink
+	}
+}
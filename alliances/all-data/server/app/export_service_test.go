@@ -0,0 +1,77 @@
+package app
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin/plugintest"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPreparedExport(writeErr error) *PreparedExport {
+	return &PreparedExport{
+		FileName: "channel.md",
+		write: func(w io.Writer, progress func(threadsDone int)) error {
+			if _, err := io.WriteString(w, "# Channel"); err != nil {
+				return err
+			}
+			progress(1)
+			return writeErr
+		},
+	}
+}
+
+// Tests that the temporary file an export is written to is removed whether the upload succeeds or not.
+func TestUploadExportRemovesTemporaryFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		writeErr  error
+		uploadErr error
+	}{
+		{name: "uploaded"},
+		{name: "write failure", writeErr: errors.New("provider unreachable")},
+		{name: "upload failure", uploadErr: errors.New("file store unavailable")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			t.Setenv("TMPDIR", tempDir)
+
+			api := &plugintest.API{}
+			api.On("LogWarn", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+			api.On("CreateUploadSession", mock.MatchedBy(func(session *mattermost.UploadSession) bool {
+				return session.FileSize == int64(len("# Channel"))
+			})).Return(&mattermost.UploadSession{}, nil).Maybe()
+			api.On("UploadData", mock.Anything, mock.Anything).Return(func(_ *mattermost.UploadSession, r io.Reader) *mattermost.FileInfo {
+				content, err := io.ReadAll(r)
+				require.NoError(t, err)
+				assert.Equal(t, "# Channel", string(content))
+				if test.uploadErr != nil {
+					return nil
+				}
+				return &mattermost.FileInfo{Id: "fileid"}
+			}, func(*mattermost.UploadSession, io.Reader) error {
+				return test.uploadErr
+			}).Maybe()
+			service := &ExportService{api: api, botID: "botid"}
+
+			fileInfo, err := service.UploadExport(newTestPreparedExport(test.writeErr), "channelid", nil)
+			if test.writeErr != nil || test.uploadErr != nil {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "fileid", fileInfo.Id)
+			}
+
+			files, err := os.ReadDir(tempDir)
+			require.NoError(t, err)
+			assert.Empty(t, files)
+		})
+	}
+}
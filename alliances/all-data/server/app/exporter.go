@@ -0,0 +1,67 @@
+package app
+
+import (
+	"io"
+	"sort"
+)
+
+const (
+	JSONFormat     = "json"
+	MarkdownFormat = "markdown"
+	HTMLFormat     = "html"
+	CSVFormat      = "csv" // Also the format of the backlink graphs exported as edge lists
+	PDFFormat      = "pdf"
+)
+
+// Exporter writes an exported channel in a specific format.
+type Exporter interface {
+	FileName(name string) string
+	ContentType() string
+
+	// NewWriter starts writing an export, whose threads are then written one at a time so that they never need to be all in memory
+	NewWriter(w io.Writer, export *ChannelExport) (ExportWriter, error)
+}
+
+// ExportWriter writes the threads of an export, in conversation order. Close completes the output, but does not close the underlying writer.
+type ExportWriter interface {
+	WriteThread(thread *ExportThread) error
+	Close() error
+}
+
+// WriteExport writes an export whose threads are all in memory.
+func WriteExport(exporter Exporter, w io.Writer, export *ChannelExport) error {
+	writer, err := exporter.NewWriter(w, export)
+	if err != nil {
+		return err
+	}
+	for _, thread := range export.Threads {
+		if err := writer.WriteThread(thread); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+var exporters = map[string]Exporter{
+	JSONFormat:     &JSON{},
+	MarkdownFormat: &Markdown{},
+	HTMLFormat:     &HTML{},
+	CSVFormat:      &CSV{},
+	PDFFormat:      &PDF{},
+}
+
+// GetExporter returns the channel exporter registered for the given format.
+func GetExporter(format string) (Exporter, bool) {
+	exporter, found := exporters[format]
+	return exporter, found
+}
+
+// GetExportFormats returns the formats channels can be exported to, sorted by name.
+func GetExportFormats() []string {
+	formats := make([]string, 0, len(exporters))
+	for format := range exporters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
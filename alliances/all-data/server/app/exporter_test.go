@@ -0,0 +1,105 @@
+package app_test
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// Extends the shared channel export with text the exporters have to escape or wrap, and with a linked element
+func newFormattedChannelExport() *app.ChannelExport {
+	export := newChannelExport()
+	export.Threads[1].Root = &mattermost.Post{
+		Id:       "otherpostid",
+		UserId:   "carol",
+		CreateAt: 1680000180000,
+		Message:  "=SUM(A1) <script>alert('x')</script> café – a long line that goes on and on to check that text is wrapped at the page width 🙂\n\n- item\n- `code`",
+	}
+	export.Backlinks = map[string][]*app.ExportBacklink{
+		"rootpostid": {{
+			Key:  "organizations/1/sections/10/elements/abc",
+			Name: "Org.Issues.Flood (2023)",
+			URL:  "http://localhost:8065/alliances/organizations/1/issues/abc?parentId=10",
+		}},
+	}
+	return export
+}
+
+// Tests the channel exporters against the golden files in testdata/exports, which are rewritten when running with -update.
+func TestExporters(t *testing.T) {
+	tests := []struct {
+		format     string
+		goldenFile string
+	}{
+		{format: app.MarkdownFormat, goldenFile: "channel.md"},
+		{format: app.HTMLFormat, goldenFile: "channel.html"},
+		{format: app.CSVFormat, goldenFile: "channel.csv"},
+		{format: app.PDFFormat, goldenFile: "channel.pdf"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			exporter, found := app.GetExporter(test.format)
+			require.True(t, found)
+
+			var output bytes.Buffer
+			require.NoError(t, app.WriteExport(exporter, &output, newFormattedChannelExport()))
+
+			goldenPath := filepath.Join("testdata", "exports", test.goldenFile)
+			if *update {
+				require.NoError(t, os.MkdirAll(filepath.Dir(goldenPath), 0755))
+				require.NoError(t, os.WriteFile(goldenPath, output.Bytes(), 0644))
+			}
+			expected, err := os.ReadFile(goldenPath)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), output.String())
+		})
+	}
+}
+
+// Tests that every registered format can be looked up and names its files with its own extension.
+func TestGetExportFormats(t *testing.T) {
+	assert.Equal(t, []string{"csv", "html", "json", "markdown", "pdf"}, app.GetExportFormats())
+	for _, format := range app.GetExportFormats() {
+		exporter, found := app.GetExporter(format)
+		require.True(t, found)
+		assert.NotEqual(t, "channel", exporter.FileName("channel"))
+	}
+
+	_, found := app.GetExporter("docx")
+	assert.False(t, found)
+}
+
+// Tests that user provided text cannot be interpreted as markup or formulas by the programs opening the exports.
+func TestExportersEscapeUserText(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+		rejected string
+	}{
+		{format: app.HTMLFormat, expected: "&lt;script&gt;alert(", rejected: "<script>"},
+		{format: app.CSVFormat, expected: "\"'=SUM(A1)", rejected: "\"=SUM(A1)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			exporter, found := app.GetExporter(test.format)
+			require.True(t, found)
+
+			var output bytes.Buffer
+			require.NoError(t, app.WriteExport(exporter, &output, newFormattedChannelExport()))
+			assert.Contains(t, output.String(), test.expected)
+			assert.NotContains(t, output.String(), test.rejected)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type CSVEdgeList struct{}
+
+func (e *CSVEdgeList) FileName(name string) string {
+	return fmt.Sprintf("%s.csv", name)
+}
+
+func (e *CSVEdgeList) ContentType() string {
+	return "text/csv"
+}
+
+// Export writes one row per edge, with the attributes of both the post and the element it links.
+func (e *CSVEdgeList) Export(w io.Writer, graph *BacklinkGraph) error {
+	posts := make(map[string]*BacklinkGraphPost, len(graph.Posts))
+	for _, post := range graph.Posts {
+		posts[post.ID] = post
+	}
+	elements := make(map[string]*BacklinkGraphElement, len(graph.Elements))
+	for _, element := range graph.Elements {
+		elements[element.Key] = element
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{
+		"source", "target", "text",
+		"post_url", "author_id", "author_name", "channel_id", "channel_name", "create_at", "timestamp", "root_id",
+		"element_name", "element_url",
+	}); err != nil {
+		return err
+	}
+	for _, edge := range graph.Edges {
+		post, found := posts[edge.Source]
+		if !found {
+			post = &BacklinkGraphPost{}
+		}
+		element, found := elements[edge.Target]
+		if !found {
+			element = &BacklinkGraphElement{}
+		}
+		if err := writer.Write([]string{
+			edge.Source, edge.Target, edge.Text,
+			post.URL, post.AuthorID, post.AuthorName, post.ChannelID, post.ChannelName,
+			strconv.FormatInt(post.CreateAt, 10), formatGraphTimestamp(post.CreateAt), post.RootID,
+			element.Name, element.URL,
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
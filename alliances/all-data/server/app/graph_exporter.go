@@ -0,0 +1,30 @@
+package app
+
+import "io"
+
+// Graphs can be exported to CSVFormat too, as edge lists
+const (
+	GraphMLFormat = "graphml"
+	JSONLDFormat  = "jsonld"
+)
+
+// GraphExporter writes a backlink graph in a format suitable for offline analysis.
+type GraphExporter interface {
+	FileName(name string) string
+	ContentType() string
+	Export(w io.Writer, graph *BacklinkGraph) error
+}
+
+// GetGraphExporter returns the exporter for the given format, defaulting to GraphML.
+func GetGraphExporter(format string) (GraphExporter, bool) {
+	switch format {
+	case GraphMLFormat, "":
+		return &GraphML{}, true
+	case CSVFormat:
+		return &CSVEdgeList{}, true
+	case JSONLDFormat:
+		return &JSONLD{}, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,97 @@
+package app_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// A root and a reply linking an element, and a widget of an element whose URL could not be resolved
+func newBacklinkGraph() *app.BacklinkGraph {
+	return &app.BacklinkGraph{
+		Posts: []*app.BacklinkGraphPost{
+			{
+				ID:          "rootpostid",
+				URL:         "http://localhost:8065/hood/pl/rootpostid",
+				Message:     "See [the \"flood\" chart](http://localhost/x) & <more>",
+				AuthorID:    "alice",
+				AuthorName:  "alice",
+				ChannelID:   "channelid",
+				ChannelName: "Incident",
+				CreateAt:    1680000000000,
+			},
+			{
+				ID:          "replypostid",
+				URL:         "http://localhost:8065/hood/pl/replypostid",
+				Message:     "Agreed, =SUM(A1)",
+				AuthorID:    "bob",
+				AuthorName:  "Bob Smith",
+				ChannelID:   "channelid",
+				ChannelName: "Incident",
+				CreateAt:    1680000060000,
+				RootID:      "rootpostid",
+			},
+		},
+		Elements: []*app.BacklinkGraphElement{
+			{
+				Key:  "organizations/1/sections/10/elements/abc",
+				Name: "Org.Issues.Flood (2023)",
+				URL:  "http://localhost:8065/alliances/organizations/1/issues/abc?parentId=10",
+			},
+			{
+				Key:  "organizations/1/sections/10/elements/def#chart",
+				Name: "Org.Issues.Drought.Chart",
+			},
+		},
+		Edges: []*app.BacklinkGraphEdge{
+			{ID: "rootpostid-abc", Source: "rootpostid", Target: "organizations/1/sections/10/elements/abc", Text: "the \"flood\" chart"},
+			{ID: "replypostid-def", Source: "replypostid", Target: "organizations/1/sections/10/elements/def#chart", Text: "drought"},
+		},
+	}
+}
+
+// Tests the backlink graph exporters against the golden files in testdata/exports, which are rewritten when running with -update.
+func TestGraphExporters(t *testing.T) {
+	tests := []struct {
+		format     string
+		goldenFile string
+	}{
+		{format: app.GraphMLFormat, goldenFile: "backlinks.graphml"},
+		{format: app.CSVFormat, goldenFile: "backlinks.csv"},
+		{format: app.JSONLDFormat, goldenFile: "backlinks.jsonld"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			exporter, found := app.GetGraphExporter(test.format)
+			require.True(t, found)
+
+			var output bytes.Buffer
+			require.NoError(t, exporter.Export(&output, newBacklinkGraph()))
+
+			goldenPath := filepath.Join("testdata", "exports", test.goldenFile)
+			if *update {
+				require.NoError(t, os.WriteFile(goldenPath, output.Bytes(), 0644))
+			}
+			expected, err := os.ReadFile(goldenPath)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), output.String())
+		})
+	}
+}
+
+// Tests that elements without URL still get an identifier the citations of the posts can refer to.
+func TestJSONLDIdentifiesElementsWithoutURL(t *testing.T) {
+	exporter, _ := app.GetGraphExporter(app.JSONLDFormat)
+	var output bytes.Buffer
+	require.NoError(t, exporter.Export(&output, newBacklinkGraph()))
+
+	assert.NotContains(t, output.String(), `"@id": ""`)
+	assert.Equal(t, 2, bytes.Count(output.Bytes(), []byte(`"@id": "_:organizations-1-sections-10-elements-def-chart"`)))
+}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const graphMLNamespace = "http://graphml.graphdrawing.org/xmlns"
+
+type GraphML struct{}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (e *GraphML) FileName(name string) string {
+	return fmt.Sprintf("%s.graphml", name)
+}
+
+func (e *GraphML) ContentType() string {
+	return "application/graphml+xml"
+}
+
+func (e *GraphML) Export(w io.Writer, graph *BacklinkGraph) error {
+	document := graphMLDocument{
+		XMLNS: graphMLNamespace,
+		Keys: []graphMLKey{
+			{ID: "type", For: "node", AttrName: "type", AttrType: "string"},
+			{ID: "name", For: "node", AttrName: "name", AttrType: "string"},
+			{ID: "url", For: "node", AttrName: "url", AttrType: "string"},
+			{ID: "message", For: "node", AttrName: "message", AttrType: "string"},
+			{ID: "authorId", For: "node", AttrName: "authorId", AttrType: "string"},
+			{ID: "authorName", For: "node", AttrName: "authorName", AttrType: "string"},
+			{ID: "channelId", For: "node", AttrName: "channelId", AttrType: "string"},
+			{ID: "channelName", For: "node", AttrName: "channelName", AttrType: "string"},
+			{ID: "createAt", For: "node", AttrName: "createAt", AttrType: "long"},
+			{ID: "timestamp", For: "node", AttrName: "timestamp", AttrType: "string"},
+			{ID: "rootId", For: "node", AttrName: "rootId", AttrType: "string"},
+			{ID: "text", For: "edge", AttrName: "text", AttrType: "string"},
+		},
+		Graph: graphMLGraph{
+			ID:          "backlinks",
+			EdgeDefault: "directed",
+			Nodes:       []graphMLNode{},
+			Edges:       []graphMLEdge{},
+		},
+	}
+	for _, post := range graph.Posts {
+		document.Graph.Nodes = append(document.Graph.Nodes, graphMLNode{
+			ID: post.ID,
+			Data: []graphMLData{
+				{Key: "type", Value: BacklinkGraphPostNode},
+				{Key: "url", Value: post.URL},
+				{Key: "message", Value: post.Message},
+				{Key: "authorId", Value: post.AuthorID},
+				{Key: "authorName", Value: post.AuthorName},
+				{Key: "channelId", Value: post.ChannelID},
+				{Key: "channelName", Value: post.ChannelName},
+				{Key: "createAt", Value: strconv.FormatInt(post.CreateAt, 10)},
+				{Key: "timestamp", Value: formatGraphTimestamp(post.CreateAt)},
+				{Key: "rootId", Value: post.RootID},
+			},
+		})
+	}
+	for _, element := range graph.Elements {
+		document.Graph.Nodes = append(document.Graph.Nodes, graphMLNode{
+			ID: element.Key,
+			Data: []graphMLData{
+				{Key: "type", Value: BacklinkGraphElementNode},
+				{Key: "name", Value: element.Name},
+				{Key: "url", Value: element.URL},
+			},
+		})
+	}
+	for _, edge := range graph.Edges {
+		document.Graph.Edges = append(document.Graph.Edges, graphMLEdge{
+			ID:     edge.ID,
+			Source: edge.Source,
+			Target: edge.Target,
+			Data:   []graphMLData{{Key: "text", Value: edge.Text}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+// Formats a timestamp in milliseconds as RFC 3339, in UTC
+func formatGraphTimestamp(millis int64) string {
+	return time.UnixMilli(millis).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const schemaOrgContext = "https://schema.org/"
+
+// JSONLD exports the graph using the schema.org vocabulary: posts are SocialMediaPosting nodes citing CreativeWork elements.
+// The text of each link is kept through a Role wrapping the cited element, as per the schema.org role pattern.
+type JSONLD struct{}
+
+type jsonLDDocument struct {
+	Context string        `json:"@context"`
+	Graph   []interface{} `json:"@graph"`
+}
+
+type jsonLDReference struct {
+	ID string `json:"@id"`
+}
+
+type jsonLDPerson struct {
+	Type       string `json:"@type"`
+	Identifier string `json:"identifier"`
+	Name       string `json:"name"`
+}
+
+type jsonLDConversation struct {
+	Type       string `json:"@type"`
+	Identifier string `json:"identifier"`
+	Name       string `json:"name"`
+}
+
+type jsonLDCitation struct {
+	Type     string          `json:"@type"`
+	RoleName string          `json:"roleName"`
+	Citation jsonLDReference `json:"citation"`
+}
+
+type jsonLDPost struct {
+	ID          string             `json:"@id"`
+	Type        string             `json:"@type"`
+	Identifier  string             `json:"identifier"`
+	Text        string             `json:"text"`
+	Author      jsonLDPerson       `json:"author"`
+	IsPartOf    jsonLDConversation `json:"isPartOf"`
+	DateCreated string             `json:"dateCreated"`
+	ParentItem  *jsonLDReference   `json:"parentItem,omitempty"`
+	Citation    []jsonLDCitation   `json:"citation"`
+}
+
+type jsonLDElement struct {
+	ID         string `json:"@id"`
+	Type       string `json:"@type"`
+	Identifier string `json:"identifier"`
+	Name       string `json:"name"`
+	URL        string `json:"url,omitempty"`
+}
+
+func (e *JSONLD) FileName(name string) string {
+	return fmt.Sprintf("%s.jsonld", name)
+}
+
+func (e *JSONLD) ContentType() string {
+	return "application/ld+json"
+}
+
+func (e *JSONLD) Export(w io.Writer, graph *BacklinkGraph) error {
+	postURLs := make(map[string]string, len(graph.Posts))
+	for _, post := range graph.Posts {
+		postURLs[post.ID] = post.URL
+	}
+	elementURLs := make(map[string]string, len(graph.Elements))
+	for _, element := range graph.Elements {
+		elementURLs[element.Key] = element.URL
+	}
+	citations := make(map[string][]jsonLDCitation, len(graph.Posts))
+	for _, edge := range graph.Edges {
+		citations[edge.Source] = append(citations[edge.Source], jsonLDCitation{
+			Type:     "Role",
+			RoleName: edge.Text,
+			Citation: jsonLDReference{ID: jsonLDElementID(edge.Target, elementURLs[edge.Target])},
+		})
+	}
+
+	document := jsonLDDocument{Context: schemaOrgContext, Graph: []interface{}{}}
+	for _, post := range graph.Posts {
+		node := jsonLDPost{
+			ID:          post.URL,
+			Type:        "SocialMediaPosting",
+			Identifier:  post.ID,
+			Text:        post.Message,
+			Author:      jsonLDPerson{Type: "Person", Identifier: post.AuthorID, Name: post.AuthorName},
+			IsPartOf:    jsonLDConversation{Type: "Conversation", Identifier: post.ChannelID, Name: post.ChannelName},
+			DateCreated: formatGraphTimestamp(post.CreateAt),
+			Citation:    citations[post.ID],
+		}
+		if post.RootID != "" {
+			rootURL, found := postURLs[post.RootID]
+			if !found {
+				// Roots without backlinks are not in the graph, but they share the permalink format of their replies
+				rootURL = strings.TrimSuffix(post.URL, post.ID) + post.RootID
+			}
+			node.ParentItem = &jsonLDReference{ID: rootURL}
+		}
+		document.Graph = append(document.Graph, node)
+	}
+	for _, element := range graph.Elements {
+		document.Graph = append(document.Graph, jsonLDElement{
+			ID:         jsonLDElementID(element.Key, element.URL),
+			Type:       "CreativeWork",
+			Identifier: element.Key,
+			Name:       element.Name,
+			URL:        element.URL,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// Elements are identified by their URL, or by a blank node named after their key when they have none
+func jsonLDElementID(key, url string) string {
+	if url != "" {
+		return url
+	}
+	return "_:" + strings.NewReplacer("/", "-", "#", "-").Replace(key)
+}
@@ -0,0 +1,165 @@
+package app
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"strings"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/shared/markdown"
+)
+
+// HTML exports the channel as a self-contained page, which needs no external resources to be displayed.
+type HTML struct{}
+
+type htmlChannel struct {
+	Name       string
+	Header     string
+	Purpose    string
+	ExportedAt string
+}
+
+type htmlWriter struct {
+	w      io.Writer
+	export *ChannelExport
+}
+
+type htmlThread struct {
+	Root    htmlPost
+	Replies []htmlPost
+}
+
+type htmlPost struct {
+	ID          string
+	Author      string
+	CreatedAt   string
+	Pinned      bool
+	Message     template.HTML
+	Attachments []string
+	Backlinks   []*ExportBacklink
+}
+
+// Scripts are disabled, since messages may contain arbitrary links
+var htmlTemplate = template.Must(template.New("channel").Parse(`{{define "header"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="Content-Security-Policy" content="default-src 'none'; style-src 'unsafe-inline'; img-src * data:">
+<title>{{.Name}}</title>
+<style>
+body { font-family: sans-serif; max-width: 800px; margin: 2em auto; color: #1f2328; }
+header { border-bottom: 1px solid #d0d7de; margin-bottom: 1em; }
+.thread { border-bottom: 1px solid #d0d7de; padding: 1em 0; }
+.replies { margin-left: 2em; border-left: 3px solid #d0d7de; padding-left: 1em; }
+.meta { color: #656d76; font-size: 0.9em; }
+.pinned { color: #9a6700; }
+pre { background: #f6f8fa; padding: 0.5em; overflow-x: auto; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Name}}</h1>
+{{- if .Header}}
+<p>{{.Header}}</p>
+{{- end}}
+{{- if .Purpose}}
+<p>{{.Purpose}}</p>
+{{- end}}
+<p class="meta">Exported on {{.ExportedAt}}</p>
+</header>
+{{- end}}
+{{define "thread"}}
+<section class="thread">
+{{template "post" .Root}}
+{{- if .Replies}}
+<div class="replies">
+{{- range .Replies}}
+{{template "post" .}}
+{{- end}}
+</div>
+{{- end}}
+</section>
+{{- end}}
+{{define "footer"}}
+</body>
+</html>
+{{end}}
+{{define "post"}}<article id="{{.ID}}">
+<p class="meta"><strong>{{.Author}}</strong> - {{.CreatedAt}}{{if .Pinned}} <span class="pinned">(pinned)</span>{{end}}</p>
+{{.Message}}
+{{- if or .Attachments .Backlinks}}
+<ul>
+{{- range .Attachments}}
+<li>Attachment: <a href="{{.}}">{{.}}</a></li>
+{{- end}}
+{{- range .Backlinks}}
+<li>Linked element: <a href="{{.URL}}">{{.Name}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+</article>{{end}}
+`))
+
+func (e *HTML) FileName(name string) string {
+	return fmt.Sprintf("%s.html", name)
+}
+
+func (e *HTML) ContentType() string {
+	return "text/html; charset=utf-8"
+}
+
+func (e *HTML) NewWriter(w io.Writer, export *ChannelExport) (ExportWriter, error) {
+	channel := htmlChannel{
+		Name:       export.Channel.DisplayName,
+		Header:     export.Channel.Header,
+		Purpose:    export.Channel.Purpose,
+		ExportedAt: exportTime(export.ExportedAt),
+	}
+	if err := htmlTemplate.ExecuteTemplate(w, "header", channel); err != nil {
+		return nil, err
+	}
+	return &htmlWriter{w: w, export: export}, nil
+}
+
+func (w *htmlWriter) WriteThread(thread *ExportThread) error {
+	htmlThread := htmlThread{Root: toHTMLPost(w.export, thread.Root), Replies: make([]htmlPost, 0, len(thread.Replies))}
+	for _, reply := range thread.Replies {
+		htmlThread.Replies = append(htmlThread.Replies, toHTMLPost(w.export, reply))
+	}
+	return htmlTemplate.ExecuteTemplate(w.w, "thread", htmlThread)
+}
+
+func (w *htmlWriter) Close() error {
+	return htmlTemplate.ExecuteTemplate(w.w, "footer", nil)
+}
+
+func toHTMLPost(export *ChannelExport, post *mattermost.Post) htmlPost {
+	attachments := []string{}
+	for _, fileID := range post.FileIds {
+		if fileLink, found := export.FileLinks[fileID]; found {
+			attachments = append(attachments, fileLink)
+		}
+	}
+	return htmlPost{
+		ID:          post.Id,
+		Author:      export.UserName(post.UserId),
+		CreatedAt:   exportTime(post.CreateAt),
+		Pinned:      post.IsPinned,
+		Message:     renderMessageHTML(post.Message),
+		Attachments: attachments,
+		Backlinks:   export.Backlinks[post.Id],
+	}
+}
+
+// Renders a markdown message the way Mattermost does, falling back to the escaped text if the renderer fails.
+// The renderer escapes any HTML in the message, so its output is safe to embed.
+func renderMessageHTML(message string) (rendered template.HTML) {
+	defer func() {
+		if r := recover(); r != nil {
+			rendered = template.HTML("<p>" + strings.ReplaceAll(html.EscapeString(message), "\n", "<br>") + "</p>")
+		}
+	}()
+	return template.HTML(markdown.RenderHTML(message))
+}
@@ -0,0 +1,252 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/util"
+)
+
+// Post prop with the name of the original author of imported posts
+const importedAuthorProp = "imported_author"
+
+type ImportService struct {
+	api                  plugin.API
+	channelService       *ChannelService
+	authorizationService *AuthorizationService
+	botID                string
+}
+
+// NewImportService returns a new imports service
+func NewImportService(api plugin.API, channelService *ChannelService, authorizationService *AuthorizationService, botID string) *ImportService {
+	return &ImportService{
+		api:                  api,
+		channelService:       channelService,
+		authorizationService: authorizationService,
+		botID:                botID,
+	}
+}
+
+// Recreates a channel exported in the STIX or JSON format, registering it under the given section.
+// The bot posts all messages, recording their original author in a prop. Privileged users can instead map the authors
+// to existing users by email, username or display name, in which case the bot only posts on behalf of the others.
+// Posts failing to be created are counted in the result, without undoing the import.
+func (s *ImportService) ImportChannel(userID string, params ImportChannelParams, r io.Reader) (ImportChannelResult, error) {
+	if params.TeamID == "" || params.ParentID == "" || params.SectionID == "" {
+		return ImportChannelResult{}, errors.Wrap(ErrInvalidInput, "teamId, parentId and sectionId are required")
+	}
+	permission := mattermost.PermissionCreatePrivateChannel
+	if params.CreatePublicChannel {
+		permission = mattermost.PermissionCreatePublicChannel
+	}
+	if !s.api.HasPermissionToTeam(userID, params.TeamID, permission) {
+		return ImportChannelResult{}, errors.Wrapf(ErrForbidden, "user %s cannot create channels in team %s", userID, params.TeamID)
+	}
+	if params.MapAuthors {
+		// Mapping authors posts on behalf of other users, so it is a privileged action
+		details := fmt.Sprintf("import channel %s in section %s", params.ChannelName, params.SectionID)
+		if err := s.authorizationService.Authorize(userID, params.TeamID, ActionMapImportedAuthors, params.SectionID, details); err != nil {
+			return ImportChannelResult{}, err
+		}
+	}
+
+	channelImport, err := ParseChannelImport(r)
+	if err != nil {
+		return ImportChannelResult{}, err
+	}
+	channelName := strings.TrimSpace(params.ChannelName)
+	if channelName == "" {
+		channelName = channelImport.Name
+	}
+	if channelName == "" {
+		return ImportChannelResult{}, errors.Wrap(ErrInvalidInput, "the imported channel has no name")
+	}
+
+	s.api.LogInfo("Importing channel", "userId", userID, "params", params, "threads", len(channelImport.Threads))
+	addChannelResult, err := s.channelService.addChannel(params.SectionID, AddChannelParams{
+		UserID:              userID,
+		ChannelName:         channelName,
+		CreatePublicChannel: params.CreatePublicChannel,
+		ParentID:            params.ParentID,
+		SectionID:           params.SectionID,
+		TeamID:              params.TeamID,
+		OrganizationID:      params.OrganizationID,
+	}, false)
+	if err != nil {
+		return ImportChannelResult{}, errors.Wrap(err, "unable to create the imported channel")
+	}
+	channelID := addChannelResult.ChannelID
+	if _, appErr := s.api.AddChannelMember(channelID, userID); appErr != nil {
+		s.api.LogWarn("Unable to add the importing user to the channel", "channelId", channelID, "userId", userID, "err", appErr)
+	}
+	s.setChannelHeader(channelID, channelImport.Description)
+
+	importer := &channelImporter{
+		service:    s,
+		channelID:  channelID,
+		mapAuthors: params.MapAuthors,
+		userIDs:    map[string]string{},
+		unmapped:   map[string]bool{},
+	}
+	result := ImportChannelResult{
+		ChannelID:       channelID,
+		ParentID:        addChannelResult.ParentID,
+		SectionID:       addChannelResult.SectionID,
+		UnmappedAuthors: []string{},
+	}
+	for _, thread := range channelImport.Threads {
+		root, err := importer.createPost(channelImport, thread.Root, "")
+		if err != nil {
+			s.api.LogWarn("Unable to import root post", "channelId", channelID, "err", err)
+			result.FailedCount += 1 + len(thread.Replies)
+			continue
+		}
+		result.PostsCount++
+		for _, reply := range thread.Replies {
+			if _, err := importer.createPost(channelImport, reply, root.Id); err != nil {
+				s.api.LogWarn("Unable to import reply", "channelId", channelID, "rootId", root.Id, "err", err)
+				result.FailedCount++
+				continue
+			}
+			result.PostsCount++
+		}
+	}
+	for author := range importer.unmapped {
+		result.UnmappedAuthors = append(result.UnmappedAuthors, author)
+	}
+	sort.Strings(result.UnmappedAuthors)
+	if params.MapAuthors {
+		mappedUserIDs := []string{}
+		for _, mappedUserID := range importer.userIDs {
+			if mappedUserID != "" {
+				mappedUserIDs = append(mappedUserIDs, mappedUserID)
+			}
+		}
+		sort.Strings(mappedUserIDs)
+		s.authorizationService.Audit(userID, params.TeamID, ActionMapImportedAuthors, channelID, true, "posted on behalf of users "+strings.Join(mappedUserIDs, ", "))
+	}
+	return result, nil
+}
+
+func (s *ImportService) setChannelHeader(channelID, header string) {
+	if header == "" {
+		return
+	}
+	channel, appErr := s.api.GetChannel(channelID)
+	if appErr != nil {
+		s.api.LogWarn("Unable to get the imported channel", "channelId", channelID, "err", appErr)
+		return
+	}
+	channel.Header = util.Substr(header, 0, channelHeaderMaxLength)
+	if _, appErr := s.api.UpdateChannel(channel); appErr != nil {
+		s.api.LogWarn("Unable to set the header of the imported channel", "channelId", channelID, "err", appErr)
+	}
+}
+
+// channelImporter creates the posts of an imported channel, caching the users mapped to each author.
+type channelImporter struct {
+	service    *ImportService
+	channelID  string
+	mapAuthors bool
+	userIDs    map[string]string // By author, empty for authors without a matching user
+	unmapped   map[string]bool
+}
+
+func (i *channelImporter) createPost(channelImport *ChannelImport, importPost *ImportPost, rootID string) (*mattermost.Post, error) {
+	authorName := importPost.Author
+	author, found := channelImport.Authors[importPost.AuthorRef]
+	if found && author.Name != "" {
+		authorName = author.Name
+	}
+	if !found {
+		author = &ImportAuthor{Name: authorName}
+	}
+
+	post := &mattermost.Post{
+		ChannelId: i.channelID,
+		RootId:    rootID,
+		Message:   importPost.Message,
+		CreateAt:  importPost.CreateAt,
+		IsPinned:  importPost.IsPinned,
+	}
+	post.AddProp(importedAuthorProp, authorName)
+	if i.mapAuthors {
+		post.UserId = i.userID(importPost.AuthorRef, author)
+	}
+	if post.UserId == "" {
+		if i.mapAuthors {
+			i.unmapped[authorName] = true
+		}
+		post.UserId = i.service.botID
+		post.Message = fmt.Sprintf("_Originally posted by **%s**_\n\n%s", authorName, importPost.Message)
+	}
+	createdPost, appErr := i.service.api.CreatePost(post)
+	if appErr != nil {
+		return nil, errors.Wrap(appErr, "unable to create imported post")
+	}
+	return createdPost, nil
+}
+
+// Returns the user matching an author, adding it to the channel the first time it is found
+func (i *channelImporter) userID(authorRef string, author *ImportAuthor) string {
+	cacheKey := authorRef
+	if cacheKey == "" {
+		cacheKey = "name:" + author.Name
+	}
+	if userID, found := i.userIDs[cacheKey]; found {
+		return userID
+	}
+
+	userID := ""
+	if user := i.service.findUser(author); user != nil {
+		userID = user.Id
+		if _, appErr := i.service.api.AddChannelMember(i.channelID, userID); appErr != nil {
+			i.service.api.LogWarn("Unable to add imported author to the channel", "channelId", i.channelID, "userId", userID, "err", appErr)
+		}
+	}
+	i.userIDs[cacheKey] = userID
+	return userID
+}
+
+// Finds the active user matching an author by email, username or display name, in this order.
+// Display names are only trusted if a single user has them.
+func (s *ImportService) findUser(author *ImportAuthor) *mattermost.User {
+	if author.Email != "" {
+		if user, appErr := s.api.GetUserByEmail(author.Email); appErr == nil && user.DeleteAt == 0 {
+			return user
+		}
+	}
+	for _, username := range []string{author.Username, author.Name} {
+		if username == "" || !mattermost.IsValidUsername(username) {
+			continue
+		}
+		if user, appErr := s.api.GetUserByUsername(username); appErr == nil && user.DeleteAt == 0 {
+			return user
+		}
+	}
+	if author.Name == "" {
+		return nil
+	}
+	users, appErr := s.api.SearchUsers(&mattermost.UserSearch{Term: author.Name, Limit: 10})
+	if appErr != nil {
+		s.api.LogWarn("Unable to search users for imported author", "author", author.Name, "err", appErr)
+		return nil
+	}
+	var match *mattermost.User
+	for _, user := range users {
+		if user.GetDisplayName(mattermost.ShowNicknameFullName) != author.Name && user.GetFullName() != author.Name {
+			continue
+		}
+		if match != nil {
+			return nil
+		}
+		match = user
+	}
+	return match
+}
@@ -0,0 +1,60 @@
+package app
+
+// Issue is an ecosystem issue as returned by the provider, limited to what its channel needs
+type Issue struct {
+	ID                        string         `json:"id"`
+	Name                      string         `json:"name"`
+	ObjectivesAndResearchArea string         `json:"objectivesAndResearchArea"`
+	Outcomes                  []IssueOutcome `json:"outcomes"`
+	Elements                  []IssueElement `json:"elements"`
+	Roles                     []IssueRole    `json:"roles"`
+	DeleteAt                  int64          `json:"deleteat"`
+}
+
+type IssueOutcome struct {
+	ID      string `json:"id"`
+	Outcome string `json:"outcome"`
+}
+
+// IssueElement is an element of an organization section involved in an issue
+type IssueElement struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	OrganizationID string `json:"organizationId"`
+	ParentID       string `json:"parentId"`
+}
+
+type IssueRole struct {
+	ID     string   `json:"id"`
+	UserID string   `json:"userId"`
+	Roles  []string `json:"roles"`
+}
+
+// SyncIssueChannelParams identifies the issues section of the issue and the team its channel belongs to
+type SyncIssueChannelParams struct {
+	TeamID              string `json:"teamId"`
+	ParentID            string `json:"parentId"`
+	OrganizationID      string `json:"organizationId"`
+	CreatePublicChannel bool   `json:"createPublicChannel"`
+}
+
+// CreateIssueParams are the values of an issue created from chat, in the first issues section of the ecosystem
+type CreateIssueParams struct {
+	TeamID                    string `json:"teamId"`
+	Name                      string `json:"name"`
+	ObjectivesAndResearchArea string `json:"objectivesAndResearchArea"`
+}
+
+type CreateIssueResult struct {
+	IssueID   string `json:"issueId"`
+	ChannelID string `json:"channelId"`
+	URL       string `json:"url"`
+}
+
+// GraphLockStatus tells whether a user is editing the ecosystem graph, and until when
+type GraphLockStatus struct {
+	Locked    bool   `json:"locked"`
+	Owner     string `json:"owner"`     // Id of the user editing the graph
+	ExpiresAt int64  `json:"expiresAt"` // Unix seconds timestamp
+}
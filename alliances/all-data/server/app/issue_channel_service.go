@@ -0,0 +1,416 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-api/cluster"
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+const (
+	defaultIssuesPollingInterval = 5 * time.Minute
+	// Post prop marking the summaries posted by the bot in the issue channels
+	issueSummaryPostProp = "issue_summary"
+)
+
+// IssueChannelService keeps a channel for each ecosystem issue of the provider, along with its members and summary.
+type IssueChannelService struct {
+	api             plugin.API
+	platformService *config.PlatformService
+	channelService  *ChannelService
+	linkService     *LinkService
+	providerClient  *link.ProviderClient
+	botID           string
+}
+
+// issuesSyncReport counts what a sync of the issue channels changed
+type issuesSyncReport struct {
+	created  int
+	linked   int
+	archived int
+	restored int
+	errors   []string
+}
+
+func (r *issuesSyncReport) addError(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// NewIssueChannelService returns a new service for the channels of the ecosystem issues
+func NewIssueChannelService(api plugin.API, platformService *config.PlatformService, channelService *ChannelService, linkService *LinkService, providerClient *link.ProviderClient, botID string) *IssueChannelService {
+	return &IssueChannelService{
+		api:             api,
+		platformService: platformService,
+		channelService:  channelService,
+		linkService:     linkService,
+		providerClient:  providerClient,
+		botID:           botID,
+	}
+}
+
+// Starts the job polling the provider for new, deleted and restored issues, which runs on a single server of the cluster at a time.
+// Polling does nothing unless issue channels are enabled in the config.
+func (s *IssueChannelService) StartSyncJob() (*cluster.Job, error) {
+	interval := defaultIssuesPollingInterval
+	if platformConfig, err := s.platformService.GetPlatformConfig(); err == nil && platformConfig.IssueChannels.PollingInterval > 0 {
+		interval = time.Duration(platformConfig.IssueChannels.PollingInterval) * time.Minute
+	}
+	return cluster.Schedule(s.api, "CSA_issueChannelsJob", cluster.MakeWaitForInterval(interval), func() {
+		if err := s.syncIssues(); err != nil {
+			s.api.LogError("failed to sync issue channels", "err", err)
+		}
+	})
+}
+
+// Creates or links the channel of an issue as soon as it is saved, rather than waiting for the polling.
+// Syncing an issue which already has a channel returns the existing one.
+func (s *IssueChannelService) SyncIssueChannel(userID, issueID string, params SyncIssueChannelParams) (AddChannelResult, error) {
+	if params.TeamID == "" || params.ParentID == "" || params.OrganizationID == "" {
+		return AddChannelResult{}, errors.Wrap(ErrInvalidInput, "teamId, parentId and organizationId are required")
+	}
+	_, resolver, err := s.linkService.GetLinkModel()
+	if err != nil {
+		return AddChannelResult{}, err
+	}
+	organization, found := resolver.FindOrganization(params.OrganizationID)
+	if !found {
+		return AddChannelResult{}, errors.Wrapf(ErrInvalidInput, "organization %s not found", params.OrganizationID)
+	}
+	section, found := resolver.FindSection(params.OrganizationID, params.ParentID)
+	if !found || !section.IsIssues {
+		return AddChannelResult{}, errors.Wrapf(ErrInvalidInput, "section %s is not an issues section", params.ParentID)
+	}
+	permission := mattermost.PermissionCreatePrivateChannel
+	if params.CreatePublicChannel {
+		permission = mattermost.PermissionCreatePublicChannel
+	}
+	if !s.api.HasPermissionToTeam(userID, params.TeamID, permission) {
+		return AddChannelResult{}, errors.Wrapf(ErrForbidden, "user %s cannot create channels in team %s", userID, params.TeamID)
+	}
+	issue, err := s.fetchIssue(section.URL, issueID)
+	if err != nil {
+		return AddChannelResult{}, err
+	}
+	if issue.ID == "" || issue.DeleteAt != 0 {
+		return AddChannelResult{}, errors.Wrapf(ErrNotFound, "issue %s not found", issueID)
+	}
+
+	mutex, err := cluster.NewMutex(s.api, "CSA_issueChannelsMutex")
+	if err != nil {
+		return AddChannelResult{}, errors.Wrap(err, "failed creating cluster mutex to sync issue channel")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	s.api.LogInfo("Syncing issue channel", "issueId", issueID, "userId", userID, "params", params)
+	result, _, err := s.syncIssueChannel(userID, params.TeamID, params.CreatePublicChannel, organization, section, issue)
+	return result, err
+}
+
+// Gets the first issues section of the ecosystem, which also serves the ecosystem graph
+func (s *IssueChannelService) getIssuesSection() (*config.Organization, *config.Section, bool) {
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return nil, nil, false
+	}
+	ecosystem, found := platformConfig.GetEcosystem()
+	if !found {
+		return nil, nil, false
+	}
+	sections := issuesSections(ecosystem.Sections)
+	if len(sections) == 0 {
+		return nil, nil, false
+	}
+	return ecosystem, sections[0], true
+}
+
+// Compares the issues of each ecosystem issues section with their channels: new issues get a channel,
+// the channels of deleted issues are archived and those of restored issues are restored.
+func (s *IssueChannelService) syncIssues() error {
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return errors.Wrap(err, "couldn't get config")
+	}
+	issueChannels := platformConfig.IssueChannels
+	if !issueChannels.Enabled {
+		return nil
+	}
+	ecosystem, found := platformConfig.GetEcosystem()
+	if !found {
+		return nil
+	}
+	team, appErr := s.api.GetTeamByName(issueChannels.TeamName)
+	if appErr != nil {
+		return errors.Wrapf(appErr, "couldn't get team %s for issue channels", issueChannels.TeamName)
+	}
+
+	mutex, err := cluster.NewMutex(s.api, "CSA_issueChannelsMutex")
+	if err != nil {
+		return errors.Wrap(err, "failed creating cluster mutex to sync issue channels")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	ecosystemChannels, err := s.channelService.GetChannelsByOrganizationID(ecosystem.ID)
+	if err != nil {
+		return errors.Wrap(err, "couldn't get ecosystem channels")
+	}
+	report := &issuesSyncReport{errors: []string{}}
+	for _, section := range issuesSections(ecosystem.Sections) {
+		sectionChannels := map[string][]Channel{}
+		for _, channel := range ecosystemChannels.Items {
+			if channel.ParentID == section.ID {
+				sectionChannels[channel.SectionID] = append(sectionChannels[channel.SectionID], channel)
+			}
+		}
+		s.syncSection(team.Id, issueChannels.CreatePublicChannel, ecosystem, section, sectionChannels, report)
+	}
+	s.api.LogInfo("Issue channels synced", "created", report.created, "linked", report.linked, "archived", report.archived, "restored", report.restored, "errors", len(report.errors))
+	if len(report.errors) > 0 {
+		s.api.LogWarn("Issue channels synced with errors", "errors", strings.Join(report.errors, "; "))
+	}
+	return nil
+}
+
+func (s *IssueChannelService) syncSection(teamID string, createPublicChannel bool, ecosystem *config.Organization, section *config.Section, sectionChannels map[string][]Channel, report *issuesSyncReport) {
+	activeIssueIDs, err := s.fetchIssueIDs(section.URL)
+	if err != nil {
+		report.addError("couldn't get issues of section %s: %s", section.ID, err.Error())
+		return
+	}
+
+	active := make(map[string]bool, len(activeIssueIDs))
+	for _, issueID := range activeIssueIDs {
+		active[issueID] = true
+		if channels, found := sectionChannels[issueID]; found {
+			s.restoreIssueChannels(channels, report)
+			continue
+		}
+		issue, err := s.fetchIssue(section.URL, issueID)
+		if err != nil {
+			report.addError("couldn't get issue %s: %s", issueID, err.Error())
+			continue
+		}
+		_, linked, err := s.syncIssueChannel(s.botID, teamID, createPublicChannel, ecosystem, section, issue)
+		if err != nil {
+			report.addError("couldn't create channel of issue %s: %s", issueID, err.Error())
+		} else if linked {
+			report.linked++
+		} else {
+			report.created++
+		}
+	}
+
+	for issueID, channels := range sectionChannels {
+		if active[issueID] {
+			continue
+		}
+		// The issues list may be outdated, so the issue is checked before archiving its channels
+		issue, err := s.fetchIssue(section.URL, issueID)
+		if err != nil {
+			report.addError("couldn't get issue %s: %s", issueID, err.Error())
+			continue
+		}
+		if issue.ID != "" && issue.DeleteAt == 0 {
+			continue
+		}
+		s.archiveIssueChannels(channels, report)
+	}
+}
+
+func (s *IssueChannelService) restoreIssueChannels(channels []Channel, report *issuesSyncReport) {
+	for _, channel := range channels {
+		mattermostChannel, appErr := s.api.GetChannel(channel.ChannelID)
+		if appErr != nil {
+			report.addError("couldn't get channel %s: %s", channel.ChannelID, appErr.Error())
+			continue
+		}
+		if mattermostChannel.DeleteAt == 0 {
+			continue
+		}
+		restored, err := s.channelService.restoreElementChannel(mattermostChannel)
+		if err != nil {
+			report.addError("couldn't restore channel %s: %s", channel.ChannelID, err.Error())
+		} else if restored {
+			report.restored++
+		}
+	}
+}
+
+func (s *IssueChannelService) archiveIssueChannels(channels []Channel, report *issuesSyncReport) {
+	for _, channel := range channels {
+		mattermostChannel, appErr := s.api.GetChannel(channel.ChannelID)
+		if appErr != nil {
+			report.addError("couldn't get channel %s: %s", channel.ChannelID, appErr.Error())
+			continue
+		}
+		if mattermostChannel.DeleteAt != 0 {
+			continue
+		}
+		if err := s.channelService.archiveElementChannel(channel.ChannelID); err != nil {
+			report.addError("couldn't archive channel %s: %s", channel.ChannelID, err.Error())
+			continue
+		}
+		report.archived++
+	}
+}
+
+// Gets the channel of an issue, otherwise links the unlinked channel named after it or creates a new one.
+// New channels get the users with a role in the issue as members and a summary of the issue. Returns whether the channel was linked.
+func (s *IssueChannelService) syncIssueChannel(userID, teamID string, createPublicChannel bool, organization *config.Organization, section *config.Section, issue Issue) (AddChannelResult, bool, error) {
+	channels, err := s.channelService.GetChannels(issue.ID, section.ID)
+	if err != nil {
+		return AddChannelResult{}, false, err
+	}
+	if len(channels.Items) > 0 {
+		return AddChannelResult{ChannelID: channels.Items[0].ChannelID, ParentID: section.ID, SectionID: issue.ID}, false, nil
+	}
+
+	params := AddChannelParams{
+		UserID:              userID,
+		ChannelName:         link.FormatName(fmt.Sprintf("%s-%s", organization.Name, issue.Name)),
+		CreatePublicChannel: createPublicChannel,
+		ParentID:            section.ID,
+		SectionID:           issue.ID,
+		TeamID:              teamID,
+		OrganizationID:      organization.ID,
+	}
+	if existing, appErr := s.api.GetChannelByName(teamID, params.ChannelName, false); appErr == nil {
+		if _, err := s.channelService.GetChannelByID(existing.Id); errors.Is(err, ErrNotFound) {
+			params.ChannelID = existing.Id
+		}
+	}
+	result, err := s.channelService.AddChannel(issue.ID, params)
+	if err != nil {
+		return AddChannelResult{}, false, err
+	}
+
+	s.addRoleMembers(result.ChannelID, issue.Roles)
+	if err := s.postSummary(result.ChannelID, issue); err != nil {
+		s.api.LogWarn("Unable to post issue summary", "channelId", result.ChannelID, "issueId", issue.ID, "err", err)
+	}
+	return result, params.ChannelID != "", nil
+}
+
+func (s *IssueChannelService) addRoleMembers(channelID string, roles []IssueRole) {
+	for _, role := range roles {
+		if role.UserID == "" {
+			continue
+		}
+		if _, appErr := s.api.AddChannelMember(channelID, role.UserID); appErr != nil {
+			s.api.LogWarn("Unable to add issue role user to channel", "channelId", channelID, "userId", role.UserID, "err", appErr)
+		}
+	}
+}
+
+// Posts the objectives, outcomes, elements and roles of the issue, linking each element to its section
+func (s *IssueChannelService) postSummary(channelID string, issue Issue) error {
+	parser, resolver, err := s.linkService.GetLinkModel()
+	if err != nil {
+		return err
+	}
+
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("#### %s\n", issue.Name))
+	if issue.ObjectivesAndResearchArea != "" {
+		summary.WriteString(fmt.Sprintf("%s\n", issue.ObjectivesAndResearchArea))
+	}
+	if len(issue.Outcomes) > 0 {
+		summary.WriteString("\n**Outcomes**\n")
+		for _, outcome := range issue.Outcomes {
+			summary.WriteString(fmt.Sprintf("- %s\n", outcome.Outcome))
+		}
+	}
+	if len(issue.Elements) > 0 {
+		summary.WriteString("\n**Elements**\n")
+		for _, element := range issue.Elements {
+			summary.WriteString(fmt.Sprintf("- %s\n", s.formatElement(parser, resolver, element)))
+		}
+	}
+	if len(issue.Roles) > 0 {
+		summary.WriteString("\n**Roles**\n")
+		for _, role := range issue.Roles {
+			user, appErr := s.api.GetUser(role.UserID)
+			if appErr != nil {
+				continue
+			}
+			summary.WriteString(fmt.Sprintf("- @%s: %s\n", user.Username, strings.Join(role.Roles, ", ")))
+		}
+	}
+
+	if _, appErr := s.api.AddChannelMember(channelID, s.botID); appErr != nil {
+		s.api.LogWarn("Unable to add the bot to the issue channel", "channelId", channelID, "err", appErr)
+	}
+	post := &mattermost.Post{
+		UserId:    s.botID,
+		ChannelId: channelID,
+		Message:   strings.TrimSuffix(summary.String(), "\n"),
+	}
+	post.AddProp(issueSummaryPostProp, issue.ID)
+	if _, appErr := s.api.CreatePost(post); appErr != nil {
+		return errors.Wrapf(appErr, "unable to post summary of issue %s", issue.ID)
+	}
+	return nil
+}
+
+func (s *IssueChannelService) formatElement(parser *link.Parser, resolver *link.Resolver, element IssueElement) string {
+	name := element.Name
+	if section, found := resolver.FindSection(element.OrganizationID, element.ParentID); found {
+		url := parser.URL(link.Reference{
+			OrganizationID: element.OrganizationID,
+			SectionID:      section.ID,
+			SectionName:    link.FormatName(section.Name),
+			ElementID:      element.ID,
+		})
+		name = fmt.Sprintf("[%s](%s)", element.Name, url)
+	}
+	if element.Description == "" {
+		return name
+	}
+	return fmt.Sprintf("%s: %s", name, element.Description)
+}
+
+// Gets the ids of the issues which are not deleted, from the paginated table of the section
+func (s *IssueChannelService) fetchIssueIDs(sectionURL string) ([]string, error) {
+	var table struct {
+		Rows []struct {
+			ID string `json:"id"`
+		} `json:"rows"`
+	}
+	if err := s.providerClient.FetchJSON(sectionURL, &table); err != nil {
+		return nil, err
+	}
+	issueIDs := make([]string, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		issueIDs = append(issueIDs, row.ID)
+	}
+	return issueIDs, nil
+}
+
+// Gets an issue, deleted ones included. The provider returns an empty issue for unknown ids.
+func (s *IssueChannelService) fetchIssue(sectionURL, issueID string) (Issue, error) {
+	var issue Issue
+	if err := s.providerClient.FetchJSON(fmt.Sprintf("%s/%s", strings.TrimSuffix(sectionURL, "/"), issueID), &issue); err != nil {
+		return Issue{}, err
+	}
+	return issue, nil
+}
+
+// Collects the issues sections, looking into nested sections as well
+func issuesSections(sections []config.Section) []*config.Section {
+	var result []*config.Section
+	for i := range sections {
+		if sections[i].IsIssues {
+			result = append(result, &sections[i])
+		}
+		result = append(result, issuesSections(sections[i].Sections)...)
+	}
+	return result
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"encoding/json"
+
+	"github.com/mattermost/mattermost-server/v6/plugin"
+)
+
+// Reads a JSON value from the plugin KV store, leaving the value untouched if the key is missing
+func kvGetJSON(api plugin.API, key string, value interface{}) error {
+	data, appErr := api.KVGet(key)
+	if appErr != nil {
+		return appErr
+	}
+	if data == nil {
+		return nil
+	}
+	return json.Unmarshal(data, value)
+}
+
+func kvSetJSON(api plugin.API, key string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if appErr := api.KVSet(key, data); appErr != nil {
+		return appErr
+	}
+	return nil
+}
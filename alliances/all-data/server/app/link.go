@@ -0,0 +1,11 @@
+package app
+
+import "github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+
+type ResolveLinkResult struct {
+	Key       string         `json:"key"`
+	Kind      link.Kind      `json:"kind"`
+	Name      string         `json:"name"`
+	URL       string         `json:"url"`
+	Reference link.Reference `json:"reference"`
+}
@@ -0,0 +1,27 @@
+package app
+
+// Actions of the buttons attached to the previews of the HOOD links in posts
+const (
+	LinkPreviewOpenAction      = "open"
+	LinkPreviewBacklinksAction = "backlinks"
+	LinkPreviewPinAction       = "pin"
+)
+
+// Context of the preview buttons, sent back by Mattermost when a button is clicked
+const (
+	linkPreviewURLContextKey  = "url"
+	linkPreviewNameContextKey = "name"
+	linkPreviewPostContextKey = "postId" // The post linking the element
+)
+
+// EvidenceProp is the post prop listing the links a post has been pinned as evidence for, i.e. {"links": [url]}
+const EvidenceProp = "evidence"
+
+// LinkPreviewActionParams is the click on a preview button, as sent by Mattermost to the plugin
+type LinkPreviewActionParams struct {
+	UserID    string
+	TeamID    string
+	ChannelID string
+	PostID    string
+	Context   map[string]interface{}
+}
@@ -0,0 +1,327 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/util"
+)
+
+// LinkPreviewActionsPath is the path, relative to the plugin API, handling the clicks on the preview buttons
+const LinkPreviewActionsPath = "/link_previews/actions"
+
+const (
+	linkPreviewMaxAttachments = 3
+	linkPreviewMaxFields      = 6
+	linkPreviewMaxValueLength = 100
+	linkPreviewColor          = "#1C58D9"
+)
+
+// LinkPreviewService attaches a preview of the linked elements to the posts linking them,
+// with buttons to open the element, show its backlinks and pin the post as evidence.
+type LinkPreviewService struct {
+	api            plugin.API
+	configuration  *config.MattermostConfig
+	linkService    *LinkService
+	providerClient *link.ProviderClient
+	commandService *CommandService
+	botID          string
+	pluginID       string
+}
+
+// NewLinkPreviewService returns a new link previews service
+func NewLinkPreviewService(api plugin.API, configuration *config.MattermostConfig, linkService *LinkService, providerClient *link.ProviderClient, commandService *CommandService, botID, pluginID string) *LinkPreviewService {
+	return &LinkPreviewService{
+		api:            api,
+		configuration:  configuration,
+		linkService:    linkService,
+		providerClient: providerClient,
+		commandService: commandService,
+		botID:          botID,
+		pluginID:       pluginID,
+	}
+}
+
+// Sends the author of a post just posted a preview of each HOOD link in its message, up to a few links, as an ephemeral
+// reply of the bot. Previews are built after posting, since they are fetched from the providers, and sent apart from the post,
+// so that adding them does not edit the post. Posts of the bot and system messages get no preview, nor do all posts if disabled.
+func (s *LinkPreviewService) SendPreviews(post *mattermost.Post) {
+	if !s.configuration.GetConfiguration().LinkPreviews || post.Type != "" || post.UserId == s.botID {
+		return
+	}
+	links := link.ExtractLinks(post.Message)
+	if len(links) == 0 {
+		return
+	}
+	parser, resolver, err := s.linkService.GetLinkModel()
+	if err != nil {
+		s.api.LogWarn("Unable to preview links", "err", err)
+		return
+	}
+
+	attachments := []*mattermost.SlackAttachment{}
+	previewedKeys := map[string]bool{}
+	for _, postLink := range links {
+		if len(previewedKeys) == linkPreviewMaxAttachments {
+			break
+		}
+		reference, ok := parser.Parse(postLink.URL)
+		if !ok {
+			continue
+		}
+		reference = resolver.Canonicalize(reference)
+		if reference.ChannelName != "" {
+			// Widgets referenced from channels not linked to any element have nothing to preview
+			continue
+		}
+		key := reference.Key()
+		if previewedKeys[key] {
+			continue
+		}
+		previewedKeys[key] = true
+		attachments = append(attachments, s.buildPreview(parser, resolver, reference, post.Id))
+	}
+	if len(attachments) == 0 {
+		return
+	}
+
+	preview := &mattermost.Post{
+		UserId:    s.botID,
+		ChannelId: post.ChannelId,
+		RootId:    post.RootId,
+	}
+	mattermost.ParseSlackAttachment(preview, attachments)
+	s.api.SendEphemeralPost(post.UserId, preview)
+}
+
+// Answers a click on the open button with the link to the element, which opens in the platform page
+func (s *LinkPreviewService) Open(params LinkPreviewActionParams) (string, error) {
+	elementURL, name, err := getLinkPreviewContext(params.Context)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Open [%s](%s).", name, elementURL), nil
+}
+
+func (s *LinkPreviewService) ShowBacklinks(params LinkPreviewActionParams) (string, error) {
+	elementURL, _, err := getLinkPreviewContext(params.Context)
+	if err != nil {
+		return "", err
+	}
+	return s.commandService.ListBacklinks(params.UserID, params.TeamID, elementURL)
+}
+
+// Pins the post to its channel, recording the element it is evidence for in the evidence prop of the post
+func (s *LinkPreviewService) PinAsEvidence(params LinkPreviewActionParams) (string, error) {
+	elementURL, name, err := getLinkPreviewContext(params.Context)
+	if err != nil {
+		return "", err
+	}
+	// Previews are ephemeral posts, so the post to pin is the one they preview the links of
+	postID, _ := params.Context[linkPreviewPostContextKey].(string)
+	if postID == "" {
+		postID = params.PostID
+	}
+	post, appErr := s.api.GetPost(postID)
+	if appErr != nil {
+		return "", errors.Wrapf(ErrNotFound, "post %s not found", postID)
+	}
+	if !s.api.HasPermissionToChannel(params.UserID, post.ChannelId, mattermost.PermissionReadChannel) {
+		return "", errors.Wrapf(ErrForbidden, "user %s cannot pin posts in channel %s", params.UserID, post.ChannelId)
+	}
+
+	links := []interface{}{}
+	if evidence, ok := post.GetProp(EvidenceProp).(map[string]interface{}); ok {
+		if existingLinks, ok := evidence["links"].([]interface{}); ok {
+			links = existingLinks
+		}
+	}
+	for _, existingLink := range links {
+		if existingLink == elementURL && post.IsPinned {
+			return fmt.Sprintf("This post is already pinned as evidence for [%s](%s).", name, elementURL), nil
+		}
+	}
+	post.IsPinned = true
+	post.AddProp(EvidenceProp, map[string]interface{}{"links": appendMissing(links, elementURL)})
+	if _, appErr := s.api.UpdatePost(post); appErr != nil {
+		return "", errors.Wrapf(appErr, "unable to pin post %s", post.Id)
+	}
+	s.api.LogInfo("Pinned post as evidence", "postId", post.Id, "userId", params.UserID, "url", elementURL)
+	return fmt.Sprintf("Pinned as evidence for [%s](%s).", name, elementURL), nil
+}
+
+func (s *LinkPreviewService) buildPreview(parser *link.Parser, resolver *link.Resolver, reference link.Reference, postID string) *mattermost.SlackAttachment {
+	elementURL := parser.URL(reference)
+	name, err := resolver.ResolveName(reference.Key())
+	if err != nil {
+		name = elementURL
+	}
+	description, fields := s.getSummary(resolver, reference)
+	context := map[string]interface{}{
+		linkPreviewURLContextKey:  elementURL,
+		linkPreviewNameContextKey: name,
+		linkPreviewPostContextKey: postID,
+	}
+	return &mattermost.SlackAttachment{
+		Fallback:  name,
+		Color:     linkPreviewColor,
+		Title:     name,
+		TitleLink: elementURL,
+		Text:      description,
+		Fields:    fields,
+		Actions: []*mattermost.PostAction{
+			s.buildAction("Open", LinkPreviewOpenAction, "default", context),
+			s.buildAction("Show backlinks", LinkPreviewBacklinksAction, "default", context),
+			s.buildAction("Pin as evidence", LinkPreviewPinAction, "primary", context),
+		},
+	}
+}
+
+func (s *LinkPreviewService) buildAction(name, action, style string, context map[string]interface{}) *mattermost.PostAction {
+	return &mattermost.PostAction{
+		Type:  mattermost.PostActionTypeButton,
+		Name:  name,
+		Style: style,
+		Integration: &mattermost.PostActionIntegration{
+			URL:     fmt.Sprintf("/plugins/%s/api/v0%s/%s", s.pluginID, LinkPreviewActionsPath, action),
+			Context: context,
+		},
+	}
+}
+
+// Gets the description of the referenced element and a summary of its data: the size of organizations and sections,
+// the values of the data points and the other properties of the elements, as served by their provider.
+func (s *LinkPreviewService) getSummary(resolver *link.Resolver, reference link.Reference) (string, []*mattermost.SlackAttachmentField) {
+	organization, found := resolver.FindOrganization(reference.OrganizationID)
+	if !found {
+		return "", nil
+	}
+	if reference.SectionID == "" {
+		return organization.Description, []*mattermost.SlackAttachmentField{
+			newPreviewField("Sections", len(flattenSections(organization.Sections))),
+		}
+	}
+	section, found := resolver.FindSection(reference.OrganizationID, reference.SectionID)
+	if !found {
+		return "", nil
+	}
+	if reference.ElementID == "" {
+		elements, err := fetchSectionElements(s.providerClient, section.URL)
+		if err != nil {
+			s.api.LogWarn("Unable to fetch section elements to preview", "url", section.URL, "err", err)
+		}
+		return "", []*mattermost.SlackAttachmentField{
+			newPreviewField("Elements", len(elements)),
+		}
+	}
+
+	element := map[string]interface{}{}
+	elementURL := fmt.Sprintf("%s/%s", strings.TrimSuffix(section.URL, "/"), reference.ElementID)
+	if err := s.providerClient.FetchJSON(elementURL, &element); err != nil {
+		s.api.LogWarn("Unable to fetch element to preview", "url", elementURL, "err", err)
+	}
+	description, _ := element["description"].(string)
+
+	if reference.Fragment != nil {
+		switch reference.Fragment.Type {
+		case link.FragmentLineDot:
+			return description, []*mattermost.SlackAttachmentField{
+				newPreviewField("Label", reference.Fragment.Label),
+				newPreviewField("Value", strings.Replace(reference.Fragment.Value, "dot", ".", 1)),
+			}
+		case link.FragmentBarCell:
+			return description, s.getBarFields(section, reference)
+		}
+	}
+
+	keys := make([]string, 0, len(element))
+	for key := range element {
+		if key != "id" && key != "name" && key != "description" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	fields := []*mattermost.SlackAttachmentField{}
+	for _, key := range keys {
+		if len(fields) == linkPreviewMaxFields {
+			break
+		}
+		switch value := element[key].(type) {
+		case string:
+			if value != "" {
+				fields = append(fields, newPreviewField(key, value))
+			}
+		case float64, bool:
+			fields = append(fields, newPreviewField(key, value))
+		}
+	}
+	return description, fields
+}
+
+// Gets the values of the bar referenced by a bar cell, from the first chart of the section having it
+func (s *LinkPreviewService) getBarFields(section *config.Section, reference link.Reference) []*mattermost.SlackAttachmentField {
+	index, err := strconv.Atoi(reference.Fragment.ID)
+	if err != nil {
+		return nil
+	}
+	bar, found := fetchBar(s.providerClient, section, reference.ElementID, index)
+	if !found {
+		return nil
+	}
+	fields := []*mattermost.SlackAttachmentField{newPreviewField("Label", bar["label"])}
+	keys := make([]string, 0, len(bar))
+	for key := range bar {
+		if key != "label" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if len(fields) == linkPreviewMaxFields {
+			break
+		}
+		fields = append(fields, newPreviewField(key, bar[key]))
+	}
+	return fields
+}
+
+func newPreviewField(title string, value interface{}) *mattermost.SlackAttachmentField {
+	text := fmt.Sprint(value)
+	if len([]rune(text)) > linkPreviewMaxValueLength {
+		text = util.Substr(text, 0, linkPreviewMaxValueLength) + "…"
+	}
+	return &mattermost.SlackAttachmentField{
+		Title: title,
+		Value: text,
+		Short: true,
+	}
+}
+
+func getLinkPreviewContext(context map[string]interface{}) (string, string, error) {
+	elementURL, _ := context[linkPreviewURLContextKey].(string)
+	if elementURL == "" {
+		return "", "", errors.Wrap(ErrInvalidInput, "the action has no element url")
+	}
+	name, _ := context[linkPreviewNameContextKey].(string)
+	if name == "" {
+		name = elementURL
+	}
+	return elementURL, name, nil
+}
+
+func appendMissing(values []interface{}, value string) []interface{} {
+	for _, existing := range values {
+		if existing == value {
+			return values
+		}
+	}
+	return append(values, value)
+}
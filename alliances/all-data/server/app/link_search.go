@@ -0,0 +1,24 @@
+package app
+
+import "github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+
+// Kinds of the platform elements that can be searched by name
+const (
+	LinkSearchOrganization = "organization"
+	LinkSearchSection      = "section"
+	LinkSearchIssue        = "issue"
+	LinkSearchElement      = "element"
+	LinkSearchDataPoint    = "dataPoint"
+	LinkSearchGraphNode    = "graphNode"
+)
+
+// LinkSearchResult is a platform element found by name, with the markdown link to insert in a message
+type LinkSearchResult struct {
+	Kind     string `json:"kind"`
+	Name     string `json:"name"`
+	Path     string `json:"path"` // Names of the containing elements, e.g. Organization › Section › Element
+	URL      string `json:"url"`
+	Markdown string `json:"markdown"`
+
+	reference link.Reference // Where the element is, to search only the organizations users can see
+}
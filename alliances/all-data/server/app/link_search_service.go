@@ -0,0 +1,348 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+const (
+	linkSearchIndexTTL      = 5 * time.Minute
+	linkSearchPathSeparator = " › "
+)
+
+// LinkSearchService searches the platform elements by name. Fetching the elements, their charts and their graphs
+// takes a request for each of them, so they are indexed in background and searches are served by the last index built,
+// which is rebuilt once it gets old.
+type LinkSearchService struct {
+	api             plugin.API
+	platformService *config.PlatformService
+	linkService     *LinkService
+	membershipStore MembershipStore
+	providerClient  *link.ProviderClient
+
+	indexLock sync.RWMutex
+	index     []LinkSearchResult
+	indexedAt time.Time
+	indexing  int32 // Set to 1 while the index is being built
+}
+
+// NewLinkSearchService returns a new service to search the platform elements by name
+func NewLinkSearchService(api plugin.API, platformService *config.PlatformService, linkService *LinkService, providerClient *link.ProviderClient, membershipStore MembershipStore) *LinkSearchService {
+	return &LinkSearchService{
+		api:             api,
+		platformService: platformService,
+		linkService:     linkService,
+		membershipStore: membershipStore,
+		providerClient:  providerClient,
+	}
+}
+
+// Searches the elements whose name contains all the words of the query, those starting with the query first.
+// Only the elements of the organizations the user can see are searched: the organizations the user belongs to and the ecosystem.
+func (s *LinkSearchService) Search(userID, query string, limit int) ([]LinkSearchResult, error) {
+	organizationIDs, err := s.getVisibleOrganizationIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	query = strings.ToLower(strings.TrimSpace(query))
+	words := strings.Fields(query)
+
+	prefixMatches := []LinkSearchResult{}
+	otherMatches := []LinkSearchResult{}
+	for _, result := range s.getIndex() {
+		if organizationIDs != nil && !organizationIDs[result.reference.OrganizationID] {
+			continue
+		}
+		name := strings.ToLower(result.Name)
+		if !containsAll(name, words) {
+			continue
+		}
+		if strings.HasPrefix(name, query) {
+			prefixMatches = append(prefixMatches, result)
+		} else {
+			otherMatches = append(otherMatches, result)
+		}
+	}
+	results := append(prefixMatches, otherMatches...)
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// Gets the indexed elements of a section, issues included, if the user can see its organization
+func (s *LinkSearchService) GetSectionElements(userID, organizationID, sectionID string) ([]LinkSearchResult, error) {
+	organizationIDs, err := s.getVisibleOrganizationIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	elements := []LinkSearchResult{}
+	if organizationIDs != nil && !organizationIDs[organizationID] {
+		return elements, nil
+	}
+	for _, result := range s.getIndex() {
+		if result.Kind != LinkSearchElement && result.Kind != LinkSearchIssue {
+			continue
+		}
+		if result.reference.OrganizationID == organizationID && result.reference.SectionID == sectionID {
+			elements = append(elements, result)
+		}
+	}
+	return elements, nil
+}
+
+// Starts building the index in background, unless it is already being built
+func (s *LinkSearchService) RefreshIndex() {
+	if !atomic.CompareAndSwapInt32(&s.indexing, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&s.indexing, 0)
+		index, err := s.buildIndex()
+		if err != nil {
+			// The last index built keeps being served
+			s.api.LogWarn("Unable to build the link search index", "err", err)
+			return
+		}
+		s.indexLock.Lock()
+		defer s.indexLock.Unlock()
+		s.index = index
+		s.indexedAt = time.Now()
+	}()
+}
+
+// Gets the last index built, refreshing it in background once it gets old. Nothing is found until the first index is built.
+func (s *LinkSearchService) getIndex() []LinkSearchResult {
+	s.indexLock.RLock()
+	index, indexedAt := s.index, s.indexedAt
+	s.indexLock.RUnlock()
+
+	if index == nil || time.Since(indexedAt) >= linkSearchIndexTTL {
+		s.RefreshIndex()
+	}
+	return index
+}
+
+// Gets the ids of the organizations a user can see, which are all of them for users who selected all organizations
+func (s *LinkSearchService) getVisibleOrganizationIDs(userID string) (map[string]bool, error) {
+	user, appErr := s.api.GetUser(userID)
+	if appErr != nil {
+		return nil, errors.Wrapf(ErrNotFound, "user %s not found", userID)
+	}
+	orgID, _ := user.GetProp("orgId")
+	if orgID == config.OrganizationIDAll {
+		return nil, nil
+	}
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get config")
+	}
+	memberships, err := s.membershipStore.GetMembershipsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	organizationIDs := map[string]bool{orgID: true}
+	for _, membership := range memberships {
+		organizationIDs[membership.OrganizationID] = true
+	}
+	if ecosystem, found := platformConfig.GetEcosystem(); found {
+		organizationIDs[ecosystem.ID] = true
+	}
+	return organizationIDs, nil
+}
+
+// Builds the index walking the platform config: organizations and sections come from the config itself,
+// while elements, chart data points and graph nodes are fetched from the providers.
+func (s *LinkSearchService) buildIndex() ([]LinkSearchResult, error) {
+	parser, _, err := s.linkService.GetLinkModel()
+	if err != nil {
+		return nil, err
+	}
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't get config")
+	}
+
+	index := []LinkSearchResult{}
+	add := func(kind, name string, path []string, reference link.Reference) {
+		url := parser.URL(reference)
+		index = append(index, LinkSearchResult{
+			Kind:      kind,
+			Name:      name,
+			Path:      strings.Join(path, linkSearchPathSeparator),
+			URL:       url,
+			Markdown:  fmt.Sprintf("[%s](%s)", name, url),
+			reference: reference,
+		})
+	}
+
+	for _, organization := range platformConfig.Organizations {
+		organizationPath := []string{organization.Name}
+		add(LinkSearchOrganization, organization.Name, organizationPath, link.Reference{OrganizationID: organization.ID})
+
+		for _, section := range flattenSections(organization.Sections) {
+			sectionReference := link.Reference{
+				OrganizationID: organization.ID,
+				SectionID:      section.ID,
+				SectionName:    link.FormatName(section.Name),
+			}
+			sectionPath := appendPath(organizationPath, section.Name)
+			add(LinkSearchSection, section.Name, sectionPath, sectionReference)
+
+			if section.IsIssues {
+				graphURL := fmt.Sprintf("%s/ecosystem_graph", strings.TrimSuffix(section.URL, "/"))
+				for _, node := range s.fetchGraphNodes(graphURL) {
+					// The ecosystem graph is shown in the page of the issues section, which highlights the node in the hash
+					nodeReference := sectionReference
+					nodeReference.Fragment = link.ParseFragment(node.ID, nodeReference)
+					add(LinkSearchGraphNode, node.name(), sectionPath, nodeReference)
+				}
+			}
+
+			elementKind := LinkSearchElement
+			if section.IsIssues {
+				elementKind = LinkSearchIssue
+			}
+			elements, err := fetchSectionElements(s.providerClient, section.URL)
+			if err != nil {
+				s.api.LogWarn("Unable to fetch section elements", "url", section.URL, "err", err)
+			}
+			for _, element := range elements {
+				elementReference := sectionReference
+				elementReference.ElementID = element.ID
+				elementPath := appendPath(sectionPath, element.Name)
+				add(elementKind, element.Name, elementPath, elementReference)
+
+				for _, widget := range section.Widgets {
+					widgetURL := strings.ReplaceAll(widget.URL, widgetIDToken, element.ID)
+					widgetPath := appendPath(elementPath, widget.Name)
+					switch widget.Type {
+					case chartWidgetType:
+						for _, point := range s.fetchChartDataPoints(widgetURL, element.ID) {
+							pointReference := elementReference
+							pointReference.Fragment = link.ParseFragment(point.hash, pointReference)
+							add(LinkSearchDataPoint, point.name, widgetPath, pointReference)
+						}
+					case graphWidgetType:
+						for _, node := range s.fetchGraphNodes(widgetURL) {
+							nodeReference := elementReference
+							nodeReference.Fragment = link.ParseFragment(fmt.Sprintf("%s-%s-%s", node.ID, element.ID, section.ID), nodeReference)
+							add(LinkSearchGraphNode, node.name(), widgetPath, nodeReference)
+						}
+					}
+				}
+			}
+		}
+	}
+	return index, nil
+}
+
+type chartDataPoint struct {
+	name string
+	hash string
+}
+
+// Gets the data points of a chart, identified by the same hashes the webapp uses to highlight them.
+// Bar charts highlight the cells of a label, line charts the dot of each series.
+func (s *LinkSearchService) fetchChartDataPoints(chartURL, elementID string) []chartDataPoint {
+	var chart struct {
+		BarData  []map[string]interface{} `json:"barData"`
+		LineData []map[string]interface{} `json:"lineData"`
+	}
+	if err := s.providerClient.FetchJSON(chartURL, &chart); err != nil {
+		s.api.LogWarn("Unable to fetch chart data", "url", chartURL, "err", err)
+		return nil
+	}
+
+	elementHash := strings.ReplaceAll(elementID, "-", "hpn")
+	points := []chartDataPoint{}
+	for i, bar := range chart.BarData {
+		points = append(points, chartDataPoint{
+			name: fmt.Sprint(bar["label"]),
+			hash: fmt.Sprintf("cell-%d-%s", i, elementHash),
+		})
+	}
+	for _, line := range chart.LineData {
+		label := fmt.Sprint(line["label"])
+		series := make([]string, 0, len(line))
+		for key := range line {
+			if key != "label" {
+				series = append(series, key)
+			}
+		}
+		sort.Strings(series)
+		for _, key := range series {
+			value, ok := line[key].(float64)
+			if !ok {
+				continue
+			}
+			points = append(points, chartDataPoint{
+				name: fmt.Sprintf("%s %s", label, key),
+				hash: fmt.Sprintf("dot-%s-%s-%s", stringifyDotLabel(label), stringifyDotValue(value), elementHash),
+			})
+		}
+	}
+	return points
+}
+
+type graphNode struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Data struct {
+		Label string `json:"label"`
+	} `json:"data"`
+}
+
+// Providers serve either the nodes of the ecosystem graph, which have a name, or those of graph widgets, which have a label
+func (n graphNode) name() string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return n.Data.Label
+}
+
+func (s *LinkSearchService) fetchGraphNodes(graphURL string) []graphNode {
+	var graph struct {
+		Nodes []graphNode `json:"nodes"`
+	}
+	if err := s.providerClient.FetchJSON(graphURL, &graph); err != nil {
+		s.api.LogWarn("Unable to fetch graph", "url", graphURL, "err", err)
+		return nil
+	}
+	return graph.Nodes
+}
+
+// Mirrors the labelStringify function of the webapp, which only replaces the first dot and whitespace
+func stringifyDotLabel(label string) string {
+	return strings.Replace(strings.Replace(label, ".", "dot", 1), " ", "wsp", 1)
+}
+
+// Mirrors the valueStringify function of the webapp, formatting numbers as JavaScript does
+func stringifyDotValue(value float64) string {
+	return strings.Replace(strconv.FormatFloat(value, 'f', -1, 64), ".", "dot", 1)
+}
+
+func appendPath(path []string, name string) []string {
+	return append(append([]string{}, path...), name)
+}
+
+func containsAll(text string, words []string) bool {
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
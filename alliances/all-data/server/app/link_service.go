@@ -0,0 +1,147 @@
+package app
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mattermost/mattermost-server/v6/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+// Element names are fetched from the providers, so they are cached for a while once resolved
+const linkNameCacheTTL = 10 * time.Minute
+
+type LinkService struct {
+	api             plugin.API
+	platformService *config.PlatformService
+	channelStore    ChannelStore
+	providerClient  *link.ProviderClient
+	pluginID        string
+
+	namesLock sync.Mutex
+	names     map[string]cachedLinkName // By canonical key
+}
+
+type cachedLinkName struct {
+	name       string
+	resolvedAt time.Time
+}
+
+// NewLinkService returns a new links service
+func NewLinkService(api plugin.API, platformService *config.PlatformService, channelStore ChannelStore, providerClient *link.ProviderClient, pluginID string) *LinkService {
+	return &LinkService{
+		api:             api,
+		platformService: platformService,
+		channelStore:    channelStore,
+		providerClient:  providerClient,
+		pluginID:        pluginID,
+		names:           map[string]cachedLinkName{},
+	}
+}
+
+// Builds the parser and the resolver for the current site URL and platform config. Both can be reused to handle many links at once.
+func (s *LinkService) GetLinkModel() (*link.Parser, *link.Resolver, error) {
+	serverConfig := s.api.GetConfig()
+	parser, err := link.NewParser(*serverConfig.ServiceSettings.SiteURL, s.pluginID)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to create link parser")
+	}
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to get platform config to resolve links")
+	}
+	return parser, link.NewResolver(platformConfig, s, s.providerClient), nil
+}
+
+// Parses a HOOD URL into its canonical reference. The second value is false for URLs not pointing to the platform.
+func (s *LinkService) ParseURL(rawURL string) (link.Reference, bool, error) {
+	parser, resolver, err := s.GetLinkModel()
+	if err != nil {
+		return link.Reference{}, false, err
+	}
+	reference, ok := parser.Parse(rawURL)
+	if !ok {
+		return link.Reference{}, false, nil
+	}
+	return resolver.Canonicalize(reference), true, nil
+}
+
+// Resolves either a HOOD URL or a canonical key to its canonical reference and display name.
+func (s *LinkService) ResolveLink(rawURL, key string) (ResolveLinkResult, error) {
+	parser, resolver, err := s.GetLinkModel()
+	if err != nil {
+		return ResolveLinkResult{}, err
+	}
+
+	var reference link.Reference
+	if rawURL != "" {
+		parsedReference, ok := parser.Parse(rawURL)
+		if !ok {
+			return ResolveLinkResult{}, errors.Wrapf(ErrNotFound, "%s is not a link to the platform", rawURL)
+		}
+		reference = resolver.Canonicalize(parsedReference)
+	} else {
+		parsedReference, err := link.ParseKey(key)
+		if err != nil {
+			return ResolveLinkResult{}, errors.Wrap(ErrNotFound, err.Error())
+		}
+		reference = resolver.Canonicalize(parsedReference)
+	}
+
+	name, err := resolver.ResolveName(reference.Key())
+	if err != nil {
+		return ResolveLinkResult{}, errors.Wrap(ErrNotFound, err.Error())
+	}
+	return ResolveLinkResult{
+		Key:       reference.Key(),
+		Kind:      reference.Kind(),
+		Name:      name,
+		URL:       parser.URL(reference),
+		Reference: reference,
+	}, nil
+}
+
+// Resolves a canonical key to the display name of the element, caching the names resolved for a while.
+// The resolver is only used when the name is not cached.
+func (s *LinkService) ResolveName(resolver *link.Resolver, key string) (string, error) {
+	s.namesLock.Lock()
+	cached, found := s.names[key]
+	s.namesLock.Unlock()
+	if found && time.Since(cached.resolvedAt) < linkNameCacheTTL {
+		return cached.name, nil
+	}
+
+	name, err := resolver.ResolveName(key)
+	if err != nil {
+		return "", err
+	}
+	s.namesLock.Lock()
+	defer s.namesLock.Unlock()
+	s.names[key] = cachedLinkName{name: name, resolvedAt: time.Now()}
+	return name, nil
+}
+
+// Maps a channel to the element whose widgets are shown in its RHS, to canonicalize widgets referenced from channels.
+func (s *LinkService) ResolveChannel(teamName, channelName string) (link.Reference, bool) {
+	team, appErr := s.api.GetTeamByName(teamName)
+	if appErr != nil {
+		return link.Reference{}, false
+	}
+	channel, appErr := s.api.GetChannelByName(team.Id, channelName, true)
+	if appErr != nil {
+		return link.Reference{}, false
+	}
+	organizationChannel, err := s.channelStore.GetChannelByID(channel.Id)
+	if err != nil {
+		s.api.LogInfo("Channel is not an organization channel", "channelId", channel.Id)
+		return link.Reference{}, false
+	}
+	return link.Reference{
+		OrganizationID: organizationChannel.OrganizationID,
+		SectionID:      organizationChannel.ParentID,
+		ElementID:      organizationChannel.SectionID,
+	}, true
+}
@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+)
+
+var markdownLinkTextEscaper = strings.NewReplacer("[", "\\[", "]", "\\]")
+
+// Markdown exports the channel as a transcript, with replies quoted below their thread root.
+type Markdown struct{}
+
+type markdownWriter struct {
+	w      io.Writer
+	export *ChannelExport
+}
+
+func (e *Markdown) FileName(name string) string {
+	return fmt.Sprintf("%s.md", name)
+}
+
+func (e *Markdown) ContentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+func (e *Markdown) NewWriter(w io.Writer, export *ChannelExport) (ExportWriter, error) {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "# %s\n\n", export.Channel.DisplayName)
+	if export.Channel.Header != "" {
+		fmt.Fprintf(&builder, "%s\n\n", export.Channel.Header)
+	}
+	if export.Channel.Purpose != "" {
+		fmt.Fprintf(&builder, "%s\n\n", export.Channel.Purpose)
+	}
+	fmt.Fprintf(&builder, "_Exported on %s_\n", exportTime(export.ExportedAt))
+
+	if _, err := io.WriteString(w, builder.String()); err != nil {
+		return nil, err
+	}
+	return &markdownWriter{w: w, export: export}, nil
+}
+
+func (w *markdownWriter) WriteThread(thread *ExportThread) error {
+	var builder strings.Builder
+	builder.WriteString("\n---\n\n")
+	writeMarkdownPost(&builder, w.export, thread.Root, "")
+	for _, reply := range thread.Replies {
+		builder.WriteString(">\n")
+		writeMarkdownPost(&builder, w.export, reply, "> ")
+	}
+	_, err := io.WriteString(w.w, builder.String())
+	return err
+}
+
+func (w *markdownWriter) Close() error {
+	return nil
+}
+
+func writeMarkdownPost(builder *strings.Builder, export *ChannelExport, post *mattermost.Post, prefix string) {
+	pinned := ""
+	if post.IsPinned {
+		pinned = " (pinned)"
+	}
+	fmt.Fprintf(builder, "%s**%s** - %s%s\n%s\n", prefix, export.UserName(post.UserId), exportTime(post.CreateAt), pinned, prefix)
+	for _, line := range strings.Split(post.Message, "\n") {
+		fmt.Fprintf(builder, "%s%s\n", prefix, line)
+	}
+
+	items := []string{}
+	for _, fileID := range post.FileIds {
+		if fileLink, found := export.FileLinks[fileID]; found {
+			items = append(items, fmt.Sprintf("Attachment: <%s>", fileLink))
+		}
+	}
+	for _, backlink := range export.Backlinks[post.Id] {
+		items = append(items, fmt.Sprintf("Linked element: [%s](%s)", markdownLinkTextEscaper.Replace(backlink.Name), backlink.URL))
+	}
+	if len(items) > 0 {
+		fmt.Fprintf(builder, "%s\n", prefix)
+	}
+	for _, item := range items {
+		fmt.Fprintf(builder, "%s- %s\n", prefix, item)
+	}
+}
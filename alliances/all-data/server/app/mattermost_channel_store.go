@@ -1,5 +1,6 @@
 package app
 
 type MattermostChannelStore interface {
+	// GetChannelsForTeam gets the channels of a team, private ones included. Direct and group channels belong to the empty team id.
 	GetChannelsForTeam(teamID string) (GetMattermostChannelsResults, error)
 }
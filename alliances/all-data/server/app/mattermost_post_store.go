@@ -0,0 +1,22 @@
+package app
+
+import mattermost "github.com/mattermost/mattermost-server/v6/model"
+
+type MattermostPostStore interface {
+	GetRootPostsForChannel(channelID string, after PostCursor, limit int, options RootPostsOptions) ([]*mattermost.Post, error)
+	CountRootPostsForChannel(channelID string, options RootPostsOptions) (int, error)
+	GetRepliesForRootPosts(rootIDs []string) ([]*mattermost.Post, error)
+	GetReactionsForPosts(postIDs []string) ([]*mattermost.Reaction, error)
+}
+
+// RootPostsOptions restricts the root posts of a channel. The zero value of each option keeps all posts.
+type RootPostsOptions struct {
+	PinnedOnly bool
+	Until      int64 // Only roots created up to this time, in milliseconds
+}
+
+// PostCursor identifies the last post of a page, to get the following one. The zero value gets the first page.
+type PostCursor struct {
+	CreateAt int64
+	PostID   string
+}
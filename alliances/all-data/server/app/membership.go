@@ -0,0 +1,54 @@
+package app
+
+// Roles of a user in an organization. Facilitators manage the members of their organization, admins also manage the other roles.
+const (
+	MembershipRoleMember      = "member"
+	MembershipRoleFacilitator = "facilitator"
+	MembershipRoleAdmin       = "admin"
+)
+
+// OrganizationMembership links a user to one of the organizations the user belongs to, with a role in it.
+type OrganizationMembership struct {
+	UserID         string `json:"userId"`
+	OrganizationID string `json:"organizationId"`
+	Role           string `json:"role"`
+	CreateAt       int64  `json:"createAt"`
+	UpdateAt       int64  `json:"updateAt"`
+}
+
+type AddMembershipParams struct {
+	UserID         string `json:"userId"`
+	OrganizationID string `json:"organizationId"`
+	Role           string `json:"role"` // Defaults to member
+}
+
+type UpdateMembershipParams struct {
+	Role string `json:"role"`
+}
+
+type GetMembershipsResults struct {
+	Items []OrganizationMembership `json:"items"`
+}
+
+func IsValidMembershipRole(role string) bool {
+	return role == MembershipRoleMember || role == MembershipRoleFacilitator || role == MembershipRoleAdmin
+}
+
+// MembershipChange is a user added to or removed from an organization channel by the reconciliation.
+type MembershipChange struct {
+	ChannelID      string `json:"channelId"`
+	UserID         string `json:"userId"`
+	OrganizationID string `json:"organizationId"`
+}
+
+// ReconciliationReport describes what the last reconciliation of the organization channels changed,
+// or would have changed in case of a dry run.
+type ReconciliationReport struct {
+	StartAt         int64              `json:"startAt"`
+	EndAt           int64              `json:"endAt"`
+	DryRun          bool               `json:"dryRun"`
+	ChannelsChecked int                `json:"channelsChecked"`
+	Added           []MembershipChange `json:"added"`
+	Removed         []MembershipChange `json:"removed"`
+	Errors          []string           `json:"errors"`
+}
@@ -0,0 +1,355 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-api/cluster"
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+const (
+	reconciliationReportKey = "membership_reconciliation_report"
+	reconciliationInterval  = time.Hour
+	channelMembersPerPage   = 200
+)
+
+// Starts the job reconciling the organization channels periodically, which runs on a single server of the cluster at a time.
+// The job does nothing while the reconciliation is off, and only reports the changes it would make in dry run mode.
+func (s *MembershipService) StartReconciliationJob() (*cluster.Job, error) {
+	return cluster.Schedule(s.api, "CSA_membershipReconciliationJob", cluster.MakeWaitForInterval(reconciliationInterval), func() {
+		mode := s.configuration.GetConfiguration().MembershipReconciliation
+		if mode != config.ReconciliationDryRun && mode != config.ReconciliationEnforce {
+			return
+		}
+		if _, err := s.reconcile(mode == config.ReconciliationDryRun); err != nil {
+			s.api.LogError("failed to reconcile organization channels", "err", err)
+		}
+	})
+}
+
+// Starts a reconciliation in background, which is a privileged action. The outcome is then available with GetReconciliationReport.
+// Unless the reconciliation is enforced in the configuration, it is a dry run only reporting the changes it would make.
+func (s *MembershipService) StartReconciliation(actorID string) error {
+	if err := s.authorizationService.Authorize(actorID, "", ActionReconcileMemberships, "", ""); err != nil {
+		return err
+	}
+	if !atomic.CompareAndSwapInt32(&s.reconciling, 0, 1) {
+		return errors.Wrap(ErrAlreadyRunning, "organization channels are already being reconciled")
+	}
+
+	dryRun := s.configuration.GetConfiguration().MembershipReconciliation != config.ReconciliationEnforce
+	go func() {
+		defer atomic.StoreInt32(&s.reconciling, 0)
+		if _, err := s.reconcile(dryRun); err != nil {
+			s.api.LogError("failed to reconcile organization channels", "userId", actorID, "err", err)
+		}
+	}()
+	return nil
+}
+
+// Gets the report of the last reconciliation, which only privileged users can read.
+func (s *MembershipService) GetReconciliationReport(actorID string) (ReconciliationReport, error) {
+	if !s.authorizationService.IsPrivileged(actorID, "") {
+		return ReconciliationReport{}, errors.Wrapf(ErrForbidden, "user %s cannot read the reconciliation report", actorID)
+	}
+	var report ReconciliationReport
+	if err := kvGetJSON(s.api, reconciliationReportKey, &report); err != nil {
+		return ReconciliationReport{}, errors.Wrap(err, "unable to get reconciliation report")
+	}
+	if report.StartAt == 0 {
+		return ReconciliationReport{}, errors.Wrap(ErrNotFound, "organization channels have never been reconciled")
+	}
+	return report, nil
+}
+
+// Compares the members of every organization channel with the users belonging to the organization, adding the missing ones
+// and removing the others. Only bots and the members and roles of the channel template of the section can stay without belonging
+// to the organization, and users who selected all organizations belong to every channel. Ecosystem channels,
+// issue channels included, are not reconciled. A dry run only reports the changes.
+func (s *MembershipService) reconcile(dryRun bool) (ReconciliationReport, error) {
+	mutex, err := cluster.NewMutex(s.api, "CSA_membershipReconciliationMutex")
+	if err != nil {
+		return ReconciliationReport{}, errors.Wrap(err, "failed creating cluster mutex to reconcile organization channels")
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	report := ReconciliationReport{
+		StartAt: time.Now().UnixMilli(),
+		DryRun:  dryRun,
+		Added:   []MembershipChange{},
+		Removed: []MembershipChange{},
+		Errors:  []string{},
+	}
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return report, errors.Wrap(err, "couldn't get config")
+	}
+	ecosystem, _ := platformConfig.GetEcosystem()
+	resolver := link.NewResolver(platformConfig, nil, nil)
+	orgChannels, err := s.channelService.GetAllOrganizationChannels()
+	if err != nil {
+		return report, errors.Wrap(err, "couldn't get all organization channels")
+	}
+
+	reconciler := &channelsReconciler{
+		service:      s,
+		report:       &report,
+		orgMembers:   map[string][]string{},
+		allOrgsUsers: map[string][]string{},
+		teamMembers:  map[string]bool{},
+		teamRoles:    map[string]map[string][]string{},
+		users:        map[string]*mattermost.User{},
+	}
+	for _, orgChannel := range orgChannels.Items {
+		if orgChannel.OrganizationID == "" || (ecosystem != nil && orgChannel.OrganizationID == ecosystem.ID) {
+			continue
+		}
+		channel, appErr := s.api.GetChannel(orgChannel.ChannelID)
+		if appErr != nil {
+			reconciler.addError("couldn't get channel %s: %s", orgChannel.ChannelID, appErr.Error())
+			continue
+		}
+		if channel.DeleteAt != 0 {
+			continue
+		}
+		var template *config.ChannelTemplate
+		if section, found := resolver.FindSection(orgChannel.OrganizationID, orgChannel.ParentID); found {
+			template = section.ChannelTemplate
+		}
+		reconciler.reconcileChannel(channel, orgChannel.OrganizationID, template)
+		report.ChannelsChecked++
+	}
+
+	report.EndAt = time.Now().UnixMilli()
+	if err := kvSetJSON(s.api, reconciliationReportKey, report); err != nil {
+		s.api.LogWarn("failed to store reconciliation report", "err", err)
+	}
+	s.api.LogInfo("Organization channels reconciled", "channels", report.ChannelsChecked, "dryRun", dryRun, "added", len(report.Added), "removed", len(report.Removed), "errors", len(report.Errors))
+	return report, nil
+}
+
+// channelsReconciler reconciles the organization channels one at a time, caching what is shared among channels.
+type channelsReconciler struct {
+	service      *MembershipService
+	report       *ReconciliationReport
+	orgMembers   map[string][]string            // Users belonging to each organization
+	allOrgsUsers map[string][]string            // Users who selected all organizations, by team
+	teamMembers  map[string]bool                // By team and user id
+	teamRoles    map[string]map[string][]string // Roles in the team of its members, by team and user id
+	users        map[string]*mattermost.User    // Loaded users, nil for those that could not be got
+}
+
+func (r *channelsReconciler) reconcileChannel(channel *mattermost.Channel, organizationID string, template *config.ChannelTemplate) {
+	expected, err := r.expectedMembers(channel.TeamId, organizationID)
+	if err != nil {
+		r.addError("couldn't get expected members of channel %s: %s", channel.Id, err.Error())
+		return
+	}
+	current, err := r.channelMembers(channel.Id)
+	if err != nil {
+		r.addError("couldn't get members of channel %s: %s", channel.Id, err.Error())
+		return
+	}
+
+	for userID := range expected {
+		if current[userID] {
+			continue
+		}
+		if r.report.DryRun {
+			r.report.Added = append(r.report.Added, MembershipChange{ChannelID: channel.Id, UserID: userID, OrganizationID: organizationID})
+			continue
+		}
+		if _, appErr := r.service.api.AddChannelMember(channel.Id, userID); appErr != nil {
+			r.addError("couldn't add user %s to channel %s: %s", userID, channel.Id, appErr.Error())
+			continue
+		}
+		r.report.Added = append(r.report.Added, MembershipChange{ChannelID: channel.Id, UserID: userID, OrganizationID: organizationID})
+	}
+
+	unexpected := []string{}
+	for userID := range current {
+		if !expected[userID] {
+			unexpected = append(unexpected, userID)
+		}
+	}
+	r.loadUsers(unexpected)
+	for _, userID := range unexpected {
+		if r.isAllowed(channel.TeamId, template, userID) {
+			continue
+		}
+		if r.report.DryRun {
+			r.report.Removed = append(r.report.Removed, MembershipChange{ChannelID: channel.Id, UserID: userID, OrganizationID: organizationID})
+			continue
+		}
+		if appErr := r.service.api.DeleteChannelMember(channel.Id, userID); appErr != nil {
+			r.addError("couldn't remove user %s from channel %s: %s", userID, channel.Id, appErr.Error())
+			continue
+		}
+		r.report.Removed = append(r.report.Removed, MembershipChange{ChannelID: channel.Id, UserID: userID, OrganizationID: organizationID})
+	}
+}
+
+// Users expected in a channel are the team members belonging to its organization, and those who selected all organizations
+func (r *channelsReconciler) expectedMembers(teamID, organizationID string) (map[string]bool, error) {
+	members, found := r.orgMembers[organizationID]
+	if !found {
+		memberships, err := r.service.store.GetMembershipsByOrganizationID(organizationID)
+		if err != nil {
+			return nil, err
+		}
+		members = make([]string, 0, len(memberships))
+		for _, membership := range memberships {
+			members = append(members, membership.UserID)
+		}
+		r.orgMembers[organizationID] = members
+	}
+	allOrgsUsers, err := r.allOrganizationsUsers(teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[string]bool, len(members)+len(allOrgsUsers))
+	for _, userID := range members {
+		if r.isTeamMember(teamID, userID) {
+			expected[userID] = true
+		}
+	}
+	for _, userID := range allOrgsUsers {
+		expected[userID] = true
+	}
+	return expected, nil
+}
+
+func (r *channelsReconciler) allOrganizationsUsers(teamID string) ([]string, error) {
+	if userIDs, found := r.allOrgsUsers[teamID]; found {
+		return userIDs, nil
+	}
+	userIDs := []string{}
+	for page := 0; ; page++ {
+		users, appErr := r.service.api.GetUsersInTeam(teamID, page, membershipsUsersPerPage)
+		if appErr != nil {
+			return nil, appErr
+		}
+		for _, user := range users {
+			r.teamMembers[teamID+user.Id] = true
+			r.users[user.Id] = user
+			if orgID, found := user.GetProp("orgId"); found && orgID == config.OrganizationIDAll {
+				userIDs = append(userIDs, user.Id)
+			}
+		}
+		if len(users) < membershipsUsersPerPage {
+			break
+		}
+	}
+	r.allOrgsUsers[teamID] = userIDs
+	return userIDs, nil
+}
+
+// Relies on allOrganizationsUsers having loaded all the members of the team
+func (r *channelsReconciler) isTeamMember(teamID, userID string) bool {
+	return r.teamMembers[teamID+userID]
+}
+
+func (r *channelsReconciler) channelMembers(channelID string) (map[string]bool, error) {
+	members := map[string]bool{}
+	for page := 0; ; page++ {
+		channelMembers, appErr := r.service.api.GetChannelMembers(channelID, page, channelMembersPerPage)
+		if appErr != nil {
+			return nil, appErr
+		}
+		for _, member := range channelMembers {
+			members[member.UserId] = true
+		}
+		if len(channelMembers) < channelMembersPerPage {
+			break
+		}
+	}
+	return members, nil
+}
+
+// Loads the given users, those not among the team members already loaded
+func (r *channelsReconciler) loadUsers(userIDs []string) {
+	for _, userID := range userIDs {
+		if _, found := r.users[userID]; found {
+			continue
+		}
+		user, appErr := r.service.api.GetUser(userID)
+		if appErr != nil {
+			r.addError("couldn't get user %s: %s", userID, appErr.Error())
+		}
+		r.users[userID] = user
+	}
+}
+
+// Tells whether a user can stay in a channel without belonging to its organization, being a bot or
+// among the members of the channel template, either by username or by system or team role
+func (r *channelsReconciler) isAllowed(teamID string, template *config.ChannelTemplate, userID string) bool {
+	user := r.users[userID]
+	if user == nil || user.IsBot {
+		// Better not to remove anyone than to remove a bot
+		return true
+	}
+	if template == nil {
+		return false
+	}
+	for _, username := range template.Members {
+		if strings.TrimPrefix(username, "@") == user.Username {
+			return true
+		}
+	}
+	if len(template.Roles) == 0 {
+		return false
+	}
+
+	teamRoles, err := r.getTeamRoles(teamID)
+	if err != nil {
+		// Better not to remove anyone than to remove a member with one of the roles
+		r.addError("couldn't get the roles of the members of team %s: %s", teamID, err.Error())
+		return true
+	}
+	roles := append(strings.Fields(user.Roles), teamRoles[userID]...)
+	for _, templateRole := range template.Roles {
+		for _, role := range roles {
+			if role == templateRole {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Gets the roles of the members of a team in the team, e.g. team_admin, by user id
+func (r *channelsReconciler) getTeamRoles(teamID string) (map[string][]string, error) {
+	if teamRoles, found := r.teamRoles[teamID]; found {
+		return teamRoles, nil
+	}
+	teamRoles := map[string][]string{}
+	for page := 0; ; page++ {
+		members, appErr := r.service.api.GetTeamMembers(teamID, page, membershipsUsersPerPage)
+		if appErr != nil {
+			return nil, appErr
+		}
+		for _, member := range members {
+			teamRoles[member.UserId] = member.GetRoles()
+		}
+		if len(members) < membershipsUsersPerPage {
+			break
+		}
+	}
+	r.teamRoles[teamID] = teamRoles
+	return teamRoles, nil
+}
+
+func (r *channelsReconciler) addError(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	r.service.api.LogWarn("Reconciliation error", "err", message)
+	r.report.Errors = append(r.report.Errors, message)
+}
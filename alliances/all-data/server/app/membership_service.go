@@ -0,0 +1,323 @@
+package app
+
+import (
+	"time"
+
+	"github.com/mattermost/mattermost-plugin-api/cluster"
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+)
+
+const (
+	// Set once the organizations selected through the orgId user prop have been converted to memberships
+	membershipsMigratedKey  = "memberships_migrated"
+	membershipsUsersPerPage = 200
+)
+
+type MembershipService struct {
+	api                  plugin.API
+	store                MembershipStore
+	channelService       *ChannelService
+	categoryService      *CategoryService
+	platformService      *config.PlatformService
+	authorizationService *AuthorizationService
+	configuration        *config.MattermostConfig
+	reconciling          int32 // Set to 1 while an on-demand reconciliation is running
+}
+
+// NewMembershipService returns a new organization memberships service
+func NewMembershipService(api plugin.API, store MembershipStore, channelService *ChannelService, categoryService *CategoryService, platformService *config.PlatformService, authorizationService *AuthorizationService, configuration *config.MattermostConfig) *MembershipService {
+	return &MembershipService{
+		api:                  api,
+		store:                store,
+		channelService:       channelService,
+		categoryService:      categoryService,
+		platformService:      platformService,
+		authorizationService: authorizationService,
+		configuration:        configuration,
+	}
+}
+
+// Gets the organizations a user belongs to. Users can see their own memberships, privileged users anyone's.
+func (s *MembershipService) GetUserMemberships(actorID, userID string) (GetMembershipsResults, error) {
+	if actorID != userID && !s.authorizationService.IsPrivileged(actorID, "") {
+		return GetMembershipsResults{}, errors.Wrapf(ErrForbidden, "user %s cannot see the memberships of user %s", actorID, userID)
+	}
+	memberships, err := s.store.GetMembershipsByUserID(userID)
+	if err != nil {
+		return GetMembershipsResults{}, err
+	}
+	return GetMembershipsResults{Items: memberships}, nil
+}
+
+// Gets the members of an organization, which only its members and privileged users can see.
+func (s *MembershipService) GetOrganizationMemberships(actorID, organizationID string) (GetMembershipsResults, error) {
+	if !s.IsOrganizationMember(actorID, organizationID) && !s.authorizationService.IsPrivileged(actorID, "") {
+		return GetMembershipsResults{}, errors.Wrapf(ErrForbidden, "user %s cannot see the members of organization %s", actorID, organizationID)
+	}
+	memberships, err := s.store.GetMembershipsByOrganizationID(organizationID)
+	if err != nil {
+		return GetMembershipsResults{}, err
+	}
+	return GetMembershipsResults{Items: memberships}, nil
+}
+
+func (s *MembershipService) IsOrganizationMember(userID, organizationID string) bool {
+	_, err := s.store.GetMembership(userID, organizationID)
+	return err == nil
+}
+
+// Adds a user to an organization, joining the organization channels.
+func (s *MembershipService) AddMembership(actorID string, params AddMembershipParams) (OrganizationMembership, error) {
+	if params.Role == "" {
+		params.Role = MembershipRoleMember
+	}
+	if !IsValidMembershipRole(params.Role) {
+		return OrganizationMembership{}, errors.Wrapf(ErrInvalidInput, "invalid role %s", params.Role)
+	}
+	if err := s.checkOrganization(params.OrganizationID); err != nil {
+		return OrganizationMembership{}, err
+	}
+	if err := s.checkCanManage(actorID, params.OrganizationID, params.Role, "add "+params.UserID+" as "+params.Role); err != nil {
+		return OrganizationMembership{}, err
+	}
+	if _, appErr := s.api.GetUser(params.UserID); appErr != nil {
+		return OrganizationMembership{}, errors.Wrapf(ErrNotFound, "user %s not found", params.UserID)
+	}
+	return s.addMembership(params.UserID, params.OrganizationID, params.Role)
+}
+
+// Changes the role of a member. Only admins of the organization and system admins can do it.
+func (s *MembershipService) UpdateMembership(actorID, userID, organizationID string, params UpdateMembershipParams) (OrganizationMembership, error) {
+	if !IsValidMembershipRole(params.Role) {
+		return OrganizationMembership{}, errors.Wrapf(ErrInvalidInput, "invalid role %s", params.Role)
+	}
+	membership, err := s.store.GetMembership(userID, organizationID)
+	if err != nil {
+		return OrganizationMembership{}, err
+	}
+	if err := s.checkCanManage(actorID, organizationID, MembershipRoleAdmin, "change role of "+userID+" to "+params.Role); err != nil {
+		return OrganizationMembership{}, err
+	}
+
+	membership.Role = params.Role
+	membership.UpdateAt = time.Now().UnixMilli()
+	if err := s.store.UpdateMembershipRole(userID, organizationID, membership.Role, membership.UpdateAt); err != nil {
+		return OrganizationMembership{}, err
+	}
+	return membership, nil
+}
+
+// Removes a user from an organization, leaving the organization channels. Users can always leave an organization themselves.
+func (s *MembershipService) RemoveMembership(actorID, userID, organizationID string) error {
+	membership, err := s.store.GetMembership(userID, organizationID)
+	if err != nil {
+		return err
+	}
+	if actorID != userID {
+		if err := s.checkCanManage(actorID, organizationID, membership.Role, "remove "+userID); err != nil {
+			return err
+		}
+	}
+	return s.removeMembership(userID, organizationID)
+}
+
+// Resets the organization a user selected, so that the user can select another one. The user is removed from the organization
+// as well, leaving its channels. Resetting the organization of a user is a privileged action.
+func (s *MembershipService) ResetUserOrganization(actorID, teamID, userID string) error {
+	if err := s.authorizationService.Authorize(actorID, teamID, ActionResetUserOrganization, userID, ""); err != nil {
+		return err
+	}
+	user, appErr := s.api.GetUser(userID)
+	if appErr != nil {
+		return errors.Wrapf(ErrNotFound, "user %s not found", userID)
+	}
+	orgID, _ := user.GetProp("orgId")
+	// The prop is cleared first, since users who selected all the organizations keep their channels
+	user.SetProp("orgId", "")
+	if _, appErr := s.api.UpdateUser(user); appErr != nil {
+		return errors.Wrapf(appErr, "couldn't update props of user %s", userID)
+	}
+	if orgID == "" || orgID == config.OrganizationIDAll {
+		return nil
+	}
+	if _, err := s.store.GetMembership(userID, orgID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.removeMembership(userID, orgID)
+}
+
+// Converts the organizations selected through the orgId user prop to memberships, once and in background.
+func (s *MembershipService) MigrateOrganizationProps() error {
+	var migrated bool
+	if err := kvGetJSON(s.api, membershipsMigratedKey, &migrated); err != nil {
+		return errors.Wrap(err, "unable to get memberships migration status")
+	}
+	if migrated {
+		return nil
+	}
+
+	go func() {
+		mutex, err := cluster.NewMutex(s.api, "CSA_membershipsMutex")
+		if err != nil {
+			s.api.LogError("failed creating cluster mutex to migrate memberships", "err", err)
+			return
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		count := 0
+		for page := 0; ; page++ {
+			users, appErr := s.api.GetUsers(&mattermost.UserGetOptions{Page: page, PerPage: membershipsUsersPerPage})
+			if appErr != nil {
+				s.api.LogError("failed to get users while migrating memberships", "page", page, "err", appErr)
+				return
+			}
+			for _, user := range users {
+				orgID, found := user.GetProp("orgId")
+				if !found || orgID == "" || orgID == config.OrganizationIDAll {
+					continue
+				}
+				if err := s.ensureMembership(user.Id, orgID); err != nil {
+					s.api.LogWarn("failed to migrate membership", "userId", user.Id, "orgId", orgID, "err", err)
+					continue
+				}
+				count++
+			}
+			if len(users) < membershipsUsersPerPage {
+				break
+			}
+		}
+		if err := kvSetJSON(s.api, membershipsMigratedKey, true); err != nil {
+			s.api.LogWarn("failed to store memberships migration status", "err", err)
+		}
+		s.api.LogInfo("Organization props migrated to memberships", "memberships", count)
+	}()
+	return nil
+}
+
+// Returns the organizations a user belongs to, by id
+func (s *MembershipService) getUserOrganizationIDs(userID string) (map[string]bool, error) {
+	memberships, err := s.store.GetMembershipsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	organizationIDs := make(map[string]bool, len(memberships))
+	for _, membership := range memberships {
+		organizationIDs[membership.OrganizationID] = true
+	}
+	return organizationIDs, nil
+}
+
+// Adds a user to an organization as a member if not already in it, without joining the channels
+func (s *MembershipService) ensureMembership(userID, organizationID string) error {
+	err := s.store.AddMembership(s.newMembership(userID, organizationID, MembershipRoleMember))
+	if errors.Is(err, ErrAlreadyExists) {
+		return nil
+	}
+	return err
+}
+
+func (s *MembershipService) addMembership(userID, organizationID, role string) (OrganizationMembership, error) {
+	membership := s.newMembership(userID, organizationID, role)
+	if err := s.store.AddMembership(membership); err != nil {
+		return OrganizationMembership{}, err
+	}
+	s.joinOrganizationChannels(userID, organizationID)
+	return membership, nil
+}
+
+// Removes a user from an organization and its channels
+func (s *MembershipService) removeMembership(userID, organizationID string) error {
+	if err := s.store.DeleteMembership(userID, organizationID); err != nil {
+		return err
+	}
+	s.leaveOrganizationChannels(userID, organizationID)
+	return nil
+}
+
+func (s *MembershipService) newMembership(userID, organizationID, role string) OrganizationMembership {
+	now := time.Now().UnixMilli()
+	return OrganizationMembership{
+		UserID:         userID,
+		OrganizationID: organizationID,
+		Role:           role,
+		CreateAt:       now,
+		UpdateAt:       now,
+	}
+}
+
+// Checks that an organization exists and is not the ecosystem, which everyone already belongs to
+func (s *MembershipService) checkOrganization(organizationID string) error {
+	platformConfig, err := s.platformService.GetPlatformConfig()
+	if err != nil {
+		return errors.Wrap(err, "couldn't get config")
+	}
+	for _, organization := range platformConfig.Organizations {
+		if organization.ID != organizationID {
+			continue
+		}
+		if organization.IsEcosystem {
+			return errors.Wrap(ErrInvalidInput, "every user already belongs to the ecosystem")
+		}
+		return nil
+	}
+	return errors.Wrapf(ErrNotFound, "organization %s not found", organizationID)
+}
+
+// Admins of an organization manage any role in it, while facilitators only manage plain members.
+// Anyone else needs to be a privileged user, and every change is recorded in the audit log.
+func (s *MembershipService) checkCanManage(actorID, organizationID, role, details string) error {
+	actorMembership, err := s.store.GetMembership(actorID, organizationID)
+	if err == nil && (actorMembership.Role == MembershipRoleAdmin || (actorMembership.Role == MembershipRoleFacilitator && role == MembershipRoleMember)) {
+		s.authorizationService.Audit(actorID, "", ActionManageMemberships, organizationID, true, details+" as organization "+actorMembership.Role)
+		return nil
+	}
+	return s.authorizationService.Authorize(actorID, "", ActionManageMemberships, organizationID, details)
+}
+
+func (s *MembershipService) joinOrganizationChannels(userID, organizationID string) {
+	channels, err := s.channelService.GetChannelsByOrganizationID(organizationID)
+	if err != nil {
+		s.api.LogWarn("couldn't get organization channels to join", "orgId", organizationID, "err", err)
+		return
+	}
+	for _, orgChannel := range channels.Items {
+		channel, appErr := s.api.GetChannel(orgChannel.ChannelID)
+		if appErr != nil || channel.DeleteAt != 0 {
+			continue
+		}
+		if _, appErr := s.api.AddChannelMember(channel.Id, userID); appErr != nil {
+			s.api.LogWarn("couldn't add organization channel to user", "channelId", channel.Id, "userId", userID, "err", appErr)
+			continue
+		}
+		if err := s.categoryService.addChannelToOrganizationCategory(userID, channel.TeamId, orgChannel); err != nil {
+			s.api.LogWarn("couldn't add channel to organization category", "channelId", channel.Id, "orgId", organizationID, "err", err)
+		}
+	}
+}
+
+// Users who selected all the organizations keep seeing the channels of the organizations they leave
+func (s *MembershipService) leaveOrganizationChannels(userID, organizationID string) {
+	if user, appErr := s.api.GetUser(userID); appErr == nil {
+		if orgID, found := user.GetProp("orgId"); found && orgID == config.OrganizationIDAll {
+			return
+		}
+	}
+	channels, err := s.channelService.GetChannelsByOrganizationID(organizationID)
+	if err != nil {
+		s.api.LogWarn("couldn't get organization channels to leave", "orgId", organizationID, "err", err)
+		return
+	}
+	for _, orgChannel := range channels.Items {
+		if appErr := s.api.DeleteChannelMember(orgChannel.ChannelID, userID); appErr != nil {
+			s.api.LogDebug("couldn't remove user from organization channel", "channelId", orgChannel.ChannelID, "userId", userID, "err", appErr)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package app
+
+// MembershipStore is an interface for storing the memberships of users in organizations
+type MembershipStore interface {
+	// AddMembership adds a membership, failing with ErrAlreadyExists if the user is already a member of the organization
+	AddMembership(membership OrganizationMembership) error
+
+	GetMembership(userID, organizationID string) (OrganizationMembership, error)
+
+	// GetMembershipsByUserID retrieves the organizations a user belongs to
+	GetMembershipsByUserID(userID string) ([]OrganizationMembership, error)
+
+	// GetMembershipsByOrganizationID retrieves the members of an organization
+	GetMembershipsByOrganizationID(organizationID string) ([]OrganizationMembership, error)
+
+	UpdateMembershipRole(userID, organizationID, role string, updateAt int64) error
+
+	DeleteMembership(userID, organizationID string) error
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+// MisalignmentsProp is the prop of the replies of the bot listing the numbers stated by a post that do not match the data points it links
+const MisalignmentsProp = "misalignments"
+
+// Misalignment is a number stated by a post next to a link to a data point, which does not match the value of the data point
+type Misalignment struct {
+	URL     string  `json:"url"`
+	Name    string  `json:"name"`
+	Claim   string  `json:"claim"` // The number as written in the post, e.g. 1,200 or 12%
+	Claimed float64 `json:"claimed"`
+	Actual  float64 `json:"actual"` // The value of the data point closest to the claimed one
+}
+
+// Numbers, with thousands separators, decimals, percentages and k or M multipliers.
+// The character before the number is matched to tell apart numbers from the digits in words, e.g. Q1 or CVE-2023-1234.
+var numericClaimRegex = regexp.MustCompile(`(^|[^\w.,\-/:])([-+]?(?:\d{1,3}(?:,\d{3})+|\d+)(?:\.\d+)?)(\s?%|[kKM]\b)?`)
+
+var sentenceEndRegex = regexp.MustCompile(`[.!?;](\s|$)|\n`)
+
+// NumericClaim is a number stated in a message, with its position in the message
+type NumericClaim struct {
+	Text  string  // The number as written, e.g. 1,200 or 12%
+	Value float64 // The value of the number, with the k and M multipliers applied
+	Start int
+	End   int
+}
+
+// ExtractNumericClaim extracts the numeric claim made about the link at the given index of the spans of a message.
+// The link text is the claim when it is a number, e.g. [12%](url), otherwise the claim is the number closest to the link
+// in the same sentence, not crossing other links. Numbers in the label of the data point, e.g. years, are not claims.
+func ExtractNumericClaim(message string, spans []link.Span, index int, label string) (NumericClaim, bool) {
+	labelNumbers := map[float64]bool{}
+	for _, claim := range findNumericClaims(label, 0) {
+		labelNumbers[claim.Value] = true
+	}
+	span := spans[index]
+	if claims := findNumericClaims(span.Text, 0); len(claims) == 1 && strings.TrimSpace(span.Text) == claims[0].Text && !labelNumbers[claims[0].Value] {
+		return claims[0], true
+	}
+
+	start := 0
+	if index > 0 {
+		start = spans[index-1].End
+	}
+	start = sentenceStart(message, start, span.Start)
+	end := len(message)
+	if index < len(spans)-1 {
+		end = spans[index+1].Start
+	}
+	if match := sentenceEndRegex.FindStringIndex(message[span.End:end]); match != nil {
+		end = span.End + match[0]
+	}
+
+	var closest NumericClaim
+	found := false
+	closestDistance := math.MaxInt
+	candidates := append(findNumericClaims(message[start:span.Start], start), findNumericClaims(message[span.End:end], span.End)...)
+	for _, claim := range candidates {
+		if labelNumbers[claim.Value] {
+			continue
+		}
+		distance := claim.Start - span.End
+		if claim.End <= span.Start {
+			distance = span.Start - claim.End
+		}
+		if distance < closestDistance {
+			closest, closestDistance, found = claim, distance, true
+		}
+	}
+	return closest, found
+}
+
+// Gets the position of the start of the sentence containing the given position, not going back further than the given limit
+func sentenceStart(message string, limit, position int) int {
+	start := limit
+	for _, match := range sentenceEndRegex.FindAllStringIndex(message[limit:position], -1) {
+		start = limit + match[1]
+	}
+	return start
+}
+
+func findNumericClaims(text string, offset int) []NumericClaim {
+	claims := []NumericClaim{}
+	for _, match := range numericClaimRegex.FindAllStringSubmatchIndex(text, -1) {
+		end := match[1]
+		if end < len(text) && isWordCharacter(text[end]) {
+			continue
+		}
+		// Dates and times, e.g. 2023-01-05, 1/2 or 10:30
+		if end+1 < len(text) && strings.IndexByte("-/:", text[end]) != -1 && '0' <= text[end+1] && text[end+1] <= '9' {
+			continue
+		}
+		number := text[match[4]:match[5]]
+		value, err := strconv.ParseFloat(strings.ReplaceAll(number, ",", ""), 64)
+		if err != nil {
+			continue
+		}
+		if match[6] != -1 {
+			switch strings.TrimSpace(text[match[6]:match[7]]) {
+			case "k", "K":
+				value *= 1e3
+			case "M":
+				value *= 1e6
+			}
+		}
+		claims = append(claims, NumericClaim{
+			Text:  text[match[4]:end],
+			Value: value,
+			Start: offset + match[4],
+			End:   offset + end,
+		})
+	}
+	return claims
+}
+
+// MatchNumericClaim tells whether a claimed number matches one of the values of a data point, within a tolerance relative to the value.
+// Percentages also match values expressed as fractions, e.g. 12% matches 0.12. Returns the closest value.
+func MatchNumericClaim(claim NumericClaim, values []float64, tolerance float64) (float64, bool) {
+	closest := math.NaN()
+	for _, value := range values {
+		candidates := []float64{claim.Value}
+		if strings.HasSuffix(claim.Text, "%") {
+			candidates = append(candidates, claim.Value/100)
+		}
+		for _, claimed := range candidates {
+			if math.Abs(claimed-value) <= tolerance*math.Abs(value) {
+				return value, true
+			}
+		}
+		if math.IsNaN(closest) || math.Abs(claim.Value-value) < math.Abs(claim.Value-closest) {
+			closest = value
+		}
+	}
+	return closest, false
+}
+
+func isWordCharacter(character byte) bool {
+	return character == '_' || ('0' <= character && character <= '9') || ('a' <= character && character <= 'z') || ('A' <= character && character <= 'Z')
+}
@@ -0,0 +1,214 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/mattermost/mattermost-server/v6/plugin"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+// MisalignmentService checks the numbers stated by posts against the chart data points they link, using local rules only:
+// the number closest to a link in the same sentence is compared with the value served by the provider for the data point.
+// Posts stating numbers out of tolerance are flagged with a reply of the bot in their thread, leaving the posts untouched.
+type MisalignmentService struct {
+	api            plugin.API
+	configuration  *config.MattermostConfig
+	linkService    *LinkService
+	providerClient *link.ProviderClient
+	botID          string
+}
+
+// NewMisalignmentService returns a new service to detect misalignments between posts and the data they link
+func NewMisalignmentService(api plugin.API, configuration *config.MattermostConfig, linkService *LinkService, providerClient *link.ProviderClient, botID string) *MisalignmentService {
+	return &MisalignmentService{
+		api:            api,
+		configuration:  configuration,
+		linkService:    linkService,
+		providerClient: providerClient,
+		botID:          botID,
+	}
+}
+
+// Followed by the post id, lists the misalignments flagged for a post so that edits only get replies about new ones
+const postMisalignmentsKeyPrefix = "post_misalignments_"
+
+// Checks a post that has just been posted or edited, recording its misalignments if they changed
+// and replying in its thread about the misalignments that were not flagged yet.
+func (s *MisalignmentService) CheckPost(post *mattermost.Post) {
+	configuration := s.configuration.GetConfiguration()
+	if !configuration.MisalignmentDetection || post.Type != "" || post.UserId == s.botID {
+		return
+	}
+	tolerance := 0.0
+	if configuration.MisalignmentTolerance > 0 {
+		tolerance = float64(configuration.MisalignmentTolerance) / 100
+	}
+	misalignments, err := s.findMisalignments(post.Message, tolerance)
+	if err != nil {
+		s.api.LogWarn("Unable to check post for misalignments", "postId", post.Id, "err", err)
+		return
+	}
+
+	flagged := []Misalignment{}
+	if err := kvGetJSON(s.api, postMisalignmentsKeyPrefix+post.Id, &flagged); err != nil {
+		s.api.LogWarn("Unable to get flagged misalignments of post", "postId", post.Id, "err", err)
+		return
+	}
+	if sameMisalignments(flagged, misalignments) {
+		return
+	}
+	if err := s.setFlaggedMisalignments(post.Id, misalignments); err != nil {
+		s.api.LogWarn("Unable to flag misalignments of post", "postId", post.Id, "err", err)
+		return
+	}
+
+	newMisalignments := []Misalignment{}
+	for _, misalignment := range misalignments {
+		if !containsMisalignment(flagged, misalignment) {
+			newMisalignments = append(newMisalignments, misalignment)
+		}
+	}
+	if len(newMisalignments) == 0 {
+		return
+	}
+	rootID := post.RootId
+	if rootID == "" {
+		rootID = post.Id
+	}
+	reply := &mattermost.Post{
+		UserId:    s.botID,
+		ChannelId: post.ChannelId,
+		RootId:    rootID,
+		Message:   formatMisalignments(newMisalignments),
+	}
+	reply.AddProp(MisalignmentsProp, newMisalignments)
+	if _, appErr := s.api.CreatePost(reply); appErr != nil {
+		s.api.LogWarn("Unable to reply about misalignments of post", "postId", post.Id, "err", appErr)
+	}
+	s.api.LogInfo("Flagged misalignments of post", "postId", post.Id, "misalignments", len(newMisalignments))
+}
+
+// Finds the numbers stated next to the links to bar cells and line dots of a message that do not match their values
+func (s *MisalignmentService) findMisalignments(message string, tolerance float64) ([]Misalignment, error) {
+	spans := link.ExtractSpans(message)
+	if len(spans) == 0 {
+		return []Misalignment{}, nil
+	}
+	parser, resolver, err := s.linkService.GetLinkModel()
+	if err != nil {
+		return nil, err
+	}
+
+	misalignments := []Misalignment{}
+	for i, span := range spans {
+		reference, ok := parser.Parse(span.URL)
+		if !ok || reference.Fragment == nil {
+			continue
+		}
+		reference = resolver.Canonicalize(reference)
+		label, values := s.getDataPointValues(resolver, reference)
+		if len(values) == 0 {
+			continue
+		}
+		claim, found := ExtractNumericClaim(message, spans, i, label)
+		if !found {
+			continue
+		}
+		actual, matches := MatchNumericClaim(claim, values, tolerance)
+		if matches {
+			continue
+		}
+		name, err := resolver.ResolveName(reference.Key())
+		if err != nil {
+			name = reference.Fragment.String()
+		}
+		misalignment := Misalignment{URL: span.URL, Name: name, Claim: claim.Text, Claimed: claim.Value, Actual: actual}
+		if !containsMisalignment(misalignments, misalignment) {
+			misalignments = append(misalignments, misalignment)
+		}
+	}
+	return misalignments, nil
+}
+
+// Gets the label and the values of the data point referenced by a bar cell or a line dot, as served by the provider.
+// The value in the hash of line dots is not trusted, since anyone can write it, so all the series at their label are fetched.
+func (s *MisalignmentService) getDataPointValues(resolver *link.Resolver, reference link.Reference) (string, []float64) {
+	section, found := resolver.FindSection(reference.OrganizationID, reference.SectionID)
+	if !found {
+		return "", nil
+	}
+	var dataPoint map[string]interface{}
+	switch reference.Fragment.Type {
+	case link.FragmentLineDot:
+		dataPoint, found = fetchLine(s.providerClient, section, reference.ElementID, reference.Fragment.Label)
+	case link.FragmentBarCell:
+		index, err := strconv.Atoi(reference.Fragment.ID)
+		if err != nil {
+			return "", nil
+		}
+		dataPoint, found = fetchBar(s.providerClient, section, reference.ElementID, index)
+	default:
+		return "", nil
+	}
+	if !found {
+		return "", nil
+	}
+	values := []float64{}
+	for key, value := range dataPoint {
+		if number, ok := value.(float64); ok && key != "label" {
+			values = append(values, number)
+		}
+	}
+	return fmt.Sprint(dataPoint["label"]), values
+}
+
+func (s *MisalignmentService) setFlaggedMisalignments(postID string, misalignments []Misalignment) error {
+	if len(misalignments) == 0 {
+		if appErr := s.api.KVDelete(postMisalignmentsKeyPrefix + postID); appErr != nil {
+			return appErr
+		}
+		return nil
+	}
+	return kvSetJSON(s.api, postMisalignmentsKeyPrefix+postID, misalignments)
+}
+
+func sameMisalignments(misalignments, otherMisalignments []Misalignment) bool {
+	if len(misalignments) != len(otherMisalignments) {
+		return false
+	}
+	for _, misalignment := range misalignments {
+		if !containsMisalignment(otherMisalignments, misalignment) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsMisalignment(misalignments []Misalignment, misalignment Misalignment) bool {
+	for _, existing := range misalignments {
+		if existing.URL == misalignment.URL && existing.Claim == misalignment.Claim && existing.Actual == misalignment.Actual {
+			return true
+		}
+	}
+	return false
+}
+
+// Elements are named rather than linked, so that the reply does not count as a backlink to them
+func formatMisalignments(misalignments []Misalignment) string {
+	var builder strings.Builder
+	builder.WriteString("This post may not match the data it links:")
+	for _, misalignment := range misalignments {
+		builder.WriteString(fmt.Sprintf(
+			"\n- %s: the post says **%s**, the data says **%s**.",
+			misalignment.Name,
+			misalignment.Claim,
+			strconv.FormatFloat(misalignment.Actual, 'f', -1, 64),
+		))
+	}
+	return builder.String()
+}
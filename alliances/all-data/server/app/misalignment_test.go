@@ -0,0 +1,119 @@
+package app_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+const dataPointURL = "http://localhost:8065/alliances/organizations/1/stories/7#cell-2-7"
+
+// Tests the extraction of the numbers stated next to the links of a message.
+func TestExtractNumericClaim(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		label    string
+		expected string
+	}{
+		{
+			name:     "number before the link",
+			message:  "Attacks grew by 12% last month, see [the chart](" + dataPointURL + ").",
+			expected: "12%",
+		},
+		{
+			name:     "number after the link",
+			message:  "As [the chart](" + dataPointURL + ") shows, we had 1,200 incidents.",
+			expected: "1,200",
+		},
+		{
+			name:     "link text",
+			message:  "Incidents were [3.5k](" + dataPointURL + ") in 2023.",
+			label:    "2023",
+			expected: "3.5k",
+		},
+		{
+			name:     "closest number",
+			message:  "From 10 to 40 incidents in [March](" + dataPointURL + ").",
+			expected: "40",
+		},
+		{
+			name:     "numbers in the label",
+			message:  "In 2023 there were 40 incidents, see [2023](" + dataPointURL + ").",
+			label:    "2023",
+			expected: "40",
+		},
+		{
+			name:     "other sentences",
+			message:  "We had 40 incidents. Details in [the chart](" + dataPointURL + "). Fixed 3 of them",
+			expected: "",
+		},
+		{
+			name:     "digits in words and dates",
+			message:  "Q1 and CVE-2023-1234 on 2023-01-05 in [the chart](" + dataPointURL + ")",
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spans := link.ExtractSpans(test.message)
+			require.Len(t, spans, 1)
+			claim, found := app.ExtractNumericClaim(test.message, spans, 0, test.label)
+			assert.Equal(t, test.expected != "", found)
+			assert.Equal(t, test.expected, claim.Text)
+		})
+	}
+}
+
+// Tests the comparison of the stated numbers with the values of the data points.
+func TestMatchNumericClaim(t *testing.T) {
+	tests := []struct {
+		name     string
+		claim    app.NumericClaim
+		values   []float64
+		actual   float64
+		expected bool
+	}{
+		{
+			name:     "within tolerance",
+			claim:    app.NumericClaim{Text: "102", Value: 102},
+			values:   []float64{100},
+			actual:   100,
+			expected: true,
+		},
+		{
+			name:     "out of tolerance",
+			claim:    app.NumericClaim{Text: "120", Value: 120},
+			values:   []float64{100},
+			actual:   100,
+			expected: false,
+		},
+		{
+			name:     "percentage of a fraction",
+			claim:    app.NumericClaim{Text: "12%", Value: 12},
+			values:   []float64{0.12},
+			actual:   0.12,
+			expected: true,
+		},
+		{
+			name:     "closest series",
+			claim:    app.NumericClaim{Text: "30", Value: 30},
+			values:   []float64{10, 25, 60},
+			actual:   25,
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, matches := app.MatchNumericClaim(test.claim, test.values, 0.05)
+			assert.Equal(t, test.expected, matches)
+			assert.Equal(t, test.actual, actual)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"fmt"
+	"io"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+)
+
+// PDF exports the channel as a printable transcript, with replies indented below their thread root.
+type PDF struct{}
+
+type pdfWriter struct {
+	document *pdfDocument
+	export   *ChannelExport
+}
+
+func (e *PDF) FileName(name string) string {
+	return fmt.Sprintf("%s.pdf", name)
+}
+
+func (e *PDF) ContentType() string {
+	return "application/pdf"
+}
+
+func (e *PDF) NewWriter(w io.Writer, export *ChannelExport) (ExportWriter, error) {
+	document, err := newPDFDocument(w, export.Channel.DisplayName, export.ExportedAt)
+	if err != nil {
+		return nil, err
+	}
+	texts := []string{export.Channel.Header, export.Channel.Purpose, fmt.Sprintf("Exported on %s", exportTime(export.ExportedAt))}
+	if err := document.AddText(export.Channel.DisplayName, true, 0); err != nil {
+		return nil, err
+	}
+	for _, text := range texts {
+		if text == "" {
+			continue
+		}
+		if err := document.AddText(text, false, 0); err != nil {
+			return nil, err
+		}
+	}
+	return &pdfWriter{document: document, export: export}, nil
+}
+
+func (w *pdfWriter) WriteThread(thread *ExportThread) error {
+	if err := w.document.AddEmptyLine(); err != nil {
+		return err
+	}
+	if err := w.addPost(thread.Root, 0); err != nil {
+		return err
+	}
+	for _, reply := range thread.Replies {
+		if err := w.document.AddEmptyLine(); err != nil {
+			return err
+		}
+		if err := w.addPost(reply, 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *pdfWriter) Close() error {
+	return w.document.Close()
+}
+
+func (w *pdfWriter) addPost(post *mattermost.Post, indent int) error {
+	pinned := ""
+	if post.IsPinned {
+		pinned = " (pinned)"
+	}
+	texts := []string{post.Message}
+	for _, fileID := range post.FileIds {
+		if fileLink, found := w.export.FileLinks[fileID]; found {
+			texts = append(texts, fmt.Sprintf("Attachment: %s", fileLink))
+		}
+	}
+	for _, backlink := range w.export.Backlinks[post.Id] {
+		texts = append(texts, fmt.Sprintf("Linked element: %s (%s)", backlink.Name, backlink.URL))
+	}
+
+	if err := w.document.AddText(fmt.Sprintf("%s - %s%s", w.export.UserName(post.UserId), exportTime(post.CreateAt), pinned), true, indent); err != nil {
+		return err
+	}
+	for _, text := range texts {
+		if err := w.document.AddText(text, false, indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Layout of the A4 pages written by pdfDocument, in points. Text uses the Courier standard fonts, whose glyphs
+// are all 0.6 em wide, so lines can be wrapped by counting characters without embedding any font metrics.
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 10
+	pdfLineHeight   = 12
+	pdfCharsPerLine = (pdfPageWidth - 2*pdfMargin) * 10 / (6 * pdfFontSize)
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+	pdfRegularFont  = "F1"
+	pdfBoldFont     = "F2"
+	pdfIndent       = "    "
+)
+
+// Characters of the Windows-1252 encoding used by the standard fonts which differ from Latin-1
+var pdfWinAnsiRunes = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88, '‰': 0x89, 'Š': 0x8A,
+	'‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B, 'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+type pdfLine struct {
+	text   string
+	bold   bool
+	indent int
+}
+
+// pdfDocument is a minimal writer of text-only PDF documents, writing each page as soon as it is full.
+type pdfDocument struct {
+	w       io.Writer
+	written int
+	offsets map[int]int // Offsets of the objects in the output, by object number
+	pages   []int       // Object numbers of the pages written so far
+	lines   []pdfLine   // Lines of the current page
+}
+
+// Objects 1 to 5 are the catalog, the pages tree, the fonts and the info, followed by a page and its content for each page.
+// The pages tree is written last, once all the pages are known.
+func newPDFDocument(w io.Writer, title string, createdAt int64) (*pdfDocument, error) {
+	document := &pdfDocument{w: w, offsets: map[int]int{}, pages: []int{}, lines: []pdfLine{}}
+	if err := document.write("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"); err != nil {
+		return nil, err
+	}
+	objects := map[int]string{
+		1: "<< /Type /Catalog /Pages 2 0 R >>",
+		3: "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		4: "<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+		5: fmt.Sprintf("<< /Title %s /Producer (HOOD) /CreationDate (D:%s) >>", pdfString(title), time.UnixMilli(createdAt).UTC().Format("20060102150405Z")),
+	}
+	for _, number := range []int{1, 3, 4, 5} {
+		if err := document.writeObject(number, objects[number]); err != nil {
+			return nil, err
+		}
+	}
+	return document, nil
+}
+
+// AddText adds a paragraph, wrapping it to the width of the page
+func (d *pdfDocument) AddText(text string, bold bool, indent int) error {
+	width := pdfCharsPerLine - indent*len(pdfIndent)
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		for _, line := range wrapPDFLine(strings.ReplaceAll(paragraph, "\t", pdfIndent), width) {
+			if err := d.addLine(pdfLine{text: line, bold: bold, indent: indent}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *pdfDocument) AddEmptyLine() error {
+	return d.addLine(pdfLine{})
+}
+
+// Close writes the last page and the document trailer
+func (d *pdfDocument) Close() error {
+	if len(d.lines) > 0 || len(d.pages) == 0 {
+		if err := d.writePage(); err != nil {
+			return err
+		}
+	}
+	kids := make([]string, 0, len(d.pages))
+	for _, page := range d.pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+	}
+	if err := d.writeObject(2, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages))); err != nil {
+		return err
+	}
+
+	size := len(d.offsets) + 1
+	xrefOffset := d.written
+	var xref strings.Builder
+	fmt.Fprintf(&xref, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for number := 1; number < size; number++ {
+		fmt.Fprintf(&xref, "%010d 00000 n \n", d.offsets[number])
+	}
+	fmt.Fprintf(&xref, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", size, xrefOffset)
+	return d.write(xref.String())
+}
+
+func (d *pdfDocument) addLine(line pdfLine) error {
+	d.lines = append(d.lines, line)
+	if len(d.lines) < pdfLinesPerPage {
+		return nil
+	}
+	return d.writePage()
+}
+
+func (d *pdfDocument) writePage() error {
+	pageNumber := 6 + 2*len(d.pages)
+	if err := d.writeObject(pageNumber, fmt.Sprintf(
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+		pdfPageWidth, pdfPageHeight, pdfRegularFont, pdfBoldFont, pageNumber+1,
+	)); err != nil {
+		return err
+	}
+	if err := d.writeObject(pageNumber+1, pdfStream(pdfPageContent(d.lines))); err != nil {
+		return err
+	}
+	d.pages = append(d.pages, pageNumber)
+	d.lines = []pdfLine{}
+	return nil
+}
+
+func (d *pdfDocument) writeObject(number int, object string) error {
+	d.offsets[number] = d.written
+	return d.write(fmt.Sprintf("%d 0 obj\n%s\nendobj\n", number, object))
+}
+
+func (d *pdfDocument) write(data string) error {
+	n, err := io.WriteString(d.w, data)
+	d.written += n
+	return err
+}
+
+func pdfPageContent(lines []pdfLine) string {
+	var content strings.Builder
+	for i, line := range lines {
+		if line.text == "" {
+			continue
+		}
+		font := pdfRegularFont
+		if line.bold {
+			font = pdfBoldFont
+		}
+		x := pdfMargin + line.indent*len(pdfIndent)*6*pdfFontSize/10
+		y := pdfPageHeight - pdfMargin - (i+1)*pdfLineHeight
+		fmt.Fprintf(&content, "BT /%s %d Tf %d %d Td %s Tj ET\n", font, pdfFontSize, x, y, pdfString(line.text))
+	}
+	return content.String()
+}
+
+func pdfStream(content string) string {
+	return fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content)
+}
+
+// Encodes a string as a PDF literal string in the Windows-1252 encoding, replacing unsupported characters
+func pdfString(text string) string {
+	var encoded bytes.Buffer
+	encoded.WriteByte('(')
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			encoded.WriteByte('\\')
+			encoded.WriteRune(r)
+		case r >= 0x20 && r < 0x7F:
+			encoded.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&encoded, "\\%03o", r)
+		default:
+			if b, found := pdfWinAnsiRunes[r]; found {
+				fmt.Fprintf(&encoded, "\\%03o", b)
+			} else {
+				encoded.WriteByte('?')
+			}
+		}
+	}
+	encoded.WriteByte(')')
+	return encoded.String()
+}
+
+// Wraps a line at word boundaries, breaking words longer than the width
+func wrapPDFLine(line string, width int) []string {
+	if utf8.RuneCountInString(line) <= width {
+		return []string{line}
+	}
+	lines := []string{}
+	current := []rune{}
+	for _, word := range strings.SplitAfter(line, " ") {
+		wordRunes := []rune(word)
+		if len(current)+len(strings.TrimRight(word, " ")) > width && len(current) > 0 {
+			lines = append(lines, strings.TrimRight(string(current), " "))
+			current = []rune{}
+		}
+		for len(wordRunes) > width {
+			lines = append(lines, string(wordRunes[:width]))
+			wordRunes = wordRunes[width:]
+		}
+		current = append(current, wordRunes...)
+	}
+	if len(current) > 0 {
+		lines = append(lines, strings.TrimRight(string(current), " "))
+	}
+	return lines
+}
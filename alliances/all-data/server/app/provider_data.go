@@ -0,0 +1,72 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+const (
+	chartWidgetType = "chart"
+	graphWidgetType = "graph"
+	widgetIDToken   = ":id"
+)
+
+type sectionElement struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Gets the elements listed by a section, which providers serve as the rows of a paginated table.
+// Sections serving anything else have no elements.
+func fetchSectionElements(providerClient *link.ProviderClient, sectionURL string) ([]sectionElement, error) {
+	var table struct {
+		Rows []sectionElement `json:"rows"`
+	}
+	if err := providerClient.FetchJSON(sectionURL, &table); err != nil {
+		return nil, err
+	}
+	return table.Rows, nil
+}
+
+// Gets the bar at an index of the first chart of a section having it, with its label and the value of each series
+func fetchBar(providerClient *link.ProviderClient, section *config.Section, elementID string, index int) (map[string]interface{}, bool) {
+	for _, widget := range section.Widgets {
+		if widget.Type != chartWidgetType {
+			continue
+		}
+		var chart struct {
+			BarData []map[string]interface{} `json:"barData"`
+		}
+		chartURL := strings.ReplaceAll(widget.URL, widgetIDToken, elementID)
+		if err := providerClient.FetchJSON(chartURL, &chart); err != nil || index < 0 || index >= len(chart.BarData) {
+			continue
+		}
+		return chart.BarData[index], true
+	}
+	return nil, false
+}
+
+// Gets the line of a label in the first chart of a section having it, with the value of each series at that label
+func fetchLine(providerClient *link.ProviderClient, section *config.Section, elementID, label string) (map[string]interface{}, bool) {
+	for _, widget := range section.Widgets {
+		if widget.Type != chartWidgetType {
+			continue
+		}
+		var chart struct {
+			LineData []map[string]interface{} `json:"lineData"`
+		}
+		chartURL := strings.ReplaceAll(widget.URL, widgetIDToken, elementID)
+		if err := providerClient.FetchJSON(chartURL, &chart); err != nil {
+			continue
+		}
+		for _, line := range chart.LineData {
+			if fmt.Sprint(line["label"]) == label {
+				return line, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+)
+
+const (
+	pinnedIssuesRuleID              = "pinned_issues"
+	defaultPinnedIssuesCategoryName = "Pinned issues"
+)
+
+// categoryKey identifies a category owned by the plugin: the main category of an organization has an empty rule id
+type categoryKey struct {
+	organizationID string
+	ruleID         string
+}
+
+// categoryTarget is a category a user should have according to the sidebar rules, with the channels belonging to it
+type categoryTarget struct {
+	key        categoryKey
+	name       string
+	sorting    string
+	collapsed  bool
+	channelIDs []string // In config order
+}
+
+// Builds the categories for the given organizations and the channels of the user, in a stable order: the main category
+// of each organization, then the section categories and the pinned issues one. Each channel belongs to a single category,
+// preferring the pinned issues, then the section categories, over the main category of its organization.
+// Categories of section rules are only built when they have channels.
+func buildCategoryTargets(platformConfig *config.PlatformConfig, organizations []config.Organization, orgChannels []Channel) []*categoryTarget {
+	sidebarConfig := platformConfig.SidebarConfig
+	sectionOrder := map[string]int{}
+	issuesSectionIDs := map[string]bool{}
+	for _, organization := range platformConfig.Organizations {
+		indexSections(organization.Sections, organization.IsEcosystem, sectionOrder, issuesSectionIDs)
+	}
+	ruleIndexBySection := map[string]int{}
+	for i, rule := range sidebarConfig.SectionCategories {
+		for _, sectionID := range rule.SectionIDs {
+			if _, found := ruleIndexBySection[sectionID]; !found {
+				ruleIndexBySection[sectionID] = i
+			}
+		}
+	}
+
+	targets := []*categoryTarget{}
+	targetsByKey := map[categoryKey]*categoryTarget{}
+	addTarget := func(target *categoryTarget) {
+		targets = append(targets, target)
+		targetsByKey[target.key] = target
+	}
+	isEcosystem := map[string]bool{}
+	for _, organization := range organizations {
+		isEcosystem[organization.ID] = organization.IsEcosystem
+		addTarget(&categoryTarget{
+			key:       categoryKey{organizationID: organization.ID},
+			name:      organization.Name,
+			sorting:   sidebarConfig.Sorting,
+			collapsed: sidebarConfig.Collapsed,
+		})
+	}
+
+	var ruleTargets []*categoryTarget
+	var pinnedIssuesTarget *categoryTarget
+	channels := append([]Channel{}, orgChannels...)
+	sort.SliceStable(channels, func(i, j int) bool {
+		return sectionPosition(sectionOrder, channels[i].ParentID) < sectionPosition(sectionOrder, channels[j].ParentID)
+	})
+	for _, channel := range channels {
+		mainTarget, found := targetsByKey[categoryKey{organizationID: channel.OrganizationID}]
+		if !found {
+			continue
+		}
+		if sidebarConfig.PinnedIssues.Enabled && isEcosystem[channel.OrganizationID] && issuesSectionIDs[channel.ParentID] {
+			if pinnedIssuesTarget == nil {
+				pinnedIssuesTarget = newPinnedIssuesTarget(sidebarConfig.PinnedIssues, channel.OrganizationID)
+			}
+			pinnedIssuesTarget.channelIDs = append(pinnedIssuesTarget.channelIDs, channel.ChannelID)
+			continue
+		}
+		if ruleIndex, found := ruleIndexBySection[channel.ParentID]; found {
+			rule := sidebarConfig.SectionCategories[ruleIndex]
+			key := categoryKey{organizationID: channel.OrganizationID, ruleID: rule.ID}
+			ruleTarget, found := targetsByKey[key]
+			if !found {
+				ruleTarget = &categoryTarget{
+					key:       key,
+					name:      sectionCategoryName(mainTarget.name, rule.Name),
+					sorting:   rule.Sorting,
+					collapsed: rule.Collapsed,
+				}
+				targetsByKey[key] = ruleTarget
+				ruleTargets = append(ruleTargets, ruleTarget)
+			}
+			ruleTarget.channelIDs = append(ruleTarget.channelIDs, channel.ChannelID)
+			continue
+		}
+		mainTarget.channelIDs = append(mainTarget.channelIDs, channel.ChannelID)
+	}
+
+	targets = append(targets, ruleTargets...)
+	if pinnedIssuesTarget != nil {
+		targets = append(targets, pinnedIssuesTarget)
+	}
+	return targets
+}
+
+// Prefixes the name of a section category with its organization, since a rule builds a category for each organization
+func sectionCategoryName(organizationName, ruleName string) string {
+	return fmt.Sprintf("%s - %s", organizationName, ruleName)
+}
+
+func newPinnedIssuesTarget(pinnedIssues config.PinnedIssuesCategory, ecosystemID string) *categoryTarget {
+	name := pinnedIssues.Name
+	if name == "" {
+		name = defaultPinnedIssuesCategoryName
+	}
+	return &categoryTarget{
+		key:       categoryKey{organizationID: ecosystemID, ruleID: pinnedIssuesRuleID},
+		name:      name,
+		sorting:   pinnedIssues.Sorting,
+		collapsed: pinnedIssues.Collapsed,
+	}
+}
+
+// Numbers the sections depth first, following the config, and collects the issues sections of the ecosystem
+func indexSections(sections []config.Section, isEcosystem bool, sectionOrder map[string]int, issuesSectionIDs map[string]bool) {
+	for _, section := range sections {
+		if _, found := sectionOrder[section.ID]; !found {
+			sectionOrder[section.ID] = len(sectionOrder)
+		}
+		if isEcosystem && section.IsIssues {
+			issuesSectionIDs[section.ID] = true
+		}
+		indexSections(section.Sections, isEcosystem, sectionOrder, issuesSectionIDs)
+	}
+}
+
+// Channels of sections missing from the config go last
+func sectionPosition(sectionOrder map[string]int, sectionID string) int {
+	if position, found := sectionOrder[sectionID]; found {
+		return position
+	}
+	return len(sectionOrder)
+}
+
+func toCategorySorting(sorting string) model.SidebarCategorySorting {
+	switch sorting {
+	case config.SidebarSortingAlphabetical:
+		return model.SidebarCategorySortAlphabetical
+	case config.SidebarSortingRecent:
+		return model.SidebarCategorySortRecent
+	case config.SidebarSortingConfig:
+		return model.SidebarCategorySortManual
+	default:
+		return model.SidebarCategorySortDefault
+	}
+}
+
+// Orders the channels of a category following the config, leaving those added by the user after them
+func orderChannels(category *model.SidebarCategoryWithChannels, channelIDs []string) {
+	inCategory := make(map[string]bool, len(category.Channels))
+	for _, channelID := range category.Channels {
+		inCategory[channelID] = true
+	}
+	isTarget := make(map[string]bool, len(channelIDs))
+	ordered := make([]string, 0, len(category.Channels))
+	for _, channelID := range channelIDs {
+		isTarget[channelID] = true
+		if inCategory[channelID] {
+			ordered = append(ordered, channelID)
+		}
+	}
+	for _, channelID := range category.Channels {
+		if !isTarget[channelID] {
+			ordered = append(ordered, channelID)
+		}
+	}
+	category.Channels = ordered
+}
@@ -0,0 +1,151 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+)
+
+func newSidebarPlatformConfig(sidebarConfig config.SidebarConfig) *config.PlatformConfig {
+	return &config.PlatformConfig{
+		SidebarConfig: sidebarConfig,
+		Organizations: []config.Organization{
+			{
+				ID:          "eco",
+				Name:        "Ecosystem",
+				IsEcosystem: true,
+				Sections:    []config.Section{{ID: "issues", Name: "Issues", IsIssues: true}},
+			},
+			{
+				ID:   "org",
+				Name: "Organization",
+				Sections: []config.Section{
+					{ID: "incidents", Name: "Incidents"},
+					{ID: "policies", Name: "Policies", Sections: []config.Section{{ID: "drafts", Name: "Drafts"}}},
+				},
+			},
+		},
+	}
+}
+
+// Summary of a category target, to compare targets regardless of their unexported fields
+type categoryTargetSummary struct {
+	key        categoryKey
+	name       string
+	channelIDs []string
+}
+
+func summarizeCategoryTargets(targets []*categoryTarget) []categoryTargetSummary {
+	summaries := []categoryTargetSummary{}
+	for _, target := range targets {
+		summaries = append(summaries, categoryTargetSummary{key: target.key, name: target.name, channelIDs: target.channelIDs})
+	}
+	return summaries
+}
+
+// Tests for the categories built from the sidebar rules.
+func TestBuildCategoryTargets(t *testing.T) {
+	channels := []Channel{
+		{ChannelID: "draft", OrganizationID: "org", ParentID: "drafts"},
+		{ChannelID: "issue", OrganizationID: "eco", ParentID: "issues"},
+		{ChannelID: "policy", OrganizationID: "org", ParentID: "policies"},
+		{ChannelID: "incident", OrganizationID: "org", ParentID: "incidents"},
+		{ChannelID: "unknown", OrganizationID: "org", ParentID: "missing"},
+		{ChannelID: "other", OrganizationID: "other", ParentID: "incidents"},
+	}
+	ecosystemKey := categoryKey{organizationID: "eco"}
+	orgKey := categoryKey{organizationID: "org"}
+
+	tests := []struct {
+		name          string
+		sidebarConfig config.SidebarConfig
+		expected      []categoryTargetSummary
+	}{
+		{
+			name: "organization categories only, in config order",
+			expected: []categoryTargetSummary{
+				{key: ecosystemKey, name: "Ecosystem", channelIDs: []string{"issue"}},
+				{key: orgKey, name: "Organization", channelIDs: []string{"incident", "policy", "draft", "unknown"}},
+			},
+		},
+		{
+			name: "section categories take their sections, including nested ones only if listed",
+			sidebarConfig: config.SidebarConfig{
+				SectionCategories: []config.SectionCategory{
+					{ID: "docs", Name: "Docs", SectionIDs: []string{"policies", "drafts"}},
+					{ID: "empty", Name: "Empty", SectionIDs: []string{"nothing"}},
+				},
+			},
+			expected: []categoryTargetSummary{
+				{key: ecosystemKey, name: "Ecosystem", channelIDs: []string{"issue"}},
+				{key: orgKey, name: "Organization", channelIDs: []string{"incident", "unknown"}},
+				{key: categoryKey{organizationID: "org", ruleID: "docs"}, name: "Organization - Docs", channelIDs: []string{"policy", "draft"}},
+			},
+		},
+		{
+			name: "sections listed by many rules belong to the first one",
+			sidebarConfig: config.SidebarConfig{
+				SectionCategories: []config.SectionCategory{
+					{ID: "first", Name: "First", SectionIDs: []string{"incidents"}},
+					{ID: "second", Name: "Second", SectionIDs: []string{"incidents", "policies"}},
+				},
+			},
+			expected: []categoryTargetSummary{
+				{key: ecosystemKey, name: "Ecosystem", channelIDs: []string{"issue"}},
+				{key: orgKey, name: "Organization", channelIDs: []string{"draft", "unknown"}},
+				{key: categoryKey{organizationID: "org", ruleID: "first"}, name: "Organization - First", channelIDs: []string{"incident"}},
+				{key: categoryKey{organizationID: "org", ruleID: "second"}, name: "Organization - Second", channelIDs: []string{"policy"}},
+			},
+		},
+		{
+			name: "pinned issues are preferred over section categories",
+			sidebarConfig: config.SidebarConfig{
+				SectionCategories: []config.SectionCategory{{ID: "issues", Name: "Issues", SectionIDs: []string{"issues"}}},
+				PinnedIssues:      config.PinnedIssuesCategory{Enabled: true},
+			},
+			expected: []categoryTargetSummary{
+				{key: ecosystemKey, name: "Ecosystem", channelIDs: nil},
+				{key: orgKey, name: "Organization", channelIDs: []string{"incident", "policy", "draft", "unknown"}},
+				{key: categoryKey{organizationID: "eco", ruleID: pinnedIssuesRuleID}, name: defaultPinnedIssuesCategoryName, channelIDs: []string{"issue"}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			platformConfig := newSidebarPlatformConfig(test.sidebarConfig)
+			targets := buildCategoryTargets(platformConfig, platformConfig.Organizations, channels)
+			assert.Equal(t, test.expected, summarizeCategoryTargets(targets))
+		})
+	}
+}
+
+// Tests that only the categories of the given organizations are built, with their sorting and collapsed defaults.
+func TestBuildCategoryTargetsOfUserOrganizations(t *testing.T) {
+	platformConfig := newSidebarPlatformConfig(config.SidebarConfig{
+		Sorting:   config.SidebarSortingAlphabetical,
+		Collapsed: true,
+		SectionCategories: []config.SectionCategory{
+			{ID: "docs", Name: "Docs", SectionIDs: []string{"policies"}, Sorting: config.SidebarSortingRecent},
+		},
+	})
+	channels := []Channel{
+		{ChannelID: "issue", OrganizationID: "eco", ParentID: "issues"},
+		{ChannelID: "policy", OrganizationID: "org", ParentID: "policies"},
+	}
+
+	targets := buildCategoryTargets(platformConfig, platformConfig.Organizations[:1], channels)
+	require.Len(t, targets, 1)
+	assert.Equal(t, categoryKey{organizationID: "eco"}, targets[0].key)
+	assert.Equal(t, []string{"issue"}, targets[0].channelIDs)
+	assert.Equal(t, config.SidebarSortingAlphabetical, targets[0].sorting)
+	assert.True(t, targets[0].collapsed)
+
+	targets = buildCategoryTargets(platformConfig, platformConfig.Organizations, channels)
+	require.Len(t, targets, 3)
+	assert.Equal(t, categoryKey{organizationID: "org", ruleID: "docs"}, targets[2].key)
+	assert.Equal(t, config.SidebarSortingRecent, targets[2].sorting)
+	assert.False(t, targets[2].collapsed)
+}
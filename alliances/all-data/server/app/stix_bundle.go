@@ -0,0 +1,150 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+)
+
+// A STIX 2.1 bundle, wrapping all the objects exported from a channel.
+type STIXBundle struct {
+	Type    string        `json:"type"`
+	ID      string        `json:"id"`
+	Objects []interface{} `json:"objects"`
+}
+
+// Properties shared by all the STIX domain and relationship objects.
+type STIXCommonProperties struct {
+	Type               string                  `json:"type"`
+	SpecVersion        string                  `json:"spec_version"`
+	ID                 string                  `json:"id"`
+	CreatedByRef       string                  `json:"created_by_ref,omitempty"`
+	Created            string                  `json:"created"`
+	Modified           string                  `json:"modified"`
+	Labels             []string                `json:"labels,omitempty"`
+	ExternalReferences []STIXExternalReference `json:"external_references,omitempty"`
+}
+
+type STIXExternalReference struct {
+	SourceName  string `json:"source_name"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url,omitempty"`
+	ExternalID  string `json:"external_id,omitempty"`
+}
+
+// ToStixBundle converts an exported channel into a bundle with a report for the channel, an opinion for each root post,
+// a note and a relationship to the root for each reply, and an identity for the platform and each author.
+func ToStixBundle(export *ChannelExport) *STIXBundle {
+	builder := newSTIXBundleBuilder(export)
+	objects := builder.Start()
+	for _, thread := range export.Threads {
+		objects = append(objects, builder.AddThread(thread)...)
+	}
+	return &STIXBundle{
+		Type:    stixBundle,
+		ID:      builder.BundleID(),
+		Objects: append(objects, builder.Report()),
+	}
+}
+
+// stixBundleBuilder converts the threads of a channel one at a time, so that bundles can be streamed.
+// The report is built last, since it references all the other objects.
+type stixBundleBuilder struct {
+	export      *ChannelExport
+	bundleID    string
+	reportID    string
+	platformID  string
+	identityIDs map[string]string
+	objectRefs  []string
+}
+
+func newSTIXBundleBuilder(export *ChannelExport) *stixBundleBuilder {
+	return &stixBundleBuilder{
+		export:      export,
+		bundleID:    fmt.Sprintf("%s--%s", stixBundle, uuid.New()),
+		reportID:    stixID(stixReport, export.Channel.Id),
+		identityIDs: map[string]string{},
+		objectRefs:  []string{},
+	}
+}
+
+func (b *stixBundleBuilder) BundleID() string {
+	return b.bundleID
+}
+
+// Start returns the identity of the platform, which created the report
+func (b *stixBundleBuilder) Start() []interface{} {
+	platform := ToStixPlatformIdentity(b.export)
+	b.platformID = platform.ID
+	b.objectRefs = append(b.objectRefs, platform.ID)
+	return []interface{}{platform}
+}
+
+// AddThread returns the objects for a thread, preceded by the identities of the authors not found in previous threads
+func (b *stixBundleBuilder) AddThread(thread *ExportThread) []interface{} {
+	objects := []interface{}{}
+	for _, post := range append([]*mattermost.Post{thread.Root}, thread.Replies...) {
+		if _, found := b.identityIDs[post.UserId]; found {
+			continue
+		}
+		identity := ToStixIdentity(b.export, post.UserId)
+		b.identityIDs[post.UserId] = identity.ID
+		objects = append(objects, identity)
+	}
+
+	opinion := ToStixOpinion(b.export, thread.Root, b.identityIDs[thread.Root.UserId], b.reportID)
+	objects = append(objects, opinion)
+	for _, reply := range thread.Replies {
+		note := ToStixNote(b.export, reply, b.identityIDs[reply.UserId], opinion.ID)
+		objects = append(objects, note, ToStixReplyRelationship(note, opinion))
+	}
+
+	for _, object := range objects {
+		b.objectRefs = append(b.objectRefs, stixObjectID(object))
+	}
+	return objects
+}
+
+func (b *stixBundleBuilder) Report() *STIXReport {
+	return ToStixReport(b.export, b.reportID, b.platformID, b.objectRefs)
+}
+
+// Builds a deterministic STIX identifier for a Mattermost object
+func stixID(stixType, id string) string {
+	return fmt.Sprintf("%s--%s", stixType, uuid.NewSHA1(stixNamespace, []byte(stixType+":"+id)))
+}
+
+// Formats a timestamp in milliseconds as a STIX timestamp, i.e. RFC 3339 in UTC with millisecond precision
+func stixTimestamp(millis int64) string {
+	return time.UnixMilli(millis).UTC().Format(stixTimestampFormat)
+}
+
+// Returns the modified timestamp for an object, which cannot be earlier than its creation
+func stixModified(created int64, updates ...int64) string {
+	modified := created
+	for _, update := range updates {
+		if update > modified {
+			modified = update
+		}
+	}
+	return stixTimestamp(modified)
+}
+
+func stixObjectID(object interface{}) string {
+	switch stixObject := object.(type) {
+	case *STIXIdentity:
+		return stixObject.ID
+	case *STIXReport:
+		return stixObject.ID
+	case *STIXOpinion:
+		return stixObject.ID
+	case *STIXNote:
+		return stixObject.ID
+	case *STIXRelationship:
+		return stixObject.ID
+	default:
+		return ""
+	}
+}
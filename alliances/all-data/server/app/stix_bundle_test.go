@@ -0,0 +1,172 @@
+package app_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+const stixSchemasURL = "http://raw.githubusercontent.com/oasis-open/cti-stix2-json-schemas/stix2.1/schemas/"
+
+// Compiles a STIX schema, loading it and the schemas it references from testdata instead of the network.
+func compileSTIXSchema(t *testing.T, name string) *jsonschema.Schema {
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	compiler.LoadURL = func(url string) (io.ReadCloser, error) {
+		if !strings.HasPrefix(url, stixSchemasURL) {
+			return nil, fmt.Errorf("unexpected schema url %s", url)
+		}
+		return os.Open(filepath.Join("testdata", "stix2", "schemas", filepath.FromSlash(strings.TrimPrefix(url, stixSchemasURL))))
+	}
+	schema, err := compiler.Compile(stixSchemasURL + name)
+	require.NoError(t, err)
+	return schema
+}
+
+func newChannelExport() *app.ChannelExport {
+	root := &mattermost.Post{Id: "rootpostid", UserId: "alice", CreateAt: 1680000000000, Message: "Look at [the chart](http://localhost/x)", IsPinned: true, FileIds: []string{"fileid"}}
+	reply := &mattermost.Post{Id: "replypostid", UserId: "bob", RootId: "rootpostid", CreateAt: 1680000060000, EditAt: 1680000120000, Message: "Agreed"}
+	other := &mattermost.Post{Id: "otherpostid", UserId: "carol", CreateAt: 1680000180000, Message: ""}
+	return &app.ChannelExport{
+		Channel: &mattermost.Channel{Id: "channelid", TeamId: "teamid", DisplayName: "Incident", Header: "About the incident", CreateAt: 1670000000000, UpdateAt: 1670000000500},
+		Team:    &mattermost.Team{Id: "teamid", Name: "hood", DisplayName: "HOOD", CreateAt: 1660000000000},
+		SiteURL: "http://localhost:8065",
+		Threads: []*app.ExportThread{
+			{Root: root, Replies: []*mattermost.Post{reply}},
+			{Root: other, Replies: []*mattermost.Post{}},
+		},
+		Users: map[string]*mattermost.User{
+			"alice": {Id: "alice", Username: "alice", Email: "alice@example.com", CreateAt: 1650000000000},
+			"bob":   {Id: "bob", Username: "bob", FirstName: "Bob", LastName: "Smith", CreateAt: 1650000000000},
+			"carol": nil,
+		},
+		FileLinks: map[string]string{"fileid": "http://localhost:8065/files/fileid/public"},
+		References: []app.ExportReference{
+			{SourceName: "issue", ExternalIds: []string{"1", "2"}, URLs: []string{"http://localhost:3000/issues/1"}},
+			{SourceName: "empty"},
+		},
+		ExportedAt: 1690000000000,
+	}
+}
+
+// Tests that exported bundles are valid according to the STIX 2.1 JSON schemas.
+func TestToStixBundleIsValid(t *testing.T) {
+	bundleSchema := compileSTIXSchema(t, "common/bundle.json")
+	objectSchemas := map[string]*jsonschema.Schema{
+		"identity":     compileSTIXSchema(t, "sdos/identity.json"),
+		"note":         compileSTIXSchema(t, "sdos/note.json"),
+		"opinion":      compileSTIXSchema(t, "sdos/opinion.json"),
+		"report":       compileSTIXSchema(t, "sdos/report.json"),
+		"relationship": compileSTIXSchema(t, "sros/relationship.json"),
+	}
+
+	tests := []struct {
+		name   string
+		export func() *app.ChannelExport
+	}{
+		{
+			name:   "channel with threads",
+			export: newChannelExport,
+		},
+		{
+			name: "empty channel",
+			export: func() *app.ChannelExport {
+				export := newChannelExport()
+				export.Threads = []*app.ExportThread{}
+				return export
+			},
+		},
+		{
+			name: "channel without team",
+			export: func() *app.ChannelExport {
+				export := newChannelExport()
+				export.Team = nil
+				return export
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bundle := toJSONObject(t, app.ToStixBundle(test.export()))
+			assert.NoError(t, bundleSchema.Validate(bundle))
+
+			for _, object := range bundle["objects"].([]interface{}) {
+				stixObject := object.(map[string]interface{})
+				schema, found := objectSchemas[stixObject["type"].(string)]
+				require.True(t, found, "unexpected object type %s", stixObject["type"])
+				assert.NoError(t, schema.Validate(stixObject), "invalid %s", stixObject["id"])
+			}
+		})
+	}
+}
+
+// Tests the objects and references in an exported bundle.
+func TestToStixBundleObjects(t *testing.T) {
+	bundle := app.ToStixBundle(newChannelExport())
+	objectsByType := map[string][]map[string]interface{}{}
+	ids := map[string]bool{}
+	for _, object := range toJSONObject(t, bundle)["objects"].([]interface{}) {
+		stixObject := object.(map[string]interface{})
+		objectType := stixObject["type"].(string)
+		objectsByType[objectType] = append(objectsByType[objectType], stixObject)
+		ids[stixObject["id"].(string)] = true
+	}
+
+	assert.Len(t, objectsByType["identity"], 4)
+	assert.Len(t, objectsByType["report"], 1)
+	assert.Len(t, objectsByType["opinion"], 2)
+	assert.Len(t, objectsByType["note"], 1)
+	assert.Len(t, objectsByType["relationship"], 1)
+
+	report := objectsByType["report"][0]
+	assert.Equal(t, "Incident", report["name"])
+	assert.Equal(t, "2023-07-22T04:26:40.000Z", report["published"])
+	assert.Len(t, report["object_refs"], len(ids)-1)
+	for _, ref := range report["object_refs"].([]interface{}) {
+		assert.True(t, ids[ref.(string)], "dangling reference %s", ref)
+	}
+
+	opinion := objectsByType["opinion"][0]
+	assert.Equal(t, "2023-03-28T10:40:00.000Z", opinion["created"])
+	assert.Equal(t, []interface{}{"pinned"}, opinion["labels"])
+	assert.Equal(t, []interface{}{report["id"]}, opinion["object_refs"])
+
+	note := objectsByType["note"][0]
+	assert.Equal(t, "Agreed", note["content"])
+	assert.Equal(t, "2023-03-28T10:42:00.000Z", note["modified"])
+	assert.Equal(t, []interface{}{"Bob Smith"}, note["authors"])
+
+	for _, identity := range objectsByType["identity"] {
+		assert.NotContains(t, identity, "contact_information")
+	}
+
+	relationship := objectsByType["relationship"][0]
+	assert.Equal(t, "replies-to", relationship["relationship_type"])
+	assert.Equal(t, note["id"], relationship["source_ref"])
+	assert.Equal(t, opinion["id"], relationship["target_ref"])
+
+	// Identifiers only depend on the exported objects, while each bundle is a new one
+	other := app.ToStixBundle(newChannelExport())
+	assert.Equal(t, toJSONObject(t, bundle)["objects"], toJSONObject(t, other)["objects"])
+	assert.NotEqual(t, bundle.ID, other.ID)
+}
+
+func toJSONObject(t *testing.T, value interface{}) map[string]interface{} {
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	var object map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &object))
+	return object
+}
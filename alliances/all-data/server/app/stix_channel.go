@@ -1,36 +1,62 @@
 package app
 
-import (
-	"time"
+// A representation of a Mattermost Channel in STIX format, encoded as a report.
+type STIXReport struct {
+	STIXCommonProperties
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Published   string   `json:"published"`
+	ObjectRefs  []string `json:"object_refs"`
+}
 
-	mattermost "github.com/mattermost/mattermost-server/v6/model"
-)
+func ToStixReport(export *ChannelExport, reportID, createdByRef string, objectRefs []string) *STIXReport {
+	channel := export.Channel
+	description := channel.Purpose
+	if channel.Header != "" {
+		description = channel.Header
+	}
 
-// A representation of a Mattermost Channel in STIX format, encoded as a report.
-type STIXChannel struct {
-	ID                 string            `json:"id"`
-	SpecVersion        string            `json:"spec_version"`
-	Type               string            `json:"type"`
-	Created            int64             `json:"created"`
-	Modified           int64             `json:"modified"`
-	Name               string            `json:"name"`
-	Description        string            `json:"description"`
-	Published          int64             `json:"published"`
-	ObjectRefs         []*STIXPost       `json:"object_refs"`
-	ExternalReferences []ExportReference `json:"external_references"`
+	externalReferences := []STIXExternalReference{{SourceName: stixMattermostSource, ExternalID: channel.Id}}
+	for _, reference := range export.References {
+		externalReferences = append(externalReferences, toStixExternalReferences(reference)...)
+	}
+
+	return &STIXReport{
+		STIXCommonProperties: STIXCommonProperties{
+			Type:               stixReport,
+			SpecVersion:        stixVersion,
+			ID:                 reportID,
+			CreatedByRef:       createdByRef,
+			Created:            stixTimestamp(channel.CreateAt),
+			Modified:           stixModified(channel.CreateAt, channel.UpdateAt),
+			ExternalReferences: externalReferences,
+		},
+		Name:        channel.DisplayName,
+		Description: description,
+		Published:   stixTimestamp(export.ExportedAt),
+		ObjectRefs:  objectRefs,
+	}
 }
 
-func ToStixChannel(channel *mattermost.Channel, opinions []*STIXPost, extraReferences []ExportReference) *STIXChannel {
-	return &STIXChannel{
-		ID:                 channel.Id,
-		SpecVersion:        stixVersion,
-		Type:               stixReport,
-		Created:            channel.CreateAt,
-		Modified:           channel.UpdateAt,
-		Name:               channel.DisplayName,
-		Description:        channel.Header,
-		Published:          time.Now().UnixMilli(),
-		ObjectRefs:         opinions,
-		ExternalReferences: extraReferences,
+// Splits a reference to multiple external ids and urls into STIX external references, which have at most one of each
+func toStixExternalReferences(reference ExportReference) []STIXExternalReference {
+	count := len(reference.URLs)
+	if len(reference.ExternalIds) > count {
+		count = len(reference.ExternalIds)
+	}
+	externalReferences := make([]STIXExternalReference, 0, count)
+	for i := 0; i < count; i++ {
+		externalReference := STIXExternalReference{SourceName: reference.SourceName}
+		if i < len(reference.URLs) {
+			externalReference.URL = reference.URLs[i]
+		}
+		if i < len(reference.ExternalIds) {
+			externalReference.ExternalID = reference.ExternalIds[i]
+		}
+		if externalReference.SourceName == "" || (externalReference.URL == "" && externalReference.ExternalID == "") {
+			continue
+		}
+		externalReferences = append(externalReferences, externalReference)
 	}
+	return externalReferences
 }
@@ -1,7 +1,26 @@
 package app
 
+import "github.com/google/uuid"
+
 const (
-	stixVersion = "2.1"
-	stixReport  = "report"
-	stixOpinion = "opinion"
+	stixVersion      = "2.1"
+	stixBundle       = "bundle"
+	stixReport       = "report"
+	stixOpinion      = "opinion"
+	stixNote         = "note"
+	stixIdentity     = "identity"
+	stixRelationship = "relationship"
+
+	stixNeutralOpinion    = "neutral"
+	stixRepliesTo         = "replies-to"
+	stixPinnedLabel       = "pinned"
+	stixMattermostSource  = "mattermost"
+	stixFileSource        = "mattermost-file"
+	stixTimestampFormat   = "2006-01-02T15:04:05.000Z"
+	stixIndividualClass   = "individual"
+	stixOrganizationClass = "organization"
+	stixSystemClass       = "system"
 )
+
+// Namespace of the UUIDv5 used in STIX identifiers, so that the same Mattermost object always gets the same identifier
+var stixNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("https://github.com/tizianocitro/hood-framework"))
@@ -0,0 +1,276 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ChannelImport holds the content of an exported channel to recreate, as read from a STIX bundle or a STIXChannel.
+type ChannelImport struct {
+	Name        string
+	Description string
+	Authors     map[string]*ImportAuthor // By STIX identity id
+	Threads     []*ImportThread          // Sorted by creation time of their roots
+}
+
+// ImportAuthor is the author of imported posts, as exported from the original platform.
+type ImportAuthor struct {
+	Name     string
+	Email    string
+	Username string
+}
+
+// ImportThread is a root post together with its replies, sorted by creation time.
+type ImportThread struct {
+	Root    *ImportPost
+	Replies []*ImportPost
+}
+
+type ImportPost struct {
+	AuthorRef string // STIX identity id, empty for unknown authors and in STIXChannel exports
+	Author    string // Name of the author as exported, used if the identity is missing
+	Message   string
+	CreateAt  int64
+	IsPinned  bool
+}
+
+// STIXChannel is a channel as exported by earlier versions of the plugin: a report embedding the opinions of its root posts,
+// which in turn embed the opinions of their replies. Authors are display names and times are Unix milliseconds.
+type STIXChannel struct {
+	ID                 string            `json:"id"`
+	SpecVersion        string            `json:"spec_version"`
+	Type               string            `json:"type"`
+	Created            int64             `json:"created"`
+	Modified           int64             `json:"modified"`
+	Name               string            `json:"name"`
+	Description        string            `json:"description"`
+	Published          int64             `json:"published"`
+	ObjectRefs         []*STIXPost       `json:"object_refs"`
+	ExternalReferences []ExportReference `json:"external_references"`
+}
+
+// STIXPost is a post as exported by earlier versions of the plugin, within a STIXChannel.
+type STIXPost struct {
+	ID                 string      `json:"id"`
+	SpecVersion        string      `json:"spec_version"`
+	Type               string      `json:"type"`
+	Created            int64       `json:"created"`
+	Modified           int64       `json:"modified"`
+	Authors            []string    `json:"authors"`
+	Opinion            string      `json:"opinion"`
+	Labels             []string    `json:"labels"`
+	ExternalReferences []string    `json:"external_references"` // Used for file attachments
+	ObjectRefs         []*STIXPost `json:"object_refs"`
+}
+
+type stixImportBundle struct {
+	Type    string            `json:"type"`
+	Objects []json.RawMessage `json:"objects"`
+}
+
+// ParseChannelImport reads an exported channel, telling STIX bundles apart from the STIXChannel reports of earlier versions by their type.
+func ParseChannelImport(r io.Reader) (*ChannelImport, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read the exported channel")
+	}
+	var common struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &common); err != nil {
+		return nil, errors.Wrapf(ErrInvalidInput, "unable to decode the exported channel: %s", err.Error())
+	}
+	switch common.Type {
+	case stixBundle:
+		return ParseSTIXBundle(bytes.NewReader(data))
+	case stixReport:
+		return ParseSTIXChannel(bytes.NewReader(data))
+	default:
+		return nil, errors.Wrapf(ErrInvalidInput, "expected a STIX bundle or a STIXChannel report, found %s", common.Type)
+	}
+}
+
+// ParseSTIXChannel reads a channel exported as a STIXChannel, whose root opinions embed the opinions of their replies.
+// Replies nested further are flattened into the thread of their root, as Mattermost threads have a single level.
+func ParseSTIXChannel(r io.Reader) (*ChannelImport, error) {
+	var stixChannel STIXChannel
+	if err := json.NewDecoder(r).Decode(&stixChannel); err != nil {
+		return nil, errors.Wrapf(ErrInvalidInput, "unable to decode STIXChannel: %s", err.Error())
+	}
+	if stixChannel.Type != stixReport {
+		return nil, errors.Wrapf(ErrInvalidInput, "expected a STIXChannel report, found %s", stixChannel.Type)
+	}
+
+	channelImport := &ChannelImport{
+		Name:        stixChannel.Name,
+		Description: stixChannel.Description,
+		Authors:     map[string]*ImportAuthor{},
+		Threads:     []*ImportThread{},
+	}
+	for _, stixPost := range stixChannel.ObjectRefs {
+		if stixPost == nil {
+			continue
+		}
+		thread := &ImportThread{Root: toLegacyImportPost(stixPost), Replies: []*ImportPost{}}
+		replies := append([]*STIXPost{}, stixPost.ObjectRefs...)
+		for len(replies) > 0 {
+			reply := replies[0]
+			replies = replies[1:]
+			if reply == nil {
+				continue
+			}
+			thread.Replies = append(thread.Replies, toLegacyImportPost(reply))
+			replies = append(replies, reply.ObjectRefs...)
+		}
+		channelImport.Threads = append(channelImport.Threads, thread)
+	}
+	sortImportThreads(channelImport.Threads)
+	return channelImport, nil
+}
+
+// ParseSTIXBundle reads a channel exported as a STIX bundle, rebuilding its threads from the opinions of the roots and the notes of the replies.
+// Notes whose opinion is not in the bundle are imported as root posts, so that no message is lost.
+func ParseSTIXBundle(r io.Reader) (*ChannelImport, error) {
+	var bundle stixImportBundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return nil, errors.Wrapf(ErrInvalidInput, "unable to decode STIX bundle: %s", err.Error())
+	}
+	if bundle.Type != stixBundle {
+		return nil, errors.Wrapf(ErrInvalidInput, "expected a STIX bundle, found %s", bundle.Type)
+	}
+
+	channelImport := &ChannelImport{Authors: map[string]*ImportAuthor{}, Threads: []*ImportThread{}}
+	var report *STIXReport
+	opinions := []*STIXOpinion{}
+	notes := []*STIXNote{}
+	repliesTo := map[string]string{}
+	for _, rawObject := range bundle.Objects {
+		var common STIXCommonProperties
+		if err := json.Unmarshal(rawObject, &common); err != nil {
+			return nil, errors.Wrapf(ErrInvalidInput, "unable to decode STIX object: %s", err.Error())
+		}
+		var err error
+		switch common.Type {
+		case stixReport:
+			report = &STIXReport{}
+			err = json.Unmarshal(rawObject, report)
+		case stixIdentity:
+			identity := &STIXIdentity{}
+			if err = json.Unmarshal(rawObject, identity); err == nil && identity.IdentityClass == stixIndividualClass {
+				channelImport.Authors[identity.ID] = toImportAuthor(identity)
+			}
+		case stixOpinion:
+			opinion := &STIXOpinion{}
+			err = json.Unmarshal(rawObject, opinion)
+			opinions = append(opinions, opinion)
+		case stixNote:
+			note := &STIXNote{}
+			err = json.Unmarshal(rawObject, note)
+			notes = append(notes, note)
+		case stixRelationship:
+			relationship := &STIXRelationship{}
+			if err = json.Unmarshal(rawObject, relationship); err == nil && relationship.RelationshipType == stixRepliesTo {
+				repliesTo[relationship.SourceRef] = relationship.TargetRef
+			}
+		}
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidInput, "unable to decode STIX %s %s: %s", common.Type, common.ID, err.Error())
+		}
+	}
+	if report == nil {
+		return nil, errors.Wrap(ErrInvalidInput, "the STIX bundle has no report for the channel")
+	}
+	channelImport.Name = report.Name
+	channelImport.Description = report.Description
+
+	threads := map[string]*ImportThread{}
+	for _, opinion := range opinions {
+		root, err := toImportPost(opinion.STIXCommonProperties, opinion.Authors, opinion.Explanation)
+		if err != nil {
+			return nil, err
+		}
+		thread := &ImportThread{Root: root, Replies: []*ImportPost{}}
+		threads[opinion.ID] = thread
+		channelImport.Threads = append(channelImport.Threads, thread)
+	}
+	for _, note := range notes {
+		reply, err := toImportPost(note.STIXCommonProperties, note.Authors, note.Content)
+		if err != nil {
+			return nil, err
+		}
+		opinionID, found := repliesTo[note.ID]
+		if !found && len(note.ObjectRefs) > 0 {
+			opinionID = note.ObjectRefs[0]
+		}
+		if thread, found := threads[opinionID]; found {
+			thread.Replies = append(thread.Replies, reply)
+		} else {
+			channelImport.Threads = append(channelImport.Threads, &ImportThread{Root: reply, Replies: []*ImportPost{}})
+		}
+	}
+
+	sortImportThreads(channelImport.Threads)
+	return channelImport, nil
+}
+
+// Sorts threads by creation time of their roots, and the replies of each thread by their own creation time
+func sortImportThreads(threads []*ImportThread) {
+	sort.SliceStable(threads, func(i, j int) bool {
+		return threads[i].Root.CreateAt < threads[j].Root.CreateAt
+	})
+	for _, thread := range threads {
+		replies := thread.Replies
+		sort.SliceStable(replies, func(i, j int) bool {
+			return replies[i].CreateAt < replies[j].CreateAt
+		})
+	}
+}
+
+func toImportAuthor(identity *STIXIdentity) *ImportAuthor {
+	author := &ImportAuthor{Name: identity.Name, Email: identity.ContactInformation}
+	for _, reference := range identity.ExternalReferences {
+		if reference.SourceName == stixMattermostSource {
+			author.Username = reference.Description
+		}
+	}
+	return author
+}
+
+func toImportPost(properties STIXCommonProperties, authors []string, message string) (*ImportPost, error) {
+	created, err := time.Parse(time.RFC3339Nano, properties.Created)
+	if err != nil {
+		return nil, errors.Wrapf(ErrInvalidInput, "invalid creation time of %s: %s", properties.ID, err.Error())
+	}
+	post := &ImportPost{
+		AuthorRef: properties.CreatedByRef,
+		Message:   message,
+		CreateAt:  created.UnixMilli(),
+	}
+	if len(authors) > 0 {
+		post.Author = authors[0]
+	}
+	for _, label := range properties.Labels {
+		if label == stixPinnedLabel {
+			post.IsPinned = true
+		}
+	}
+	return post, nil
+}
+
+func toLegacyImportPost(stixPost *STIXPost) *ImportPost {
+	post := &ImportPost{Message: stixPost.Opinion, CreateAt: stixPost.Created}
+	if len(stixPost.Authors) > 0 {
+		post.Author = stixPost.Authors[0]
+	}
+	for _, label := range stixPost.Labels {
+		if label == stixPinnedLabel {
+			post.IsPinned = true
+		}
+	}
+	return post
+}
@@ -0,0 +1,134 @@
+package app_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// Tests that an exported bundle is imported back into the same threads.
+func TestParseSTIXBundleRoundTrip(t *testing.T) {
+	data, err := json.Marshal(app.ToStixBundle(newChannelExport()))
+	require.NoError(t, err)
+
+	channelImport, err := app.ParseSTIXBundle(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Incident", channelImport.Name)
+	assert.Equal(t, "About the incident", channelImport.Description)
+	require.Len(t, channelImport.Threads, 2)
+
+	root := channelImport.Threads[0].Root
+	assert.Equal(t, "Look at [the chart](http://localhost/x)", root.Message)
+	assert.Equal(t, int64(1680000000000), root.CreateAt)
+	assert.True(t, root.IsPinned)
+	assert.Equal(t, &app.ImportAuthor{Name: "alice", Username: "alice"}, channelImport.Authors[root.AuthorRef])
+
+	require.Len(t, channelImport.Threads[0].Replies, 1)
+	reply := channelImport.Threads[0].Replies[0]
+	assert.Equal(t, "Agreed", reply.Message)
+	assert.Equal(t, int64(1680000060000), reply.CreateAt)
+	assert.False(t, reply.IsPinned)
+	assert.Equal(t, "Bob Smith", channelImport.Authors[reply.AuthorRef].Name)
+
+	other := channelImport.Threads[1]
+	assert.Empty(t, other.Replies)
+	assert.Equal(t, "carol", channelImport.Authors[other.Root.AuthorRef].Name)
+	assert.Empty(t, channelImport.Authors[other.Root.AuthorRef].Email)
+
+	// The team is not an author
+	assert.Len(t, channelImport.Authors, 3)
+}
+
+func TestParseSTIXBundleInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not json", data: "channel"},
+		{name: "not a bundle", data: `{"type":"report","objects":[]}`},
+		{name: "without report", data: `{"type":"bundle","objects":[]}`},
+		{name: "invalid timestamp", data: `{"type":"bundle","objects":[{"type":"report","id":"report--1","name":"x"},{"type":"opinion","id":"opinion--1","created":"yesterday"}]}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := app.ParseSTIXBundle(strings.NewReader(test.data))
+			assert.True(t, errors.Is(err, app.ErrInvalidInput), "unexpected error %v", err)
+		})
+	}
+}
+
+// Tests that a channel exported as a STIXChannel by earlier versions is imported back into the same threads.
+func TestParseSTIXChannelRoundTrip(t *testing.T) {
+	stixChannel := &app.STIXChannel{
+		ID:          "channel-1",
+		SpecVersion: "2.1",
+		Type:        "report",
+		Name:        "Incident",
+		Description: "About the incident",
+		ObjectRefs: []*app.STIXPost{
+			{
+				ID:      "post-2",
+				Type:    "opinion",
+				Created: 1680000120000,
+				Authors: []string{"Carol"},
+				Opinion: "Later root",
+				Labels:  []string{},
+			},
+			{
+				ID:      "post-1",
+				Type:    "opinion",
+				Created: 1680000000000,
+				Authors: []string{"Alice"},
+				Opinion: "Look at [the chart](http://localhost/x)",
+				Labels:  []string{"pinned"},
+				ObjectRefs: []*app.STIXPost{
+					{ID: "post-4", Type: "opinion", Created: 1680000090000, Authors: []string{"Alice"}, Opinion: "Thanks"},
+					{ID: "post-3", Type: "opinion", Created: 1680000060000, Authors: []string{"Bob Smith"}, Opinion: "Agreed"},
+				},
+			},
+		},
+	}
+	data, err := json.Marshal(stixChannel)
+	require.NoError(t, err)
+
+	channelImport, err := app.ParseChannelImport(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	assert.Equal(t, "Incident", channelImport.Name)
+	assert.Equal(t, "About the incident", channelImport.Description)
+	assert.Empty(t, channelImport.Authors)
+	require.Len(t, channelImport.Threads, 2)
+
+	root := channelImport.Threads[0].Root
+	assert.Equal(t, &app.ImportPost{Author: "Alice", Message: "Look at [the chart](http://localhost/x)", CreateAt: 1680000000000, IsPinned: true}, root)
+	assert.Equal(t, []*app.ImportPost{
+		{Author: "Bob Smith", Message: "Agreed", CreateAt: 1680000060000},
+		{Author: "Alice", Message: "Thanks", CreateAt: 1680000090000},
+	}, channelImport.Threads[0].Replies)
+
+	other := channelImport.Threads[1]
+	assert.Equal(t, "Later root", other.Root.Message)
+	assert.Empty(t, other.Replies)
+}
+
+// Tests that both export formats are told apart by their type.
+func TestParseChannelImport(t *testing.T) {
+	data, err := json.Marshal(app.ToStixBundle(newChannelExport()))
+	require.NoError(t, err)
+	channelImport, err := app.ParseChannelImport(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Len(t, channelImport.Threads, 2)
+
+	for _, data := range []string{"channel", `{"type":"opinion"}`, `{"objects":[]}`} {
+		_, err := app.ParseChannelImport(strings.NewReader(data))
+		assert.True(t, errors.Is(err, app.ErrInvalidInput), "unexpected error %v for %s", err, data)
+	}
+}
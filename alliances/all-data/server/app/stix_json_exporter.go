@@ -3,13 +3,18 @@ package app
 import (
 	"encoding/json"
 	"fmt"
-	"net/http"
-
-	"github.com/sirupsen/logrus"
+	"io"
 )
 
 type JSON struct{}
 
+// jsonWriter streams a STIX 2.1 bundle, writing each object as soon as its thread is converted
+type jsonWriter struct {
+	w       io.Writer
+	builder *stixBundleBuilder
+	count   int
+}
+
 func (e *JSON) FileName(name string) string {
 	return fmt.Sprintf("%s.json", name)
 }
@@ -18,21 +23,45 @@ func (e *JSON) ContentType() string {
 	return "application/json"
 }
 
-func (e *JSON) Export(w http.ResponseWriter, stixChannel *STIXChannel) {
-	fileName := e.FileName(stixChannel.Name)
-
-	jsonBytes, err := json.Marshal(stixChannel)
-	if err != nil {
-		logrus.WithError(err).Error("Unable to marshal JSON")
-		return
+// NewWriter starts writing the channel as a STIX 2.1 bundle
+func (e *JSON) NewWriter(w io.Writer, export *ChannelExport) (ExportWriter, error) {
+	writer := &jsonWriter{w: w, builder: newSTIXBundleBuilder(export)}
+	if _, err := fmt.Fprintf(w, `{"type":%q,"id":%q,"objects":[`, stixBundle, writer.builder.BundleID()); err != nil {
+		return nil, err
+	}
+	if err := writer.writeObjects(writer.builder.Start()...); err != nil {
+		return nil, err
 	}
+	return writer, nil
+}
+
+func (w *jsonWriter) WriteThread(thread *ExportThread) error {
+	return w.writeObjects(w.builder.AddThread(thread)...)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Content-Disposition", "attachment; filename="+fileName)
-	w.WriteHeader(http.StatusOK)
+func (w *jsonWriter) Close() error {
+	if err := w.writeObjects(w.builder.Report()); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w.w, "]}\n")
+	return err
+}
 
-	if _, err = w.Write(jsonBytes); err != nil {
-		logrus.WithError(err).Warn("Unable to write to http.ResponseWriter")
-		return
+func (w *jsonWriter) writeObjects(objects ...interface{}) error {
+	for _, object := range objects {
+		data, err := json.Marshal(object)
+		if err != nil {
+			return err
+		}
+		if w.count > 0 {
+			if _, err := io.WriteString(w.w, ","); err != nil {
+				return err
+			}
+		}
+		if _, err := w.w.Write(data); err != nil {
+			return err
+		}
+		w.count++
 	}
+	return nil
 }
@@ -2,89 +2,89 @@ package app
 
 import (
 	mattermost "github.com/mattermost/mattermost-server/v6/model"
-	"github.com/mattermost/mattermost-server/v6/plugin"
 )
 
-// A representation of a Mattermost Post in STIX format, encoded as an opinion.
-type STIXPost struct {
-	ID                 string      `json:"id"`
-	SpecVersion        string      `json:"spec_version"`
-	Type               string      `json:"type"`
-	Created            int64       `json:"created"`
-	Modified           int64       `json:"modified"`
-	Authors            []string    `json:"authors"`
-	Opinion            string      `json:"opinion"`
-	Labels             []string    `json:"labels"`
-	ExternalReferences []string    `json:"external_references"` // Used for file attachments
-	ObjectRefs         []*STIXPost `json:"object_refs"`
+// A representation of a Mattermost root Post in STIX format, encoded as an opinion about the channel report.
+type STIXOpinion struct {
+	STIXCommonProperties
+	Explanation string   `json:"explanation,omitempty"`
+	Authors     []string `json:"authors,omitempty"`
+	Opinion     string   `json:"opinion"`
+	ObjectRefs  []string `json:"object_refs"`
 }
 
-func ToStixPost(
-	api plugin.API,
-	post *mattermost.Post,
-	withThreadPosts bool,
-	pinnedOnly bool,
-	usersCache map[string]*mattermost.User,
-) *STIXPost {
-	if pinnedOnly && !post.IsPinned {
-		return nil
+// A representation of a Mattermost reply Post in STIX format, encoded as a note about the opinion of its root.
+type STIXNote struct {
+	STIXCommonProperties
+	Content    string   `json:"content"`
+	Authors    []string `json:"authors,omitempty"`
+	ObjectRefs []string `json:"object_refs"`
+}
+
+type STIXRelationship struct {
+	STIXCommonProperties
+	RelationshipType string `json:"relationship_type"`
+	SourceRef        string `json:"source_ref"`
+	TargetRef        string `json:"target_ref"`
+}
+
+func ToStixOpinion(export *ChannelExport, post *mattermost.Post, createdByRef, reportID string) *STIXOpinion {
+	return &STIXOpinion{
+		STIXCommonProperties: toStixPostProperties(export, stixOpinion, post, createdByRef),
+		Explanation:          post.Message,
+		Authors:              []string{export.UserName(post.UserId)},
+		Opinion:              stixNeutralOpinion,
+		ObjectRefs:           []string{reportID},
 	}
-	stixThreadPosts := []*STIXPost{}
-	if withThreadPosts {
-		threadPostList, err := api.GetPostThread(post.Id)
-		if err != nil {
-			api.LogInfo("error with GetPostThread", "err", err)
-		} else {
-			threadPosts := threadPostList.ToSlice()[1:]
+}
 
-			stixThreadPosts = make([]*STIXPost, 0, len(threadPosts))
-			for _, threadPost := range threadPosts {
-				// we always want to include posts in the thread even if they are not pinned
-				stixThreadPosts = append(stixThreadPosts, ToStixPost(api, threadPost, false, false, usersCache))
-			}
-		}
+func ToStixNote(export *ChannelExport, post *mattermost.Post, createdByRef, opinionID string) *STIXNote {
+	return &STIXNote{
+		STIXCommonProperties: toStixPostProperties(export, stixNote, post, createdByRef),
+		Content:              post.Message,
+		Authors:              []string{export.UserName(post.UserId)},
+		ObjectRefs:           []string{opinionID},
 	}
+}
 
-	fileLinks := make([]string, 0, len(post.FileIds))
-	for _, fileID := range post.FileIds {
-		fileURL, err := api.GetFileLink(fileID)
-		if err != nil {
-			api.LogWarn("Failed to get URL for file during STIX post conversion", "fileId", fileID, "err", err)
-			continue
-		}
-		fileLinks = append(fileLinks, fileURL)
+// ToStixReplyRelationship links the note of a reply to the opinion of its thread root.
+func ToStixReplyRelationship(note *STIXNote, opinion *STIXOpinion) *STIXRelationship {
+	return &STIXRelationship{
+		STIXCommonProperties: STIXCommonProperties{
+			Type:         stixRelationship,
+			SpecVersion:  stixVersion,
+			ID:           stixID(stixRelationship, note.ID),
+			CreatedByRef: note.CreatedByRef,
+			Created:      note.Created,
+			Modified:     note.Created,
+		},
+		RelationshipType: stixRepliesTo,
+		SourceRef:        note.ID,
+		TargetRef:        opinion.ID,
 	}
+}
 
-	user, ok := usersCache[post.UserId]
-	if !ok {
-		newUser, err := api.GetUser(post.UserId)
-		if err != nil {
-			api.LogWarn("failed retrieving post author during STIX post conversion")
-		} else {
-			usersCache[post.UserId] = newUser
-			user = newUser
+func toStixPostProperties(export *ChannelExport, stixType string, post *mattermost.Post, createdByRef string) STIXCommonProperties {
+	externalReferences := []STIXExternalReference{{SourceName: stixMattermostSource, ExternalID: post.Id, URL: export.PostURL(post.Id)}}
+	for _, fileID := range post.FileIds {
+		if fileLink, found := export.FileLinks[fileID]; found {
+			externalReferences = append(externalReferences, STIXExternalReference{SourceName: stixFileSource, ExternalID: fileID, URL: fileLink})
 		}
 	}
-	userName := ""
-	if user != nil {
-		userName = user.GetDisplayName(mattermost.ShowNicknameFullName)
-	}
 
-	labels := []string{}
+	var labels []string
 	if post.IsPinned {
-		labels = append(labels, "pinned")
+		labels = append(labels, stixPinnedLabel)
 	}
 
-	return &STIXPost{
-		ID:                 post.Id,
+	return STIXCommonProperties{
+		Type:               stixType,
 		SpecVersion:        stixVersion,
-		Type:               stixOpinion,
-		Created:            post.CreateAt,
-		Modified:           post.EditAt,
-		Authors:            []string{userName},
-		Opinion:            post.Message,
+		ID:                 stixID(stixType, post.Id),
+		CreatedByRef:       createdByRef,
+		Created:            stixTimestamp(post.CreateAt),
+		Modified:           stixModified(post.CreateAt, post.EditAt),
 		Labels:             labels,
-		ExternalReferences: fileLinks,
-		ObjectRefs:         stixThreadPosts,
+		ExternalReferences: externalReferences,
 	}
 }
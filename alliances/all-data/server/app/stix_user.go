@@ -0,0 +1,63 @@
+package app
+
+// A representation of a Mattermost user, or of the team the channel belongs to, in STIX format.
+type STIXIdentity struct {
+	STIXCommonProperties
+	Name               string `json:"name"`
+	IdentityClass      string `json:"identity_class,omitempty"`
+	ContactInformation string `json:"contact_information,omitempty"`
+}
+
+// ToStixIdentity converts a post author, falling back to an identity named after the user id for unknown users.
+// Bundles are shared outside the platform, so authors are only identified by their username, never by their email.
+func ToStixIdentity(export *ChannelExport, userID string) *STIXIdentity {
+	identity := &STIXIdentity{
+		STIXCommonProperties: STIXCommonProperties{
+			Type:               stixIdentity,
+			SpecVersion:        stixVersion,
+			ID:                 stixID(stixIdentity, userID),
+			Created:            stixTimestamp(export.ExportedAt),
+			Modified:           stixTimestamp(export.ExportedAt),
+			ExternalReferences: []STIXExternalReference{{SourceName: stixMattermostSource, ExternalID: userID}},
+		},
+		Name:          export.UserName(userID),
+		IdentityClass: stixIndividualClass,
+	}
+	if user, found := export.Users[userID]; found && user != nil {
+		identity.Created = stixTimestamp(user.CreateAt)
+		identity.Modified = stixModified(user.CreateAt, user.UpdateAt)
+		identity.ExternalReferences[0].Description = user.Username
+	}
+	return identity
+}
+
+// ToStixPlatformIdentity converts the team of the exported channel, which is the creator of the report.
+// Channels not belonging to a team are attributed to the platform itself.
+func ToStixPlatformIdentity(export *ChannelExport) *STIXIdentity {
+	if export.Team == nil {
+		return &STIXIdentity{
+			STIXCommonProperties: STIXCommonProperties{
+				Type:        stixIdentity,
+				SpecVersion: stixVersion,
+				ID:          stixID(stixIdentity, export.SiteURL),
+				Created:     stixTimestamp(export.ExportedAt),
+				Modified:    stixTimestamp(export.ExportedAt),
+			},
+			Name:          "Mattermost",
+			IdentityClass: stixSystemClass,
+		}
+	}
+	team := export.Team
+	return &STIXIdentity{
+		STIXCommonProperties: STIXCommonProperties{
+			Type:               stixIdentity,
+			SpecVersion:        stixVersion,
+			ID:                 stixID(stixIdentity, team.Id),
+			Created:            stixTimestamp(team.CreateAt),
+			Modified:           stixModified(team.CreateAt, team.UpdateAt),
+			ExternalReferences: []STIXExternalReference{{SourceName: stixMattermostSource, ExternalID: team.Id, Description: team.Name}},
+		},
+		Name:          team.DisplayName,
+		IdentityClass: stixOrganizationClass,
+	}
+}
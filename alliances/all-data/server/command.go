@@ -8,6 +8,7 @@ import (
 	"github.com/mattermost/mattermost-server/v6/plugin"
 	"github.com/pkg/errors"
 
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
 	"github.com/tizianocitro/hood-framework/alliances/all-data/server/command"
 )
 
@@ -19,6 +20,8 @@ func (p *Plugin) ExecuteCommand(c *plugin.Context, args *model.CommandArgs) (*mo
 	return p.executeGetOrganizationURLCommand(args), nil */
 	case command.ResetUserOrganizationCommandName:
 		return p.executeResetUserOrganizationCommand(args), nil
+	case command.HoodCommandName:
+		return p.executeHoodCommand(args), nil
 	default:
 		return &model.CommandResponse{
 			ResponseType: model.CommandResponseTypeEphemeral,
@@ -65,6 +68,12 @@ func (p *Plugin) executeGetOrganizationURLCommand(args *model.CommandArgs) *mode
 
 func (p *Plugin) executeResetUserOrganizationCommand(args *model.CommandArgs) *model.CommandResponse {
 	serverConfig := p.API.GetConfig()
+	if !p.authorizationService.IsPrivileged(args.UserId, args.TeamId) {
+		return &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "You are not allowed to reset user organizations.",
+		}
+	}
 
 	return command.OpenDialogResetUserOrganizationRequest(&command.ResetUserOrganizationDialogConfig{
 		Args: args,
@@ -79,6 +88,86 @@ func (p *Plugin) executeResetUserOrganizationCommand(args *model.CommandArgs) *m
 	})
 }
 
+func (p *Plugin) executeHoodCommand(args *model.CommandArgs) *model.CommandResponse {
+	hoodArgs := command.GetHoodArgs(args)
+	if len(hoodArgs) == 0 {
+		return command.HelpHoodResponse()
+	}
+	action := ""
+	if len(hoodArgs) > 1 {
+		action = hoodArgs[1]
+	}
+
+	var text string
+	var err error
+	switch {
+	case hoodArgs[0] == command.HoodOrgCommand && action == command.HoodListAction:
+		text, err = p.commandService.ListOrganizations()
+	case hoodArgs[0] == command.HoodSectionCommand && action == command.HoodListAction:
+		text, err = p.commandService.ListSections(strings.Join(hoodArgs[2:], " "))
+	case hoodArgs[0] == command.HoodLinkCommand && len(hoodArgs) > 1:
+		if text, err = p.commandService.GetLink(hoodArgs[1]); err == nil {
+			return &model.CommandResponse{
+				ResponseType: model.CommandResponseTypeInChannel,
+				Text:         text,
+			}
+		}
+	case hoodArgs[0] == command.HoodFindCommand && len(hoodArgs) > 1:
+		var found bool
+		if text, found, err = p.commandService.FindLink(args.UserId, strings.Join(hoodArgs[1:], " ")); err == nil && found {
+			return &model.CommandResponse{
+				ResponseType: model.CommandResponseTypeInChannel,
+				Text:         text,
+			}
+		}
+	case hoodArgs[0] == command.HoodBacklinksCommand && len(hoodArgs) > 1:
+		text, err = p.commandService.ListBacklinks(args.UserId, args.TeamId, hoodArgs[1])
+	case hoodArgs[0] == command.HoodExportCommand:
+		text, err = p.commandService.StartExport(args.UserId, args.ChannelId, action)
+	case hoodArgs[0] == command.HoodIssueCommand && action == command.HoodCreateAction:
+		if !p.authorizationService.IsPrivileged(args.UserId, args.TeamId) {
+			return command.EphemeralResponse("You are not allowed to create issues in this team.")
+		}
+		serverConfig := p.API.GetConfig()
+		return command.OpenDialogHoodIssueCreateRequest(&command.HoodIssueCreateDialogConfig{
+			Args: args,
+			PluginConfig: command.PluginConfig{
+				PathPrefix: "plugins",
+				PluginID:   p.pluginID,
+				SiteURL:    *serverConfig.ServiceSettings.SiteURL,
+				PluginAPI: command.PluginAPI{
+					API: p.API,
+				},
+			},
+		})
+	case hoodArgs[0] == command.HoodGraphCommand && action == command.HoodLockStatusAction:
+		text, err = p.commandService.GetGraphLockStatus()
+	default:
+		return command.HelpHoodResponse()
+	}
+	if err != nil {
+		p.API.LogWarn("Failed to execute hood command", "command", args.Command, "err", err)
+		return command.EphemeralResponse(hoodCommandErrorMessage(err))
+	}
+	return command.EphemeralResponse(text)
+}
+
+// Explains a failed hood command to the user, without leaking internal errors
+func hoodCommandErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, app.ErrInvalidInput):
+		return fmt.Sprintf("Invalid command: %s.", strings.TrimSuffix(err.Error(), ": "+app.ErrInvalidInput.Error()))
+	case errors.Is(err, app.ErrNotFound):
+		return "Nothing found, check the organization, section or element you provided."
+	case errors.Is(err, app.ErrTooManyAttempts):
+		return "Too many unauthorized attempts, try again later."
+	case errors.Is(err, app.ErrForbidden):
+		return "You are not allowed to do this."
+	default:
+		return "Something went wrong, try again later."
+	}
+}
+
 func (p *Plugin) registerCommands() error {
 	/* if err := p.API.RegisterCommand(command.GetOrganizationURLCommand()); err != nil {
 		return errors.Wrapf(err, "failed to register %s command", command.GetOrganizationURLCommandName)
@@ -86,6 +175,9 @@ func (p *Plugin) registerCommands() error {
 	if err := p.API.RegisterCommand(command.ResetUserOrganizationCommand()); err != nil {
 		return errors.Wrapf(err, "failed to register %s command", command.ResetUserOrganizationCommandName)
 	}
+	if err := p.API.RegisterCommand(command.HoodCommand(fmt.Sprintf("/plugins/%s/api/v0", p.pluginID), app.GetExportFormats())); err != nil {
+		return errors.Wrapf(err, "failed to register %s command", command.HoodCommandName)
+	}
 
 	return nil
 }
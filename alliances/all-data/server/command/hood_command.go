@@ -0,0 +1,203 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+const (
+	HoodCommandName     = "hood"
+	HoodIssueCreatePath = "/" + hoodIssueCreateEndpoint
+
+	hoodDisplayName = "HOOD"
+	hoodDesc        = "Navigate the organizations, sections and elements of the platform."
+
+	hoodIssueCreateEndpoint       = "hood_issue_create"
+	hoodIssueCreateCallback       = "handleHoodIssueCreate"
+	hoodIssueCreateNotifyOnCancel = false
+	hoodIssueCreateSubmitLabel    = "Create"
+	hoodIssueCreateTitle          = "Create an ecosystem issue"
+
+	hoodIssueNameFieldName       = "name"
+	hoodIssueObjectivesFieldName = "objectivesAndResearchArea"
+)
+
+// Subcommands of the hood command
+const (
+	HoodOrgCommand       = "org"
+	HoodSectionCommand   = "section"
+	HoodLinkCommand      = "link"
+	HoodFindCommand      = "find"
+	HoodBacklinksCommand = "backlinks"
+	HoodExportCommand    = "export"
+	HoodIssueCommand     = "issue"
+	HoodGraphCommand     = "graph"
+	HoodHelpCommand      = "help"
+
+	HoodListAction       = "list"
+	HoodCreateAction     = "create"
+	HoodLockStatusAction = "lock-status"
+)
+
+// Paths, relative to the plugin API, serving the dynamic lists of the autocomplete
+const (
+	HoodOrganizationsAutocompletePath = "/commands/autocomplete/organizations"
+	HoodElementsAutocompletePath      = "/commands/autocomplete/elements"
+	HoodLinksAutocompletePath         = "/commands/autocomplete/links"
+)
+
+const hoodHelpResponse = "###### " + hoodDesc + "\n" +
+	"- `/hood org list` - List the organizations.\n" +
+	"- `/hood section list [organization]` - List the sections of an organization.\n" +
+	"- `/hood link [element]` - Post a link to an organization, a section or an element.\n" +
+	"- `/hood find [name]` - Post a link to the organization, section, issue, chart data point or graph node with the given name.\n" +
+	"- `/hood backlinks [url]` - List the posts linking an element.\n" +
+	"- `/hood export [format]` - Export this channel, the bot sends you the export once done.\n" +
+	"- `/hood issue create` - Open a dialog to create an ecosystem issue.\n" +
+	"- `/hood graph lock-status` - Show whether someone is editing the ecosystem graph.\n" +
+	"- `/hood help` - Show this help text."
+
+type HoodIssueCreateDialogConfig struct {
+	PluginConfig
+	Args *model.CommandArgs
+}
+
+// HoodIssueCreateSubmission holds the values submitted through the issue creation dialog
+type HoodIssueCreateSubmission struct {
+	Name                      string
+	ObjectivesAndResearchArea string
+}
+
+// HoodCommand returns the hood command. Dynamic lists are fetched from the plugin API at the given path, e.g. /plugins/{pluginId}/api/v0.
+func HoodCommand(apiPath string, exportFormats []string) *model.Command {
+	return &model.Command{
+		AutoComplete:     true,
+		AutocompleteData: getHoodAutocompleteData(apiPath, exportFormats),
+		AutoCompleteDesc: hoodDesc,
+		DisplayName:      hoodDisplayName,
+		Trigger:          HoodCommandName,
+	}
+}
+
+func HelpHoodResponse() *model.CommandResponse {
+	return EphemeralResponse(hoodHelpResponse)
+}
+
+func EphemeralResponse(text string) *model.CommandResponse {
+	return &model.CommandResponse{
+		ResponseType: model.CommandResponseTypeEphemeral,
+		Text:         text,
+	}
+}
+
+// GetHoodArgs splits the arguments following the hood trigger
+func GetHoodArgs(args *model.CommandArgs) []string {
+	fields := strings.Fields(args.Command)
+	if len(fields) < 2 {
+		return []string{}
+	}
+	return fields[1:]
+}
+
+func OpenDialogHoodIssueCreateRequest(config *HoodIssueCreateDialogConfig) *model.CommandResponse {
+	API := config.API
+	openDialogRequest := model.OpenDialogRequest{
+		Dialog:    hoodIssueCreateDialog(),
+		TriggerId: config.Args.TriggerId,
+		URL:       fmt.Sprintf("%s/%s/%s/%s", config.SiteURL, config.PathPrefix, config.PluginID, hoodIssueCreateEndpoint),
+	}
+	if err := API.OpenInteractiveDialog(openDialogRequest); err != nil {
+		errorMessage := fmt.Sprintf("Failed to open the interactive dialog for %s %s %s command", HoodCommandName, HoodIssueCommand, HoodCreateAction)
+		API.LogError(errorMessage, "err", err.Error())
+		return EphemeralResponse(errorMessage)
+	}
+	return &model.CommandResponse{}
+}
+
+// Gets the values submitted through the issue creation dialog
+func GetHoodIssueCreateSubmission(request *model.SubmitDialogRequest) (HoodIssueCreateSubmission, error) {
+	name, ok := request.Submission[hoodIssueNameFieldName].(string)
+	if !ok || strings.TrimSpace(name) == "" {
+		return HoodIssueCreateSubmission{}, fmt.Errorf("request is missing field %s", hoodIssueNameFieldName)
+	}
+	objectives, _ := request.Submission[hoodIssueObjectivesFieldName].(string)
+	return HoodIssueCreateSubmission{
+		Name:                      strings.TrimSpace(name),
+		ObjectivesAndResearchArea: objectives,
+	}, nil
+}
+
+func getHoodAutocompleteData(apiPath string, exportFormats []string) *model.AutocompleteData {
+	command := model.NewAutocompleteData(HoodCommandName, "[command]", hoodDesc)
+
+	org := model.NewAutocompleteData(HoodOrgCommand, "[action]", "Organizations of the platform.")
+	org.AddCommand(model.NewAutocompleteData(HoodListAction, "", "List the organizations."))
+	command.AddCommand(org)
+
+	section := model.NewAutocompleteData(HoodSectionCommand, "[action]", "Sections of the organizations.")
+	sectionList := model.NewAutocompleteData(HoodListAction, "[organization]", "List the sections of an organization.")
+	sectionList.AddDynamicListArgument("The organization", apiPath+HoodOrganizationsAutocompletePath, true)
+	section.AddCommand(sectionList)
+	command.AddCommand(section)
+
+	linkCommand := model.NewAutocompleteData(HoodLinkCommand, "[element]", "Post a link to an organization, a section or an element.")
+	linkCommand.AddDynamicListArgument("The element to link, type the key of a section to list its elements", apiPath+HoodElementsAutocompletePath, true)
+	command.AddCommand(linkCommand)
+
+	find := model.NewAutocompleteData(HoodFindCommand, "[name]", "Post a link to an element found by name.")
+	find.AddDynamicListArgument("Type [ followed by the name of an organization, a section, an issue, a chart data point or a graph node", apiPath+HoodLinksAutocompletePath, true)
+	command.AddCommand(find)
+
+	backlinks := model.NewAutocompleteData(HoodBacklinksCommand, "[url]", "List the posts linking an element.")
+	backlinks.AddTextArgument("The URL of the element", "[url]", "")
+	command.AddCommand(backlinks)
+
+	export := model.NewAutocompleteData(HoodExportCommand, "[format]", "Export this channel.")
+	formats := []model.AutocompleteListItem{}
+	for _, format := range exportFormats {
+		formats = append(formats, model.AutocompleteListItem{Item: format, HelpText: fmt.Sprintf("Export as %s", format)})
+	}
+	export.AddStaticListArgument("The export format, markdown by default", false, formats)
+	command.AddCommand(export)
+
+	issue := model.NewAutocompleteData(HoodIssueCommand, "[action]", "Ecosystem issues.")
+	issue.AddCommand(model.NewAutocompleteData(HoodCreateAction, "", "Open a dialog to create an ecosystem issue."))
+	command.AddCommand(issue)
+
+	graph := model.NewAutocompleteData(HoodGraphCommand, "[action]", "Ecosystem graph.")
+	graph.AddCommand(model.NewAutocompleteData(HoodLockStatusAction, "", "Show whether someone is editing the ecosystem graph."))
+	command.AddCommand(graph)
+
+	command.AddCommand(model.NewAutocompleteData(HoodHelpCommand, "", "Show the help text."))
+	return command
+}
+
+func hoodIssueCreateDialog() model.Dialog {
+	return model.Dialog{
+		CallbackId:     hoodIssueCreateCallback,
+		Elements:       hoodIssueCreateDialogElements(),
+		NotifyOnCancel: hoodIssueCreateNotifyOnCancel,
+		SubmitLabel:    hoodIssueCreateSubmitLabel,
+		Title:          hoodIssueCreateTitle,
+	}
+}
+
+func hoodIssueCreateDialogElements() []model.DialogElement {
+	return []model.DialogElement{
+		{
+			DisplayName: "Name",
+			Name:        hoodIssueNameFieldName,
+			Type:        "text",
+			Placeholder: "Name of the issue.",
+			MaxLength:   64,
+		},
+		{
+			DisplayName: "Objectives and research area",
+			Name:        hoodIssueObjectivesFieldName,
+			Type:        "textarea",
+			Optional:    true,
+		},
+	}
+}
@@ -1,27 +1,23 @@
 package command
 
 import (
-	"encoding/base64"
 	"fmt"
 
-	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
-
 	"github.com/mattermost/mattermost-server/v6/model"
 )
 
 const (
-	resetUserOrganizationDisplayName       = "ResetUserOrganization"
-	ResetUserOrganizationCommandName       = "reset_user_organization"
-	resetUserOrganizationCallback          = "handleResetUserOrganization"
-	resetUserOrganizationNotifyOnCancel    = true
-	resetUserOrganizationState             = ""
-	resetUserOrganizationSubmitLabel       = "Confirm"
-	resetUserOrganizationDesc              = "Reset the organization associated to an user."
-	resetUserOrganizationAutoComplete      = true
-	ResetUserOrganizationPath              = "/" + resetUserOrganizationEndpoint
-	resetUserOrganizationEndpoint          = "reset_user_organization"
-	resetUserOrganizationUserFieldName     = "user_field"
-	resetUserOrganizationPasswordFieldName = "password_field"
+	resetUserOrganizationDisplayName    = "ResetUserOrganization"
+	ResetUserOrganizationCommandName    = "reset_user_organization"
+	resetUserOrganizationCallback       = "handleResetUserOrganization"
+	resetUserOrganizationNotifyOnCancel = true
+	resetUserOrganizationState          = ""
+	resetUserOrganizationSubmitLabel    = "Confirm"
+	resetUserOrganizationDesc           = "Reset the organization associated to an user."
+	resetUserOrganizationAutoComplete   = true
+	ResetUserOrganizationPath           = "/" + resetUserOrganizationEndpoint
+	resetUserOrganizationEndpoint       = "reset_user_organization"
+	resetUserOrganizationUserFieldName  = "user_field"
 )
 
 type ResetUserOrganizationDialogConfig struct {
@@ -29,11 +25,6 @@ type ResetUserOrganizationDialogConfig struct {
 	Args *model.CommandArgs
 }
 
-type ResetUserOrganizationConfig struct {
-	PluginConfig
-	UserID string
-}
-
 func ResetUserOrganizationCommand() *model.Command {
 	return &model.Command{
 		AutoComplete:     resetUserOrganizationAutoComplete,
@@ -81,45 +72,14 @@ func resetUserOrganizationDialogElements() []model.DialogElement {
 		Placeholder: "Select a user.",
 		HelpText:    "Choose a user from the list.",
 		DataSource:  "users",
-	}, {
-		DisplayName: "Admin password",
-		Name:        resetUserOrganizationPasswordFieldName,
-		Type:        "text",
-		SubType:     "password",
-		Placeholder: "Password",
-		HelpText:    "Enter the administrator password.",
 	}}
 }
 
-func ResetUserOrganization(pluginConfig *ResetUserOrganizationConfig, config *config.MattermostConfig, request *model.SubmitDialogRequest) error {
+// Gets the user selected in the dialog, whose organization is going to be reset
+func GetResetUserOrganizationTarget(request *model.SubmitDialogRequest) (string, error) {
 	userID, ok := request.Submission[resetUserOrganizationUserFieldName].(string)
-
-	if !ok {
-		return fmt.Errorf("request is missing field %s", resetUserOrganizationUserFieldName)
-	}
-
-	password, ok := request.Submission[resetUserOrganizationPasswordFieldName].(string)
 	if !ok {
-		return fmt.Errorf("request is missing field %s", resetUserOrganizationPasswordFieldName)
-	}
-	pluginConfig.API.LogInfo("checking pw", "pw", password, "adminpw", config.GetConfiguration().AdminPassword)
-
-	if password == "" || base64.StdEncoding.EncodeToString([]byte(password)) != config.GetConfiguration().AdminPassword {
-		return fmt.Errorf("wrong password")
+		return "", fmt.Errorf("request is missing field %s", resetUserOrganizationUserFieldName)
 	}
-
-	user, err := pluginConfig.API.GetUser(userID)
-	if err != nil {
-		return fmt.Errorf("something went wrong with userid %s", userID)
-	}
-	user.SetProp("orgId", "")
-	if _, err := pluginConfig.API.UpdateUser(user); err != nil {
-		return fmt.Errorf("couldn't update user props")
-	}
-
-	pluginConfig.API.SendEphemeralPost(pluginConfig.UserID, &model.Post{
-		Message:   fmt.Sprintf("Organization reset for the user %s successful.", user.Username),
-		ChannelId: request.ChannelId,
-	})
-	return nil
+	return userID, nil
 }
@@ -18,12 +18,23 @@ func NewMattermostConfig(api plugin.API) *MattermostConfig {
 	}
 }
 
+// Modes of the reconciliation of the organization channels members
+const (
+	ReconciliationOff     = "off"
+	ReconciliationDryRun  = "dryRun"
+	ReconciliationEnforce = "enforce"
+)
+
 type Configuration struct {
-	AdminPassword               string
+	PrivilegedRoles             string // Comma separated roles allowed to perform privileged actions, besides system and team admins
+	MembershipReconciliation    string // One of the reconciliation modes, off when empty
 	EcosystemGraph              bool
 	EcosystemGraphAutosave      bool
 	EcosystemGraphAutosaveDelay int
 	EcosystemGraphRSB           bool
+	LinkPreviews                bool // Whether posts linking platform elements get a preview of them
+	MisalignmentDetection       bool // Whether numbers stated next to links to data points are checked against their values
+	MisalignmentTolerance       int  // Percentage a stated number can differ from the value of a data point by
 }
 
 func (c *Configuration) Clone() *Configuration {
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"io/ioutil"
 
 	yaml "gopkg.in/yaml.v3"
@@ -12,6 +13,8 @@ const OrganizationIDAll = "__all"
 type PlatformConfig struct {
 	EnvironmentConfig EnvironmentConfig `json:"environmentConfig" yaml:"environmentConfig"`
 	Organizations     []Organization    `json:"organizations" yaml:"organizations"`
+	SidebarConfig     SidebarConfig     `json:"sidebarConfig" yaml:"sidebarConfig"`
+	IssueChannels     IssueChannels     `json:"issueChannels" yaml:"issueChannels"`
 }
 
 type EnvironmentConfig struct {
@@ -25,6 +28,49 @@ type ShowOptionsConfig struct {
 	ShowDefaultChannels  bool `json:"showDefaultChannels" yaml:"showDefaultChannels"`
 }
 
+// Orders of the channels in the sidebar categories, Mattermost keeps the order channels were added in by default
+const (
+	SidebarSortingAlphabetical = "alphabetical"
+	SidebarSortingRecent       = "recent"
+	SidebarSortingConfig       = "config" // Following the order of the sections in the config
+)
+
+// SidebarConfig declares the sidebar categories set up for each user, on top of the one per organization.
+// Sorting and collapsed states are defaults, applied when a category is created.
+type SidebarConfig struct {
+	Sorting           string               `json:"sorting" yaml:"sorting"`
+	Collapsed         bool                 `json:"collapsed" yaml:"collapsed"`
+	SectionCategories []SectionCategory    `json:"sectionCategories" yaml:"sectionCategories"`
+	PinnedIssues      PinnedIssuesCategory `json:"pinnedIssues" yaml:"pinnedIssues"`
+}
+
+// SectionCategory groups the channels of some sections in a category of their own, apart from their organization category.
+// Each organization gets its own category, named after the organization and the rule.
+type SectionCategory struct {
+	ID         string   `json:"id" yaml:"id"`
+	Name       string   `json:"name" yaml:"name"`
+	SectionIDs []string `json:"sectionIds" yaml:"sectionIds"`
+	Sorting    string   `json:"sorting" yaml:"sorting"`
+	Collapsed  bool     `json:"collapsed" yaml:"collapsed"`
+}
+
+// PinnedIssuesCategory groups the channels of the active ecosystem issues
+type PinnedIssuesCategory struct {
+	Enabled   bool   `json:"enabled" yaml:"enabled"`
+	Name      string `json:"name" yaml:"name"`
+	Sorting   string `json:"sorting" yaml:"sorting"`
+	Collapsed bool   `json:"collapsed" yaml:"collapsed"`
+}
+
+// IssueChannels keeps a channel for each issue of the ecosystem issues sections, polling the provider for new, deleted and restored issues.
+// The polling interval is read when the plugin is activated.
+type IssueChannels struct {
+	Enabled             bool   `json:"enabled" yaml:"enabled"`
+	TeamName            string `json:"teamName" yaml:"teamName"`               // Team the channels are created in
+	PollingInterval     int    `json:"pollingInterval" yaml:"pollingInterval"` // In minutes
+	CreatePublicChannel bool   `json:"createPublicChannel" yaml:"createPublicChannel"`
+}
+
 type Organization struct {
 	IsEcosystem bool      `json:"isEcosystem" yaml:"isEcosystem"`
 	Description string    `json:"description" yaml:"description"`
@@ -43,6 +89,25 @@ type Section struct {
 	CustomView string    `json:"customView" yaml:"customView"`
 	Sections   []Section `json:"sections" yaml:"sections"`
 	Widgets    []Widget  `json:"widgets" yaml:"widgets"`
+
+	ChannelTemplate *ChannelTemplate `json:"channelTemplate,omitempty" yaml:"channelTemplate"`
+}
+
+// ChannelTemplate sets up the channels created for the elements of a section, including ecosystem issues.
+// Header, purpose and messages can use the {{elementLink}}, {{elementUrl}}, {{elementName}}, {{sectionName}} and {{organizationName}} placeholders.
+type ChannelTemplate struct {
+	Header       string      `json:"header" yaml:"header"`
+	Purpose      string      `json:"purpose" yaml:"purpose"`
+	IntroMessage string      `json:"introMessage" yaml:"introMessage"` // Pinned by the bot, followed by links to the widgets of the section
+	Members      []string    `json:"members" yaml:"members"`           // Usernames
+	Roles        []string    `json:"roles" yaml:"roles"`               // Team members with any of these system or team roles join too, e.g. team_admin
+	Checklists   []Checklist `json:"checklists" yaml:"checklists"`
+}
+
+// Checklist is posted and pinned by the bot, with an unchecked task for each item
+type Checklist struct {
+	Title string   `json:"title" yaml:"title"`
+	Items []string `json:"items" yaml:"items"`
 }
 
 type Widget struct {
@@ -61,9 +126,27 @@ func getPlatformConfig(filepath string) (*PlatformConfig, error) {
 	if err = yaml.Unmarshal(yamlFile, config); err != nil {
 		return nil, err
 	}
+	if err = config.SidebarConfig.validate(); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
 
+// Rule ids identify the categories of the rules, so they must be unique and not empty, which is the id of the organization category
+func (c SidebarConfig) validate() error {
+	ruleIDs := make(map[string]bool, len(c.SectionCategories))
+	for i, rule := range c.SectionCategories {
+		if rule.ID == "" {
+			return fmt.Errorf("sidebar section category %d has no id", i)
+		}
+		if ruleIDs[rule.ID] {
+			return fmt.Errorf("sidebar section category id %s is not unique", rule.ID)
+		}
+		ruleIDs[rule.ID] = true
+	}
+	return nil
+}
+
 // Utility to get the ecosystem organization. We assume only one exists in the config channel.
 func (p PlatformConfig) GetEcosystem() (*Organization, bool) {
 	for _, organization := range p.Organizations {
@@ -2,8 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/mattermost/mattermost-server/v6/model"
 
@@ -48,7 +51,6 @@ func (p *Plugin) handleGetOrganizationURL(w http.ResponseWriter, r *http.Request
 }
 
 func (p *Plugin) handleResetUserOrganization(w http.ResponseWriter, r *http.Request) {
-	serverConfig := p.API.GetConfig()
 	request, err := p.getDialogRequestFromBody(r)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -65,28 +67,91 @@ func (p *Plugin) handleResetUserOrganization(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err := command.ResetUserOrganization(&command.ResetUserOrganizationConfig{
-		UserID: userID,
-		PluginConfig: command.PluginConfig{
-			PathPrefix: p.pluginURLPathPrefix,
-			PluginID:   p.pluginID,
-			SiteURL:    *serverConfig.ServiceSettings.SiteURL,
-			PluginAPI: command.PluginAPI{
-				API: p.API,
-			},
-		},
-	}, p.configuration, request); err != nil {
-		w.Header().Set("Content-Type", "application/json")
+	targetUserID, err := command.GetResetUserOrganizationTarget(request)
+	if err != nil {
+		p.returnDialogError(w, "Select a user.")
+		return
+	}
+	if err := p.membershipService.ResetUserOrganization(userID, request.TeamId, targetUserID); err != nil {
+		p.API.LogWarn("Failed to reset user organization", "userId", targetUserID, "err", err)
+		switch {
+		case errors.Is(err, app.ErrTooManyAttempts):
+			p.returnDialogError(w, "Too many unauthorized attempts, try again later.")
+		case errors.Is(err, app.ErrForbidden):
+			p.returnDialogError(w, "You are not allowed to reset user organizations.")
+		default:
+			p.returnDialogError(w, "Unable to reset the organization of the user.")
+		}
+		return
+	}
+
+	p.API.SendEphemeralPost(userID, &model.Post{
+		ChannelId: request.ChannelId,
+		Message:   fmt.Sprintf("Organization reset for the user %s successful.", p.getUserName(targetUserID)),
+	})
+	p.completeRequest(w)
+}
+
+func (p *Plugin) handleHoodIssueCreate(w http.ResponseWriter, r *http.Request) {
+	request, err := p.getDialogRequestFromBody(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	userID, err := p.getUserIDByUserRequestID(request)
+	if err != nil {
 		w.WriteHeader(http.StatusOK)
-		_ = json.NewEncoder(w).Encode(map[string]interface{}{
-			"error": "Wrong password.",
-		})
 		return
 	}
 
+	if p.isRequestCanceled(request) {
+		return
+	}
+
+	submission, err := command.GetHoodIssueCreateSubmission(request)
+	if err != nil {
+		p.returnDialogError(w, "Provide a name for the issue.")
+		return
+	}
+	result, err := p.commandService.CreateIssue(userID, app.CreateIssueParams{
+		TeamID:                    request.TeamId,
+		Name:                      submission.Name,
+		ObjectivesAndResearchArea: submission.ObjectivesAndResearchArea,
+	})
+	if err != nil {
+		p.API.LogWarn("Failed to create issue", "err", err)
+		switch {
+		case errors.Is(err, app.ErrTooManyAttempts):
+			p.returnDialogError(w, "Too many unauthorized attempts, try again later.")
+		case errors.Is(err, app.ErrForbidden):
+			p.returnDialogError(w, "You are not allowed to create issues in this team.")
+		case errors.Is(err, app.ErrInvalidInput):
+			p.returnDialogError(w, strings.TrimSuffix(err.Error(), ": "+app.ErrInvalidInput.Error()))
+		case errors.Is(err, app.ErrNotFound):
+			p.returnDialogError(w, "The platform has no ecosystem issues section.")
+		default:
+			p.returnDialogError(w, "Unable to create the issue.")
+		}
+		return
+	}
+
+	p.API.SendEphemeralPost(userID, &model.Post{
+		ChannelId: request.ChannelId,
+		Message:   fmt.Sprintf("Created issue [%s](%s) and its channel ~%s.", submission.Name, result.URL, p.getChannelName(result.ChannelID)),
+	})
 	p.completeRequest(w)
 }
 
+// Shows an error in the interactive dialog, keeping it open
+func (p *Plugin) returnDialogError(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": message,
+	})
+}
+
 func (p *Plugin) getDialogRequestFromBody(r *http.Request) (*model.SubmitDialogRequest, error) {
 	request := &model.SubmitDialogRequest{}
 	body, err := io.ReadAll(r.Body)
@@ -123,3 +188,19 @@ func (p *Plugin) completeRequest(w http.ResponseWriter) {
 	p.API.LogInfo("Completed request")
 	w.WriteHeader(http.StatusOK)
 }
+
+func (p *Plugin) getUserName(userID string) string {
+	user, appErr := p.API.GetUser(userID)
+	if appErr != nil {
+		return userID
+	}
+	return user.Username
+}
+
+func (p *Plugin) getChannelName(channelID string) string {
+	channel, appErr := p.API.GetChannel(channelID)
+	if appErr != nil {
+		return channelID
+	}
+	return channel.Name
+}
@@ -0,0 +1,209 @@
+package link
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+)
+
+var (
+	fencedCodeRegex     = regexp.MustCompile("(?ms)^ {0,3}(```|~~~).*?(^ {0,3}(```|~~~)[ \t]*$|\\z)")
+	inlineCodeRegex     = regexp.MustCompile("`[^`\n]+`")
+	definitionRegex     = regexp.MustCompile(`(?m)^ {0,3}\[([^\]\n]+)\]:[ \t]*<?([^\s>]+)>?(?:[ \t]+(?:"[^"\n]*"|'[^'\n]*'|\([^)\n]*\)))?[ \t]*$`)
+	inlineLinkRegex     = regexp.MustCompile(`(!?)\[([^\]\n]*)\]\([ \t]*<?([^\s<>]+?)>?(?:[ \t]+(?:"[^"\n]*"|'[^'\n]*'))?[ \t]*\)`)
+	referenceLinkRegex  = regexp.MustCompile(`(!?)\[([^\]\n]+)\](?:\[([^\]\n]*)\])?`)
+	autolinkRegex       = regexp.MustCompile(`<((?:https?|ftp)://[^\s<>]+)>`)
+	bareURLRegex        = regexp.MustCompile(`(?:https?|ftp)://[^\s<>]+`)
+	trailingPunctuation = ".,:;!?*_~'\""
+)
+
+// Link is a link found in a message, with the text it is rendered with.
+// Text is empty when the link is rendered as the URL itself, e.g. for bare URLs and autolinks.
+type Link struct {
+	Text string
+	URL  string
+}
+
+// Span is a link found in a message, along with the byte offsets of the markdown rendering it.
+type Span struct {
+	Link
+	Start int
+	End   int
+}
+
+// ExtractLinks returns the links in a markdown message in order of appearance, in every form Mattermost renders them:
+// inline links, reference-style links, autolinks and bare URLs. Links in code spans and code blocks are ignored, as well as images.
+func ExtractLinks(message string) []Link {
+	spans := ExtractSpans(message)
+	links := make([]Link, 0, len(spans))
+	for _, span := range spans {
+		links = append(links, span.Link)
+	}
+	return links
+}
+
+// ExtractSpans returns the links in a markdown message as ExtractLinks does, along with where they are in the message.
+func ExtractSpans(message string) []Span {
+	text := []byte(message)
+	mask(text, fencedCodeRegex.FindAllIndex(text, -1))
+	mask(text, inlineCodeRegex.FindAllIndex(text, -1))
+
+	links := []Span{}
+	definitions := map[string]string{}
+	for _, match := range definitionRegex.FindAllSubmatchIndex(text, -1) {
+		label := normalizeLabel(string(text[match[2]:match[3]]))
+		if _, found := definitions[label]; !found {
+			definitions[label] = string(text[match[4]:match[5]])
+		}
+		mask(text, [][]int{match[0:2]})
+	}
+
+	for _, match := range inlineLinkRegex.FindAllSubmatchIndex(text, -1) {
+		if match[3] == match[2] {
+			links = append(links, newSpan(string(text[match[4]:match[5]]), string(text[match[6]:match[7]]), match[0], match[1]))
+		}
+		mask(text, [][]int{match[0:2]})
+	}
+
+	for _, match := range referenceLinkRegex.FindAllSubmatchIndex(text, -1) {
+		linkText := string(text[match[4]:match[5]])
+		label := linkText
+		if match[6] != -1 && match[7] > match[6] {
+			label = string(text[match[6]:match[7]])
+		}
+		url, found := definitions[normalizeLabel(label)]
+		if !found {
+			continue
+		}
+		if match[3] == match[2] {
+			links = append(links, newSpan(linkText, url, match[0], match[1]))
+		}
+		mask(text, [][]int{match[0:2]})
+	}
+
+	for _, match := range autolinkRegex.FindAllSubmatchIndex(text, -1) {
+		links = append(links, newSpan("", string(text[match[2]:match[3]]), match[0], match[1]))
+		mask(text, [][]int{match[0:2]})
+	}
+
+	for _, match := range bareURLRegex.FindAllIndex(text, -1) {
+		url := trimBareURL(string(text[match[0]:match[1]]))
+		links = append(links, newSpan("", url, match[0], match[0]+len(url)))
+	}
+
+	sort.SliceStable(links, func(i, j int) bool {
+		return links[i].Start < links[j].Start
+	})
+	return links
+}
+
+// ExtractPostLinks returns the links in the message of a post, followed by the ones in its message attachments and other props.
+func ExtractPostLinks(post *mattermost.Post) []Link {
+	links := ExtractLinks(post.Message)
+	for _, attachment := range post.Attachments() {
+		links = append(links, extractAttachmentLinks(attachment)...)
+	}
+
+	keys := make([]string, 0, len(post.GetProps()))
+	for key := range post.GetProps() {
+		if key != "attachments" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		links = append(links, extractValueLinks(post.GetProp(key))...)
+	}
+	return links
+}
+
+func extractAttachmentLinks(attachment *mattermost.SlackAttachment) []Link {
+	links := []Link{}
+	if attachment == nil {
+		return links
+	}
+	if attachment.TitleLink != "" {
+		links = append(links, newLink(attachment.Title, attachment.TitleLink))
+	}
+	if attachment.AuthorLink != "" {
+		links = append(links, newLink(attachment.AuthorName, attachment.AuthorLink))
+	}
+	for _, text := range []string{attachment.Pretext, attachment.Title, attachment.Text, attachment.Footer} {
+		links = append(links, ExtractLinks(text)...)
+	}
+	for _, field := range attachment.Fields {
+		if field != nil {
+			links = append(links, ExtractLinks(fmt.Sprint(field.Value))...)
+		}
+	}
+	return links
+}
+
+func extractValueLinks(value interface{}) []Link {
+	links := []Link{}
+	switch typedValue := value.(type) {
+	case string:
+		links = append(links, ExtractLinks(typedValue)...)
+	case []interface{}:
+		for _, item := range typedValue {
+			links = append(links, extractValueLinks(item)...)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(typedValue))
+		for key := range typedValue {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			links = append(links, extractValueLinks(typedValue[key])...)
+		}
+	}
+	return links
+}
+
+func newLink(text, url string) Link {
+	text = strings.TrimSpace(text)
+	if text == url {
+		text = ""
+	}
+	return Link{Text: text, URL: url}
+}
+
+func newSpan(text, url string, start, end int) Span {
+	return Span{Link: newLink(text, url), Start: start, End: end}
+}
+
+// Removes the trailing punctuation that is not considered part of a bare URL, as well as unbalanced closing parentheses
+func trimBareURL(url string) string {
+	for url != "" {
+		last := url[len(url)-1]
+		if strings.IndexByte(trailingPunctuation, last) != -1 {
+			url = url[:len(url)-1]
+			continue
+		}
+		if last == ')' && strings.Count(url, ")") > strings.Count(url, "(") {
+			url = url[:len(url)-1]
+			continue
+		}
+		break
+	}
+	return url
+}
+
+func normalizeLabel(label string) string {
+	return strings.ToLower(strings.Join(strings.Fields(label), " "))
+}
+
+// Replaces the given spans with spaces, so that they are not matched again
+func mask(text []byte, spans [][]int) {
+	for _, span := range spans {
+		for i := span[0]; i < span[1]; i++ {
+			if text[i] != '\n' {
+				text[i] = ' '
+			}
+		}
+	}
+}
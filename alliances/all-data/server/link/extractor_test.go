@@ -0,0 +1,195 @@
+package link_test
+
+import (
+	"testing"
+
+	mattermost "github.com/mattermost/mattermost-server/v6/model"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+const elementURL = "http://localhost:8065/alliances/organizations/1/issues/abc?parentId=10"
+
+// Tests for the links extraction from markdown messages.
+func TestExtractLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		message  string
+		expected []link.Link
+	}{
+		{
+			name:     "no links",
+			message:  "just some text",
+			expected: []link.Link{},
+		},
+		{
+			name:     "inline link",
+			message:  "see [the issue](" + elementURL + ") for details",
+			expected: []link.Link{{Text: "the issue", URL: elementURL}},
+		},
+		{
+			name:     "inline link with title",
+			message:  "see [the issue](" + elementURL + " \"Issue\")",
+			expected: []link.Link{{Text: "the issue", URL: elementURL}},
+		},
+		{
+			name:     "inline link with empty text",
+			message:  "see [](" + elementURL + ")",
+			expected: []link.Link{{Text: "", URL: elementURL}},
+		},
+		{
+			name:     "inline link with the url as text",
+			message:  "see [" + elementURL + "](" + elementURL + ")",
+			expected: []link.Link{{Text: "", URL: elementURL}},
+		},
+		{
+			name:     "bare url",
+			message:  "see " + elementURL + " for details",
+			expected: []link.Link{{Text: "", URL: elementURL}},
+		},
+		{
+			name:     "bare url followed by punctuation",
+			message:  "have you seen " + elementURL + "?",
+			expected: []link.Link{{Text: "", URL: elementURL}},
+		},
+		{
+			name:     "bare url in parentheses",
+			message:  "the issue (" + elementURL + ") is open",
+			expected: []link.Link{{Text: "", URL: elementURL}},
+		},
+		{
+			name:     "bare url with fragment",
+			message:  elementURL + "#table-row-5",
+			expected: []link.Link{{Text: "", URL: elementURL + "#table-row-5"}},
+		},
+		{
+			name:     "autolink",
+			message:  "see <" + elementURL + ">",
+			expected: []link.Link{{Text: "", URL: elementURL}},
+		},
+		{
+			name:     "full reference link",
+			message:  "see [the issue][issue]\n\n[issue]: " + elementURL,
+			expected: []link.Link{{Text: "the issue", URL: elementURL}},
+		},
+		{
+			name:     "collapsed reference link",
+			message:  "see [Issue][]\n\n[issue]: <" + elementURL + "> \"Issue\"",
+			expected: []link.Link{{Text: "Issue", URL: elementURL}},
+		},
+		{
+			name:     "shortcut reference link",
+			message:  "see [issue]\n\n[issue]: " + elementURL,
+			expected: []link.Link{{Text: "issue", URL: elementURL}},
+		},
+		{
+			name:     "reference link without definition",
+			message:  "see [issue] and [issue][other]",
+			expected: []link.Link{},
+		},
+		{
+			name:     "image",
+			message:  "![chart](" + elementURL + ")",
+			expected: []link.Link{},
+		},
+		{
+			name:     "inline code",
+			message:  "run `curl " + elementURL + "`",
+			expected: []link.Link{},
+		},
+		{
+			name:     "code block",
+			message:  "```\n[issue](" + elementURL + ")\n```\nafter " + elementURL,
+			expected: []link.Link{{Text: "", URL: elementURL}},
+		},
+		{
+			name:    "multiple links in order of appearance",
+			message: "<http://a.com/x> then [b](http://b.com/y) then http://c.com/z and [d]\n[d]: http://d.com/w",
+			expected: []link.Link{
+				{Text: "", URL: "http://a.com/x"},
+				{Text: "b", URL: "http://b.com/y"},
+				{Text: "", URL: "http://c.com/z"},
+				{Text: "d", URL: "http://d.com/w"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, link.ExtractLinks(test.message))
+		})
+	}
+}
+
+// Tests for the positions of the links extracted from markdown messages.
+func TestExtractSpans(t *testing.T) {
+	message := "rose to [12](http://b.com/y) as in http://c.com/z."
+	expected := []link.Span{
+		{Link: link.Link{Text: "12", URL: "http://b.com/y"}, Start: 8, End: 28},
+		{Link: link.Link{Text: "", URL: "http://c.com/z"}, Start: 35, End: 49},
+	}
+	spans := link.ExtractSpans(message)
+	assert.Equal(t, expected, spans)
+	assert.Equal(t, "[12](http://b.com/y)", message[spans[0].Start:spans[0].End])
+	assert.Equal(t, "http://c.com/z", message[spans[1].Start:spans[1].End])
+}
+
+// Tests for the links extraction from posts, including attachments and props.
+func TestExtractPostLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		post     func() *mattermost.Post
+		expected []link.Link
+	}{
+		{
+			name: "message only",
+			post: func() *mattermost.Post {
+				return &mattermost.Post{Message: "see " + elementURL}
+			},
+			expected: []link.Link{{Text: "", URL: elementURL}},
+		},
+		{
+			name: "attachment title link and text",
+			post: func() *mattermost.Post {
+				post := &mattermost.Post{}
+				mattermost.ParseSlackAttachment(post, []*mattermost.SlackAttachment{{
+					Title:     "Issue",
+					TitleLink: elementURL,
+					Text:      "related to [chart](http://localhost:8065/alliances/organizations/1/charts)",
+				}})
+				return post
+			},
+			expected: []link.Link{
+				{Text: "Issue", URL: elementURL},
+				{Text: "chart", URL: "http://localhost:8065/alliances/organizations/1/charts"},
+			},
+		},
+		{
+			name: "attachment fields",
+			post: func() *mattermost.Post {
+				post := &mattermost.Post{}
+				mattermost.ParseSlackAttachment(post, []*mattermost.SlackAttachment{{
+					Fields: []*mattermost.SlackAttachmentField{{Title: "Element", Value: "<" + elementURL + ">"}},
+				}})
+				return post
+			},
+			expected: []link.Link{{Text: "", URL: elementURL}},
+		},
+		{
+			name: "nested props",
+			post: func() *mattermost.Post {
+				post := &mattermost.Post{}
+				post.AddProp("evidence", map[string]interface{}{"links": []interface{}{elementURL}})
+				return post
+			},
+			expected: []link.Link{{Text: "", URL: elementURL}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, link.ExtractPostLinks(test.post()))
+		})
+	}
+}
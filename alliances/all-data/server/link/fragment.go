@@ -0,0 +1,119 @@
+package link
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FragmentType is the type of widget, or widget item, referenced by the hash of a HOOD URL.
+type FragmentType string
+
+const (
+	FragmentSectionInfo       FragmentType = "sectionInfo"
+	FragmentSectionLink       FragmentType = "sectionLink"
+	FragmentWidget            FragmentType = "widget"
+	FragmentTableRow          FragmentType = "tableRow"
+	FragmentPaginatedTableRow FragmentType = "paginatedTableRow"
+	FragmentTimelineItem      FragmentType = "timelineItem"
+	FragmentBarCell           FragmentType = "barCell"
+	FragmentLineDot           FragmentType = "lineDot"
+	FragmentGraphNode         FragmentType = "graphNode"
+	FragmentUnknown           FragmentType = "unknown"
+)
+
+// Hash prefixes and suffixes used by the webapp to build the ids of the hyperlinkable items, see buildIdForUrlHashReference
+const (
+	sectionInfoPrefix       = "_"
+	sectionLinkPrefix       = "section-link-"
+	tableRowPrefix          = "table-row-"
+	paginatedTableRowPrefix = "paginated-table-row-"
+	timelineItemPrefix      = "timeline-item-"
+	barCellPrefix           = "cell-"
+	lineDotPrefix           = "dot-"
+	widgetSuffix            = "-widget"
+)
+
+// Fragment is the widget, or the single data point of a widget, referenced by the hash of a HOOD URL.
+type Fragment struct {
+	Type  FragmentType `json:"type"`
+	ID    string       `json:"id"`
+	Label string       `json:"label,omitempty"` // Only set for line chart dots
+	Value string       `json:"value,omitempty"` // Only set for line chart dots
+	Raw   string       `json:"raw"`
+}
+
+// ParseFragment classifies a URL hash. The reference the hash belongs to is used to recognize graph nodes.
+func ParseFragment(hash string, reference Reference) *Fragment {
+	hash = strings.TrimPrefix(hash, "#")
+	fragment := &Fragment{Type: FragmentUnknown, ID: hash, Raw: hash}
+
+	switch {
+	case strings.HasPrefix(hash, sectionInfoPrefix):
+		fragment.Type = FragmentSectionInfo
+		fragment.ID = strings.TrimPrefix(hash, sectionInfoPrefix)
+	case strings.HasPrefix(hash, sectionLinkPrefix):
+		fragment.Type = FragmentSectionLink
+		fragment.ID = strings.TrimPrefix(hash, sectionLinkPrefix)
+	case strings.HasPrefix(hash, paginatedTableRowPrefix):
+		fragment.Type = FragmentPaginatedTableRow
+		fragment.ID = strings.TrimPrefix(hash, paginatedTableRowPrefix)
+	case strings.HasPrefix(hash, tableRowPrefix):
+		fragment.Type = FragmentTableRow
+		fragment.ID = strings.TrimPrefix(hash, tableRowPrefix)
+	case strings.HasPrefix(hash, timelineItemPrefix):
+		fragment.Type = FragmentTimelineItem
+		fragment.ID = strings.TrimPrefix(hash, timelineItemPrefix)
+	case strings.HasSuffix(hash, widgetSuffix):
+		fragment.Type = FragmentWidget
+		fragment.ID = strings.TrimSuffix(hash, widgetSuffix)
+	case strings.HasPrefix(hash, barCellPrefix):
+		// cell-<index>-<section id>
+		if index, _, found := strings.Cut(strings.TrimPrefix(hash, barCellPrefix), "-"); found {
+			fragment.Type = FragmentBarCell
+			fragment.ID = index
+		}
+	case strings.HasPrefix(hash, lineDotPrefix):
+		// dot-<label>-<value>-<section id>, where the label may contain dashes
+		parts := strings.Split(strings.TrimPrefix(hash, lineDotPrefix), "-")
+		if len(parts) >= 3 {
+			fragment.Type = FragmentLineDot
+			fragment.Label = unstringifyLabel(strings.Join(parts[:len(parts)-2], "-"))
+			fragment.Value = parts[len(parts)-2]
+			fragment.ID = fmt.Sprintf("%s-%s", fragment.Label, fragment.Value)
+		}
+	case reference.ElementID != "" && strings.HasSuffix(hash, fmt.Sprintf("-%s-%s", reference.ElementID, reference.SectionID)):
+		// <node id>-<section id>-<parent id>
+		fragment.Type = FragmentGraphNode
+		fragment.ID = strings.TrimSuffix(hash, fmt.Sprintf("-%s-%s", reference.ElementID, reference.SectionID))
+	}
+	return fragment
+}
+
+// IsDataPoint tells whether the fragment references a single data point of a widget rather than a whole widget.
+func (f *Fragment) IsDataPoint() bool {
+	switch f.Type {
+	case FragmentTableRow, FragmentPaginatedTableRow, FragmentTimelineItem, FragmentBarCell, FragmentLineDot, FragmentGraphNode:
+		return true
+	default:
+		return false
+	}
+}
+
+// String returns a human readable description of the fragment, used when no better name can be resolved.
+func (f *Fragment) String() string {
+	switch f.Type {
+	case FragmentLineDot:
+		return fmt.Sprintf("%s: %s", f.Label, f.Value)
+	case FragmentBarCell:
+		return fmt.Sprintf("bar %s", f.ID)
+	case FragmentUnknown:
+		return f.Raw
+	default:
+		return f.ID
+	}
+}
+
+// Reverts the labelStringify function of the webapp, which only replaces the first dot and whitespace
+func unstringifyLabel(label string) string {
+	return strings.Replace(strings.Replace(label, "wsp", " ", 1), "dot", ".", 1)
+}
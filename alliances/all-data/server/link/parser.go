@@ -0,0 +1,108 @@
+package link
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	parentIDParam  = "parentId"
+	sectionIDParam = "sectionId"
+)
+
+var whitespaceRegex = regexp.MustCompile(`\s`)
+
+// Parser recognizes HOOD URLs, i.e. URLs of the platform pages served by the plugin, and converts them from and to references.
+type Parser struct {
+	siteURL  *url.URL
+	pluginID string
+}
+
+// NewParser returns a new parser for the URLs of the plugin with the given id, served under the given site URL
+func NewParser(siteURL, pluginID string) (*Parser, error) {
+	parsedSiteURL, err := url.Parse(strings.TrimSuffix(siteURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid site url %s: %w", siteURL, err)
+	}
+	return &Parser{
+		siteURL:  parsedSiteURL,
+		pluginID: pluginID,
+	}, nil
+}
+
+// Parse converts a HOOD URL into a reference. The second value is false for URLs not pointing to the platform.
+// The following URLs are recognized:
+//   - {siteURL}/{pluginID}/organizations/{organizationID}[/{sectionName}[/{elementID}]][?parentId={sectionID}][#{hash}]
+//   - {siteURL}/{teamName}/channels/{channelName}#{hash}, for widgets referenced from the RHS of a channel
+func (p *Parser) Parse(rawURL string) (Reference, bool) {
+	parsedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return Reference{}, false
+	}
+	if parsedURL.Host != "" && !strings.EqualFold(parsedURL.Host, p.siteURL.Host) {
+		return Reference{}, false
+	}
+	if !strings.HasPrefix(parsedURL.Path, p.siteURL.Path+"/") {
+		return Reference{}, false
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(parsedURL.Path, p.siteURL.Path), "/"), "/")
+	if len(segments) == 3 && segments[1] == channelsSegment {
+		if parsedURL.Fragment == "" {
+			return Reference{}, false
+		}
+		reference := Reference{TeamName: segments[0], ChannelName: segments[2]}
+		reference.Fragment = ParseFragment(parsedURL.Fragment, reference)
+		return reference, true
+	}
+
+	if len(segments) < 3 || len(segments) > 5 || segments[0] != p.pluginID || segments[1] != organizationsSegment || segments[2] == "" {
+		return Reference{}, false
+	}
+	reference := Reference{OrganizationID: segments[2]}
+	if len(segments) > 3 {
+		reference.SectionName = segments[3]
+	}
+	if len(segments) > 4 {
+		reference.ElementID = segments[4]
+	}
+
+	query := parsedURL.Query()
+	if parentID := query.Get(parentIDParam); parentID != "" {
+		reference.SectionID = parentID
+	}
+	if sectionID := query.Get(sectionIDParam); sectionID != "" && reference.ElementID == "" {
+		reference.ElementID = sectionID
+	}
+	if parsedURL.Fragment != "" {
+		reference.Fragment = ParseFragment(parsedURL.Fragment, reference)
+	}
+	return reference, true
+}
+
+// URL builds the URL of the page showing the referenced element, mirroring how the webapp builds hyperlinks.
+func (p *Parser) URL(reference Reference) string {
+	var hyperlink string
+	if reference.ChannelName != "" {
+		hyperlink = fmt.Sprintf("%s/%s/%s/%s", p.siteURL.String(), reference.TeamName, channelsSegment, reference.ChannelName)
+	} else {
+		hyperlink = fmt.Sprintf("%s/%s/%s/%s", p.siteURL.String(), p.pluginID, organizationsSegment, reference.OrganizationID)
+		if reference.SectionName != "" {
+			hyperlink = fmt.Sprintf("%s/%s", hyperlink, reference.SectionName)
+			if reference.ElementID != "" {
+				hyperlink = fmt.Sprintf("%s/%s?%s=%s", hyperlink, reference.ElementID, parentIDParam, url.QueryEscape(reference.SectionID))
+			}
+		}
+	}
+	if reference.Fragment != nil {
+		hyperlink = fmt.Sprintf("%s#%s", hyperlink, reference.Fragment.Raw)
+	}
+	return hyperlink
+}
+
+// FormatName formats a name to be used as an URL path segment, in the same way the webapp does.
+func FormatName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(whitespaceRegex.ReplaceAllString(name, "-"), "'", "-"))
+}
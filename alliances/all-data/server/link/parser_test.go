@@ -0,0 +1,167 @@
+package link_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+// Tests for the parsing of HOOD URLs into references.
+func TestParse(t *testing.T) {
+	parser, err := link.NewParser("http://localhost:8065/", "alliances")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		url      string
+		expected link.Reference
+		ok       bool
+	}{
+		{
+			name:     "organization",
+			url:      "http://localhost:8065/alliances/organizations/1",
+			expected: link.Reference{OrganizationID: "1"},
+			ok:       true,
+		},
+		{
+			name:     "section",
+			url:      "http://localhost:8065/alliances/organizations/1/issues",
+			expected: link.Reference{OrganizationID: "1", SectionName: "issues"},
+			ok:       true,
+		},
+		{
+			name:     "element",
+			url:      elementURL,
+			expected: link.Reference{OrganizationID: "1", SectionName: "issues", SectionID: "10", ElementID: "abc"},
+			ok:       true,
+		},
+		{
+			name:     "element with section id in the query",
+			url:      "http://localhost:8065/alliances/organizations/1/issues?parentId=10&sectionId=abc",
+			expected: link.Reference{OrganizationID: "1", SectionName: "issues", SectionID: "10", ElementID: "abc"},
+			ok:       true,
+		},
+		{
+			name: "widget",
+			url:  elementURL + "#table-widget",
+			expected: link.Reference{
+				OrganizationID: "1",
+				SectionName:    "issues",
+				SectionID:      "10",
+				ElementID:      "abc",
+				Fragment:       &link.Fragment{Type: link.FragmentWidget, ID: "table", Raw: "table-widget"},
+			},
+			ok: true,
+		},
+		{
+			name: "bar cell",
+			url:  elementURL + "#cell-2-abc",
+			expected: link.Reference{
+				OrganizationID: "1",
+				SectionName:    "issues",
+				SectionID:      "10",
+				ElementID:      "abc",
+				Fragment:       &link.Fragment{Type: link.FragmentBarCell, ID: "2", Raw: "cell-2-abc"},
+			},
+			ok: true,
+		},
+		{
+			name: "line dot with a label containing dashes and whitespaces",
+			url:  elementURL + "#dot-2023-Q1wspsales-12dot5-abc",
+			expected: link.Reference{
+				OrganizationID: "1",
+				SectionName:    "issues",
+				SectionID:      "10",
+				ElementID:      "abc",
+				Fragment: &link.Fragment{
+					Type:  link.FragmentLineDot,
+					ID:    "2023-Q1 sales-12dot5",
+					Label: "2023-Q1 sales",
+					Value: "12dot5",
+					Raw:   "dot-2023-Q1wspsales-12dot5-abc",
+				},
+			},
+			ok: true,
+		},
+		{
+			name: "graph node",
+			url:  elementURL + "#node-abc-10",
+			expected: link.Reference{
+				OrganizationID: "1",
+				SectionName:    "issues",
+				SectionID:      "10",
+				ElementID:      "abc",
+				Fragment:       &link.Fragment{Type: link.FragmentGraphNode, ID: "node", Raw: "node-abc-10"},
+			},
+			ok: true,
+		},
+		{
+			name: "widget referenced from a channel",
+			url:  "http://localhost:8065/team/channels/town-square#table-widget",
+			expected: link.Reference{
+				TeamName:    "team",
+				ChannelName: "town-square",
+				Fragment:    &link.Fragment{Type: link.FragmentWidget, ID: "table", Raw: "table-widget"},
+			},
+			ok: true,
+		},
+		{
+			name:     "relative url",
+			url:      "/alliances/organizations/1",
+			expected: link.Reference{OrganizationID: "1"},
+			ok:       true,
+		},
+		{
+			name: "channel without hash",
+			url:  "http://localhost:8065/team/channels/town-square",
+		},
+		{
+			name: "other host",
+			url:  "http://example.com/alliances/organizations/1",
+		},
+		{
+			name: "other plugin",
+			url:  "http://localhost:8065/playbooks/organizations/1",
+		},
+		{
+			name: "other mattermost page",
+			url:  "http://localhost:8065/team/messages/@user",
+		},
+		{
+			name: "missing organization",
+			url:  "http://localhost:8065/alliances/organizations/",
+		},
+		{
+			name: "too many segments",
+			url:  "http://localhost:8065/alliances/organizations/1/issues/abc/more",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reference, ok := parser.Parse(test.url)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, reference)
+		})
+	}
+}
+
+// Tests that the URL built for a reference parses back into the same reference.
+func TestParseURLRoundTrip(t *testing.T) {
+	parser, err := link.NewParser("http://localhost:8065", "alliances")
+	require.NoError(t, err)
+
+	for _, url := range []string{
+		"http://localhost:8065/alliances/organizations/1",
+		"http://localhost:8065/alliances/organizations/1/issues",
+		elementURL,
+		elementURL + "#cell-2-abc",
+		"http://localhost:8065/team/channels/town-square#table-widget",
+	} {
+		reference, ok := parser.Parse(url)
+		require.True(t, ok, url)
+		assert.Equal(t, url, parser.URL(reference))
+	}
+}
@@ -0,0 +1,76 @@
+package link
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Long enough for the snapshots of whole sections, which exports bundle
+const providerRequestTimeout = 30 * time.Second
+
+// ProviderClient requests the data served by the providers of the platform config, sharing the same HTTP client.
+type ProviderClient struct {
+	client *http.Client
+}
+
+// NewProviderClient returns a new client for the providers.
+func NewProviderClient() *ProviderClient {
+	return &ProviderClient{client: &http.Client{Timeout: providerRequestTimeout}}
+}
+
+// Get requests a provider URL, failing unless the provider answers with 200 OK. The caller must close the body.
+func (c *ProviderClient) Get(url string) (io.ReadCloser, error) {
+	response, err := c.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch %s: %w", url, err)
+	}
+	if response.StatusCode != http.StatusOK {
+		response.Body.Close()
+		return nil, fmt.Errorf("unexpected status %d while fetching %s", response.StatusCode, url)
+	}
+	return response.Body, nil
+}
+
+// FetchJSON decodes the JSON served by a provider URL into value.
+func (c *ProviderClient) FetchJSON(url string, value interface{}) error {
+	body, err := c.Get(url)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return json.NewDecoder(body).Decode(value)
+}
+
+// PostJSON posts a JSON value to a provider URL, decoding the response into result even when the provider returns an error.
+func (c *ProviderClient) PostJSON(url string, value, result interface{}) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	response, err := c.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to post to %s: %w", url, err)
+	}
+	defer response.Body.Close()
+	decodeErr := json.NewDecoder(response.Body).Decode(result)
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d while posting to %s", response.StatusCode, url)
+	}
+	return decodeErr
+}
+
+// FetchElementName fetches the name of an element from the provider URL of its section.
+func (c *ProviderClient) FetchElementName(sectionURL, elementID string) (string, error) {
+	var element struct {
+		Name string `json:"name"`
+	}
+	if err := c.FetchJSON(fmt.Sprintf("%s/%s", strings.TrimSuffix(sectionURL, "/"), elementID), &element); err != nil {
+		return "", err
+	}
+	return element.Name, nil
+}
@@ -0,0 +1,62 @@
+package link_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+// Tests the requests to the providers against a fake provider.
+func TestProviderClient(t *testing.T) {
+	provider := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/elements/abc":
+			_, _ = io.WriteString(w, `{"id":"abc","name":"Alpha"}`)
+		case "/issues":
+			var issue map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&issue)
+			if issue["name"] == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = io.WriteString(w, `{"error":"missing name"}`)
+				return
+			}
+			_, _ = io.WriteString(w, `{"id":"1","name":"`+issue["name"]+`"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer provider.Close()
+	client := link.NewProviderClient()
+
+	t.Run("fetches the name of an element", func(t *testing.T) {
+		name, err := client.FetchElementName(provider.URL+"/elements/", "abc")
+		require.NoError(t, err)
+		assert.Equal(t, "Alpha", name)
+	})
+
+	t.Run("fails for responses other than ok", func(t *testing.T) {
+		var element map[string]string
+		assert.Error(t, client.FetchJSON(provider.URL+"/missing", &element))
+		_, err := client.Get(provider.URL + "/missing")
+		assert.Error(t, err)
+	})
+
+	t.Run("decodes the response of a post", func(t *testing.T) {
+		var saved map[string]string
+		require.NoError(t, client.PostJSON(provider.URL+"/issues", map[string]string{"name": "Outage"}, &saved))
+		assert.Equal(t, "Outage", saved["name"])
+	})
+
+	t.Run("decodes the response of a failed post", func(t *testing.T) {
+		var saved map[string]string
+		assert.Error(t, client.PostJSON(provider.URL+"/issues", map[string]string{}, &saved))
+		assert.Equal(t, "missing name", saved["error"])
+	})
+}
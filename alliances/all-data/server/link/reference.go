@@ -0,0 +1,123 @@
+package link
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind is the type of platform element a reference points to.
+type Kind string
+
+const (
+	KindOrganization Kind = "organization"
+	KindSection      Kind = "section"
+	KindElement      Kind = "element"
+	KindWidget       Kind = "widget"
+	KindDataPoint    Kind = "dataPoint"
+	KindChannel      Kind = "channel"
+)
+
+const (
+	organizationsSegment = "organizations"
+	sectionsSegment      = "sections"
+	sectionNamesSegment  = "sectionNames"
+	elementsSegment      = "elements"
+	teamsSegment         = "teams"
+	channelsSegment      = "channels"
+)
+
+// Reference is a typed reference to an element of the platform, parsed from a HOOD URL.
+type Reference struct {
+	OrganizationID string    `json:"organizationId"`
+	SectionID      string    `json:"sectionId"`
+	SectionName    string    `json:"sectionName"` // URL formatted section name, as found in the URL path
+	ElementID      string    `json:"elementId"`
+	Fragment       *Fragment `json:"fragment,omitempty"`
+
+	// Set only for widgets referenced from the RHS of a channel, which have not been canonicalized yet
+	TeamName    string `json:"teamName,omitempty"`
+	ChannelName string `json:"channelName,omitempty"`
+}
+
+// Kind returns the most specific kind of element the reference points to.
+func (r Reference) Kind() Kind {
+	if r.Fragment != nil {
+		if r.Fragment.IsDataPoint() {
+			return KindDataPoint
+		}
+		return KindWidget
+	}
+	if r.ChannelName != "" {
+		return KindChannel
+	}
+	if r.ElementID != "" {
+		return KindElement
+	}
+	if r.SectionID != "" || r.SectionName != "" {
+		return KindSection
+	}
+	return KindOrganization
+}
+
+// Key returns the canonical key identifying the referenced element.
+// It only depends on IDs, except for sections that could not be resolved to an ID, so that the same element always gets the same key.
+func (r Reference) Key() string {
+	var key string
+	if r.ChannelName != "" {
+		key = fmt.Sprintf("%s/%s/%s/%s", teamsSegment, r.TeamName, channelsSegment, r.ChannelName)
+	} else {
+		key = fmt.Sprintf("%s/%s", organizationsSegment, r.OrganizationID)
+		if r.SectionID != "" {
+			key = fmt.Sprintf("%s/%s/%s", key, sectionsSegment, r.SectionID)
+		} else if r.SectionName != "" {
+			key = fmt.Sprintf("%s/%s/%s", key, sectionNamesSegment, r.SectionName)
+		}
+		if r.ElementID != "" {
+			key = fmt.Sprintf("%s/%s/%s", key, elementsSegment, r.ElementID)
+		}
+	}
+	if r.Fragment != nil {
+		key = fmt.Sprintf("%s#%s", key, r.Fragment.Raw)
+	}
+	return key
+}
+
+// ParseKey parses a canonical key, as returned by Reference.Key, back into a reference.
+func ParseKey(key string) (Reference, error) {
+	path, hash, _ := strings.Cut(key, "#")
+	segments := strings.Split(path, "/")
+	if len(segments)%2 != 0 {
+		return Reference{}, fmt.Errorf("invalid key %s", key)
+	}
+
+	reference := Reference{}
+	for i := 0; i < len(segments); i += 2 {
+		value := segments[i+1]
+		if value == "" {
+			return Reference{}, fmt.Errorf("invalid key %s: empty %s", key, segments[i])
+		}
+		switch segments[i] {
+		case organizationsSegment:
+			reference.OrganizationID = value
+		case sectionsSegment:
+			reference.SectionID = value
+		case sectionNamesSegment:
+			reference.SectionName = value
+		case elementsSegment:
+			reference.ElementID = value
+		case teamsSegment:
+			reference.TeamName = value
+		case channelsSegment:
+			reference.ChannelName = value
+		default:
+			return Reference{}, fmt.Errorf("invalid key %s: unknown segment %s", key, segments[i])
+		}
+	}
+	if reference.OrganizationID == "" && reference.ChannelName == "" {
+		return Reference{}, fmt.Errorf("invalid key %s: missing organization", key)
+	}
+	if hash != "" {
+		reference.Fragment = ParseFragment(hash, reference)
+	}
+	return reference, nil
+}
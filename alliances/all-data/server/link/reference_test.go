@@ -0,0 +1,95 @@
+package link_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
+)
+
+// Tests that references get a canonical key, which parses back into the same reference.
+func TestReferenceKey(t *testing.T) {
+	element := link.Reference{OrganizationID: "1", SectionID: "10", SectionName: "issues", ElementID: "abc"}
+	barCell := element
+	barCell.Fragment = link.ParseFragment("cell-2-abc", barCell)
+
+	tests := []struct {
+		name      string
+		reference link.Reference
+		key       string
+		parsed    link.Reference // Differs from the reference when the key does not keep all its fields
+	}{
+		{
+			name:      "organization",
+			reference: link.Reference{OrganizationID: "1"},
+			key:       "organizations/1",
+			parsed:    link.Reference{OrganizationID: "1"},
+		},
+		{
+			name:      "section resolved to an id",
+			reference: link.Reference{OrganizationID: "1", SectionID: "10", SectionName: "issues"},
+			key:       "organizations/1/sections/10",
+			parsed:    link.Reference{OrganizationID: "1", SectionID: "10"},
+		},
+		{
+			name:      "section known by name only",
+			reference: link.Reference{OrganizationID: "1", SectionName: "issues"},
+			key:       "organizations/1/sectionNames/issues",
+			parsed:    link.Reference{OrganizationID: "1", SectionName: "issues"},
+		},
+		{
+			name:      "element",
+			reference: element,
+			key:       "organizations/1/sections/10/elements/abc",
+			parsed:    link.Reference{OrganizationID: "1", SectionID: "10", ElementID: "abc"},
+		},
+		{
+			name:      "data point",
+			reference: barCell,
+			key:       "organizations/1/sections/10/elements/abc#cell-2-abc",
+			parsed:    link.Reference{OrganizationID: "1", SectionID: "10", ElementID: "abc", Fragment: barCell.Fragment},
+		},
+		{
+			name: "widget referenced from a channel",
+			reference: link.Reference{
+				TeamName:    "team",
+				ChannelName: "town-square",
+				Fragment:    &link.Fragment{Type: link.FragmentWidget, ID: "table", Raw: "table-widget"},
+			},
+			key: "teams/team/channels/town-square#table-widget",
+			parsed: link.Reference{
+				TeamName:    "team",
+				ChannelName: "town-square",
+				Fragment:    &link.Fragment{Type: link.FragmentWidget, ID: "table", Raw: "table-widget"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			key := test.reference.Key()
+			assert.Equal(t, test.key, key)
+
+			parsed, err := link.ParseKey(key)
+			require.NoError(t, err)
+			assert.Equal(t, test.parsed, parsed)
+			assert.Equal(t, key, parsed.Key())
+		})
+	}
+}
+
+// Tests that malformed keys are rejected.
+func TestParseKeyErrors(t *testing.T) {
+	for _, key := range []string{
+		"",
+		"organizations",
+		"organizations/",
+		"sections/10",
+		"organizations/1/unknown/2",
+		"organizations/1/sections",
+	} {
+		_, err := link.ParseKey(key)
+		assert.Error(t, err, key)
+	}
+}
@@ -0,0 +1,148 @@
+package link
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+)
+
+// ChannelResolver maps a channel to the element whose widgets are shown in the channel's RHS.
+type ChannelResolver interface {
+	ResolveChannel(teamName, channelName string) (Reference, bool)
+}
+
+// Resolver canonicalizes references and resolves them to display names through the platform config.
+type Resolver struct {
+	platformConfig  *config.PlatformConfig
+	channelResolver ChannelResolver
+	providerClient  *ProviderClient
+}
+
+// NewResolver returns a new resolver. The channel resolver and the provider client are optional:
+// if nil, channel references are kept as they are and element names are not fetched.
+func NewResolver(platformConfig *config.PlatformConfig, channelResolver ChannelResolver, providerClient *ProviderClient) *Resolver {
+	return &Resolver{
+		platformConfig:  platformConfig,
+		channelResolver: channelResolver,
+		providerClient:  providerClient,
+	}
+}
+
+// Canonicalize fills in the IDs and names missing from a parsed reference, so that equivalent references get the same key.
+func (r *Resolver) Canonicalize(reference Reference) Reference {
+	if reference.ChannelName != "" && r.channelResolver != nil {
+		if channelReference, found := r.channelResolver.ResolveChannel(reference.TeamName, reference.ChannelName); found {
+			channelReference.Fragment = reference.Fragment
+			reference = channelReference
+		}
+	}
+
+	organization, found := r.FindOrganization(reference.OrganizationID)
+	if !found {
+		return reference
+	}
+	if reference.SectionID != "" {
+		if section, found := findSectionByID(organization.Sections, reference.SectionID); found {
+			reference.SectionName = FormatName(section.Name)
+		}
+	} else if reference.SectionName != "" {
+		if section, found := findSectionByName(organization.Sections, reference.SectionName); found {
+			reference.SectionID = section.ID
+		}
+	}
+	if reference.Fragment != nil {
+		// Graph nodes can only be recognized once the IDs are known
+		reference.Fragment = ParseFragment(reference.Fragment.Raw, reference)
+	}
+	return reference
+}
+
+// ResolveName returns the display name of the element identified by a canonical key, e.g. Organization.Section.Element.Widget.
+// Element names are fetched from the provider serving the section, falling back to the element ID on failure.
+func (r *Resolver) ResolveName(key string) (string, error) {
+	reference, err := ParseKey(key)
+	if err != nil {
+		return "", err
+	}
+	if reference.ChannelName != "" {
+		name := reference.ChannelName
+		if reference.Fragment != nil {
+			name = fmt.Sprintf("%s.%s", name, reference.Fragment.String())
+		}
+		return name, nil
+	}
+
+	organization, found := r.FindOrganization(reference.OrganizationID)
+	if !found {
+		return "", fmt.Errorf("organization %s not found", reference.OrganizationID)
+	}
+	names := []string{organization.Name}
+
+	var section *config.Section
+	if reference.SectionID != "" {
+		section, found = findSectionByID(organization.Sections, reference.SectionID)
+	} else if reference.SectionName != "" {
+		section, found = findSectionByName(organization.Sections, reference.SectionName)
+	}
+	if section != nil && found {
+		names = append(names, section.Name)
+	}
+
+	if reference.ElementID != "" {
+		elementName := reference.ElementID
+		if section != nil && r.providerClient != nil {
+			if name, err := r.providerClient.FetchElementName(section.URL, reference.ElementID); err == nil && name != "" {
+				elementName = name
+			}
+		}
+		names = append(names, elementName)
+	}
+	if reference.Fragment != nil {
+		names = append(names, reference.Fragment.String())
+	}
+	return strings.Join(names, "."), nil
+}
+
+// FindOrganization returns the organization with the given ID from the platform config.
+func (r *Resolver) FindOrganization(organizationID string) (*config.Organization, bool) {
+	for i := range r.platformConfig.Organizations {
+		if r.platformConfig.Organizations[i].ID == organizationID {
+			return &r.platformConfig.Organizations[i], true
+		}
+	}
+	return nil, false
+}
+
+// FindSection returns the section with the given ID, looking into nested sections as well.
+func (r *Resolver) FindSection(organizationID, sectionID string) (*config.Section, bool) {
+	organization, found := r.FindOrganization(organizationID)
+	if !found {
+		return nil, false
+	}
+	return findSectionByID(organization.Sections, sectionID)
+}
+
+func findSectionByID(sections []config.Section, sectionID string) (*config.Section, bool) {
+	for i := range sections {
+		if sections[i].ID == sectionID {
+			return &sections[i], true
+		}
+		if section, found := findSectionByID(sections[i].Sections, sectionID); found {
+			return section, true
+		}
+	}
+	return nil, false
+}
+
+func findSectionByName(sections []config.Section, formattedName string) (*config.Section, bool) {
+	for i := range sections {
+		if FormatName(sections[i].Name) == formattedName {
+			return &sections[i], true
+		}
+		if section, found := findSectionByName(sections[i].Sections, formattedName); found {
+			return section, true
+		}
+	}
+	return nil, false
+}
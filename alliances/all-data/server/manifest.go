@@ -39,10 +39,31 @@ const manifestStr = `
     "header": "",
     "footer": "",
     "settings": [{
-			"key": "adminPassword",
-			"display_name": "Admin password",
+			"key": "privilegedRoles",
+			"display_name": "Privileged roles",
 			"type": "text",
-			"help_text": "A standard base64 encoded password used to select the \"All\" option from the organization dropdown, to be able to view all channels."
+			"help_text": "Comma separated Mattermost roles allowed to select the \"All\" option from the organization dropdown, reset user organizations and manage organization memberships, besides system and team admins."
+		},
+		{
+			"key": "membershipReconciliation",
+			"display_name": "Organization channels reconciliation",
+			"type": "dropdown",
+			"help_text": "Whether the members of the organization channels are periodically reconciled with the members of the organizations: users are added to the channels of their organizations and everyone else is removed, except bots and the members and roles of the channel template of the section. The dry run only reports the changes it would make, so check its report before enforcing.",
+			"default": "off",
+			"options": [
+				{
+					"display_name": "Off",
+					"value": "off"
+				},
+				{
+					"display_name": "Dry run",
+					"value": "dryRun"
+				},
+				{
+					"display_name": "Enforce",
+					"value": "enforce"
+				}
+			]
 		},
 		{
 			"key": "ecosystemGraph",
@@ -71,6 +92,27 @@ const manifestStr = `
 			"type": "bool",
 			"help_text": "Specifies whether to show a button in the channel header / rightmost Mattermost sidebar to allow editing the ecosystem graph from the main stage.",
 			"default": false
+		},
+		{
+			"key": "linkPreviews",
+			"display_name": "Enable link previews",
+			"type": "bool",
+			"help_text": "Toggle whether posts linking organizations, sections and elements of the platform get a preview of the linked elements, shown to their author, with buttons to open them, show their backlinks and pin the post as evidence.",
+			"default": false
+		},
+		{
+			"key": "misalignmentDetection",
+			"display_name": "Enable misalignment detection",
+			"type": "bool",
+			"help_text": "Toggle whether the numbers stated in posts next to links to chart data points are checked against the values of the data points. Posts stating numbers that do not match are flagged with a reply of the bot.",
+			"default": false
+		},
+		{
+			"key": "misalignmentTolerance",
+			"display_name": "Misalignment tolerance",
+			"type": "number",
+			"help_text": "Percentage a number stated in a post can differ from the value of the linked data point by before being flagged as a misalignment. Only integer values are allowed.",
+			"default": 5
 			}]
   }
 }
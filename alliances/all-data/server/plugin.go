@@ -16,6 +16,7 @@ import (
 	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
 	"github.com/tizianocitro/hood-framework/alliances/all-data/server/command"
 	"github.com/tizianocitro/hood-framework/alliances/all-data/server/config"
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/link"
 	"github.com/tizianocitro/hood-framework/alliances/all-data/server/sqlstore"
 )
 
@@ -40,12 +41,31 @@ type Plugin struct {
 	// How the plugin URLs starts
 	pluginURLPathPrefix string
 
-	platformService *config.PlatformService
-	categoryService *app.CategoryService
-	channelService  *app.ChannelService
-	postService     *app.PostService
-	eventService    *app.EventService
-	userService     *app.UserService
+	platformService       *config.PlatformService
+	categoryService       *app.CategoryService
+	channelService        *app.ChannelService
+	linkService           *app.LinkService
+	exportService         *app.ExportService
+	importService         *app.ImportService
+	membershipService     *app.MembershipService
+	authorizationService  *app.AuthorizationService
+	exportScheduleService *app.ExportScheduleService
+	postService           *app.PostService
+	eventService          *app.EventService
+	userService           *app.UserService
+	issueChannelService   *app.IssueChannelService
+	commandService        *app.CommandService
+	linkPreviewService    *app.LinkPreviewService
+	misalignmentService   *app.MisalignmentService
+
+	// Runs the due export schedules, on a single server of the cluster at a time
+	exportSchedulesJob *cluster.Job
+	// Periodically reconciles the members of the organization channels
+	membershipReconciliationJob *cluster.Job
+	// Polls the provider to keep a channel for each ecosystem issue
+	issueChannelsJob *cluster.Job
+	// Removes the backlinks of deleted posts
+	deletedBacklinksJob *cluster.Job
 }
 
 func (p *Plugin) OnActivate() error {
@@ -71,13 +91,30 @@ func (p *Plugin) OnActivate() error {
 	channelStore := sqlstore.NewChannelStore(apiClient, sqlStore)
 	categoryStore := sqlstore.NewCategoryStore(apiClient, sqlStore)
 	mattermostChannelStore := sqlstore.NewMattermostChannelStore(apiClient, sqlStore)
+	mattermostPostStore := sqlstore.NewMattermostPostStore(apiClient, sqlStore)
+	exportScheduleStore := sqlstore.NewExportScheduleStore(apiClient, sqlStore)
+	membershipStore := sqlstore.NewMembershipStore(apiClient, sqlStore)
+	auditStore := sqlstore.NewAuditStore(apiClient, sqlStore)
 
 	p.platformService = config.NewPlatformService(p.API, configFileName, defaultConfigFileName)
-	p.categoryService = app.NewCategoryService(p.API, p.platformService, channelStore, categoryStore, mattermostChannelStore)
-	p.channelService = app.NewChannelService(p.API, channelStore, mattermostChannelStore, p.categoryService, p.platformService)
+	p.categoryService = app.NewCategoryService(p.API, p.platformService, channelStore, categoryStore, membershipStore, mattermostChannelStore)
+	providerClient := link.NewProviderClient()
+	p.linkService = app.NewLinkService(p.API, p.platformService, channelStore, providerClient, p.pluginID)
+	channelTemplateService := app.NewChannelTemplateService(p.API, p.linkService, providerClient, p.botID)
+	p.channelService = app.NewChannelService(p.API, channelStore, mattermostChannelStore, p.categoryService, p.platformService, p.linkService, channelTemplateService)
+	p.exportService = app.NewExportService(p.API, p.channelService, p.platformService, p.linkService, providerClient, mattermostPostStore, p.botID, p.pluginID)
+	p.authorizationService = app.NewAuthorizationService(p.API, auditStore, p.configuration)
+	p.importService = app.NewImportService(p.API, p.channelService, p.authorizationService, p.botID)
+	p.exportScheduleService = app.NewExportScheduleService(p.API, exportScheduleStore, p.exportService, p.botID)
 	p.postService = app.NewPostService(p.API, p.channelService)
-	p.eventService = app.NewEventService(p.API, p.platformService, p.channelService, p.categoryService, p.botID, p.configuration)
+	p.membershipService = app.NewMembershipService(p.API, membershipStore, p.channelService, p.categoryService, p.platformService, p.authorizationService, p.configuration)
+	p.eventService = app.NewEventService(p.API, p.platformService, p.channelService, p.categoryService, p.membershipService, p.authorizationService, p.botID)
 	p.userService = app.NewUserService(p.API)
+	p.issueChannelService = app.NewIssueChannelService(p.API, p.platformService, p.channelService, p.linkService, providerClient, p.botID)
+	linkSearchService := app.NewLinkSearchService(p.API, p.platformService, p.linkService, providerClient, membershipStore)
+	p.commandService = app.NewCommandService(p.API, p.platformService, p.channelService, p.linkService, providerClient, p.exportService, p.issueChannelService, linkSearchService, p.authorizationService)
+	p.linkPreviewService = app.NewLinkPreviewService(p.API, p.configuration, p.linkService, providerClient, p.commandService, p.botID, p.pluginID)
+	p.misalignmentService = app.NewMisalignmentService(p.API, p.configuration, p.linkService, providerClient, p.botID)
 
 	mutex, err := cluster.NewMutex(p.API, "CSA_dbMutex")
 	if err != nil {
@@ -90,6 +127,27 @@ func (p *Plugin) OnActivate() error {
 	}
 	mutex.Unlock()
 
+	if err := p.channelService.RebuildOutdatedBacklinks(); err != nil {
+		p.API.LogWarn("failed to rebuild outdated backlinks", "err", err)
+	}
+	if err := p.membershipService.MigrateOrganizationProps(); err != nil {
+		p.API.LogWarn("failed to migrate organization props to memberships", "err", err)
+	}
+	if p.exportSchedulesJob, err = p.exportScheduleService.Start(); err != nil {
+		return errors.Wrapf(err, "failed to start export schedules job")
+	}
+	if p.membershipReconciliationJob, err = p.membershipService.StartReconciliationJob(); err != nil {
+		return errors.Wrapf(err, "failed to start membership reconciliation job")
+	}
+	if p.issueChannelsJob, err = p.issueChannelService.StartSyncJob(); err != nil {
+		return errors.Wrapf(err, "failed to start issue channels job")
+	}
+	if p.deletedBacklinksJob, err = p.channelService.StartDeletedBacklinksJob(); err != nil {
+		return errors.Wrapf(err, "failed to start deleted backlinks job")
+	}
+	// Searches are served by the index once built, so it starts being built right away
+	linkSearchService.RefreshIndex()
+
 	p.handler = api.NewHandler(p.pluginAPI)
 	api.NewConfigHandler(
 		p.handler.APIRouter,
@@ -100,6 +158,30 @@ func (p *Plugin) OnActivate() error {
 		p.handler.APIRouter,
 		p.channelService,
 	)
+	api.NewLinkHandler(
+		p.handler.APIRouter,
+		p.linkService,
+	)
+	api.NewExportHandler(
+		p.handler.APIRouter,
+		p.exportService,
+	)
+	api.NewImportHandler(
+		p.handler.APIRouter,
+		p.importService,
+	)
+	api.NewExportScheduleHandler(
+		p.handler.APIRouter,
+		p.exportScheduleService,
+	)
+	api.NewMembershipHandler(
+		p.handler.APIRouter,
+		p.membershipService,
+	)
+	api.NewAuditHandler(
+		p.handler.APIRouter,
+		p.authorizationService,
+	)
 	api.NewPostHandler(
 		p.handler.APIRouter,
 		p.postService,
@@ -112,6 +194,18 @@ func (p *Plugin) OnActivate() error {
 		p.handler.APIRouter,
 		p.userService,
 	)
+	api.NewIssueHandler(
+		p.handler.APIRouter,
+		p.issueChannelService,
+	)
+	api.NewCommandHandler(
+		p.handler.APIRouter,
+		p.commandService,
+	)
+	api.NewLinkPreviewHandler(
+		p.handler.APIRouter,
+		p.linkPreviewService,
+	)
 
 	if err := p.registerCommands(); err != nil {
 		return errors.Wrapf(err, "failed to register commands")
@@ -121,6 +215,30 @@ func (p *Plugin) OnActivate() error {
 	return nil
 }
 
+func (p *Plugin) OnDeactivate() error {
+	if p.exportSchedulesJob != nil {
+		if err := p.exportSchedulesJob.Close(); err != nil {
+			p.API.LogWarn("failed to stop export schedules job", "err", err)
+		}
+	}
+	if p.membershipReconciliationJob != nil {
+		if err := p.membershipReconciliationJob.Close(); err != nil {
+			p.API.LogWarn("failed to stop membership reconciliation job", "err", err)
+		}
+	}
+	if p.issueChannelsJob != nil {
+		if err := p.issueChannelsJob.Close(); err != nil {
+			p.API.LogWarn("failed to stop issue channels job", "err", err)
+		}
+	}
+	if p.deletedBacklinksJob != nil {
+		if err := p.deletedBacklinksJob.Close(); err != nil {
+			p.API.LogWarn("failed to stop deleted backlinks job", "err", err)
+		}
+	}
+	return nil
+}
+
 // func (p *Plugin) WebSocketMessageHasBeenPosted(webConnID, userID string, req *model.WebSocketRequest) {
 // 	p.API.LogInfo("Received an event", "req", req, "userId", userID)
 // 	p.API.LogInfo("Completed event processing", "req", req, "userId", userID)
@@ -133,6 +251,8 @@ func (p *Plugin) ServeHTTP(c *plugin.Context, w http.ResponseWriter, r *http.Req
 		p.handleGetOrganizationURL(w, r)
 	case command.ResetUserOrganizationPath:
 		p.handleResetUserOrganization(w, r)
+	case command.HoodIssueCreatePath:
+		p.handleHoodIssueCreate(w, r)
 	default:
 		p.handler.ServeHTTP(w, r)
 	}
@@ -146,6 +266,16 @@ func (p *Plugin) MessageWillBeUpdated(c *plugin.Context, newPost, oldPost *model
 func (p *Plugin) MessageHasBeenPosted(c *plugin.Context, post *model.Post) {
 	p.API.LogInfo("MessageHasBeenPosted", "post", post)
 	p.channelService.AddBacklinkIfPresent(post)
+	p.linkPreviewService.SendPreviews(post)
+	p.misalignmentService.CheckPost(post)
+}
+
+func (p *Plugin) MessageHasBeenUpdated(c *plugin.Context, newPost, oldPost *model.Post) {
+	p.channelService.UpdateBacklinks(newPost)
+	// Only edits of the message can change the numbers it states, not those of its props, e.g. pinning it
+	if newPost.DeleteAt == 0 && newPost.Message != oldPost.Message {
+		p.misalignmentService.CheckPost(newPost)
+	}
 }
 
 func (p *Plugin) getPluginIDFromManifest() string {
@@ -0,0 +1,12 @@
+package sqlstore
+
+type AuditRecordEntity struct {
+	ID       string
+	UserID   string
+	Action   string
+	TeamID   string
+	TargetID string
+	Allowed  bool
+	Details  string
+	CreateAt int64
+}
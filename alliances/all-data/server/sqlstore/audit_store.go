@@ -0,0 +1,101 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+const defaultAuditRecordsPerPage = 100
+
+// auditStore is a sql store for the audit log
+// Use NewAuditStore to create it
+type auditStore struct {
+	pluginAPI    PluginAPIClient
+	store        *SQLStore
+	queryBuilder sq.StatementBuilderType
+
+	recordsSelect sq.SelectBuilder
+}
+
+var _ app.AuditStore = (*auditStore)(nil)
+
+// NewAuditStore creates a new store for the audit log.
+func NewAuditStore(pluginAPI PluginAPIClient, sqlStore *SQLStore) app.AuditStore {
+	recordsSelect := sqlStore.builder.
+		Select(
+			"ID",
+			"UserID",
+			"Action",
+			"TeamID",
+			"TargetID",
+			"Allowed",
+			"Details",
+			"CreateAt",
+		).
+		From("CSA_AuditLog")
+
+	return &auditStore{
+		pluginAPI:     pluginAPI,
+		store:         sqlStore,
+		queryBuilder:  sqlStore.builder,
+		recordsSelect: recordsSelect,
+	}
+}
+
+func (s *auditStore) AddAuditRecord(record app.AuditRecord) error {
+	if _, err := s.store.execBuilder(s.store.db, s.queryBuilder.
+		Insert("CSA_AuditLog").
+		SetMap(map[string]interface{}{
+			"ID":       record.ID,
+			"UserID":   record.UserID,
+			"Action":   record.Action,
+			"TeamID":   record.TeamID,
+			"TargetID": record.TargetID,
+			"Allowed":  record.Allowed,
+			"Details":  record.Details,
+			"CreateAt": record.CreateAt,
+		})); err != nil {
+		return errors.Wrap(err, "could not add audit record")
+	}
+	return nil
+}
+
+func (s *auditStore) GetAuditRecords(options app.AuditFilterOptions) ([]app.AuditRecord, error) {
+	perPage := options.PerPage
+	if perPage <= 0 {
+		perPage = defaultAuditRecordsPerPage
+	}
+	query := s.recordsSelect.
+		OrderBy("CreateAt DESC").
+		Limit(uint64(perPage)).
+		Offset(uint64(options.Page * perPage))
+	if options.UserID != "" {
+		query = query.Where(sq.Eq{"UserID": options.UserID})
+	}
+	if options.Action != "" {
+		query = query.Where(sq.Eq{"Action": options.Action})
+	}
+
+	var entities []AuditRecordEntity
+	if err := s.store.selectBuilder(s.store.db, &entities, query); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "failed to get audit records")
+	}
+	records := make([]app.AuditRecord, 0, len(entities))
+	for _, entity := range entities {
+		records = append(records, app.AuditRecord{
+			ID:       entity.ID,
+			UserID:   entity.UserID,
+			Action:   entity.Action,
+			TeamID:   entity.TeamID,
+			TargetID: entity.TargetID,
+			Allowed:  entity.Allowed,
+			Details:  entity.Details,
+			CreateAt: entity.CreateAt,
+		})
+	}
+	return records, nil
+}
@@ -0,0 +1,10 @@
+package sqlstore
+
+type OrganizationCategoryEntity struct {
+	UserID         string
+	TeamID         string
+	OrganizationID string
+	RuleID         string
+	CategoryID     string
+	CreateAt       int64
+}
@@ -1,6 +1,8 @@
 package sqlstore
 
 import (
+	"database/sql"
+
 	sq "github.com/Masterminds/squirrel"
 	"github.com/mattermost/mattermost-server/v6/model"
 	"github.com/pkg/errors"
@@ -8,26 +10,109 @@ import (
 	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
 )
 
-// A SQL store to interface the Mattermost sidebarcategories table
+// A SQL store for the sidebar categories owned by the plugin, which also interfaces the Mattermost sidebarcategories table
 type categoryStore struct {
 	pluginAPI    PluginAPIClient
 	store        *SQLStore
 	queryBuilder sq.StatementBuilderType
+
+	organizationCategoriesSelect sq.SelectBuilder
 }
 
 // This is a way to implement interface explicitly
 var _ app.CategoryStore = (*categoryStore)(nil)
 
 func NewCategoryStore(pluginAPI PluginAPIClient, sqlStore *SQLStore) app.CategoryStore {
+	organizationCategoriesSelect := sqlStore.builder.
+		Select(
+			"UserID",
+			"TeamID",
+			"OrganizationID",
+			"RuleID",
+			"CategoryID",
+			"CreateAt",
+		).
+		From("CSA_OrganizationCategory")
+
 	return &categoryStore{
-		pluginAPI:    pluginAPI,
-		store:        sqlStore,
-		queryBuilder: sqlStore.builder,
+		pluginAPI:                    pluginAPI,
+		store:                        sqlStore,
+		queryBuilder:                 sqlStore.builder,
+		organizationCategoriesSelect: organizationCategoriesSelect,
+	}
+}
+
+func (s *categoryStore) GetOrganizationCategories(userID, teamID string) ([]app.OrganizationCategory, error) {
+	var entities []OrganizationCategoryEntity
+	if err := s.store.selectBuilder(s.store.db, &entities, s.organizationCategoriesSelect.
+		Where(sq.Eq{"UserID": userID, "TeamID": teamID}).
+		OrderBy("CreateAt ASC")); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrapf(err, "failed to get organization categories of user '%s' in team '%s'", userID, teamID)
+	}
+	organizationCategories := make([]app.OrganizationCategory, 0, len(entities))
+	for _, entity := range entities {
+		organizationCategories = append(organizationCategories, app.OrganizationCategory{
+			UserID:         entity.UserID,
+			TeamID:         entity.TeamID,
+			OrganizationID: entity.OrganizationID,
+			RuleID:         entity.RuleID,
+			CategoryID:     entity.CategoryID,
+			CreateAt:       entity.CreateAt,
+		})
+	}
+	return organizationCategories, nil
+}
+
+func (s *categoryStore) SaveOrganizationCategory(organizationCategory app.OrganizationCategory) error {
+	tx, err := s.store.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	defer s.store.finalizeTransaction(tx)
+
+	if _, err := s.store.execBuilder(tx, s.queryBuilder.
+		Delete("CSA_OrganizationCategory").
+		Where(sq.Eq{
+			"UserID":         organizationCategory.UserID,
+			"TeamID":         organizationCategory.TeamID,
+			"OrganizationID": organizationCategory.OrganizationID,
+			"RuleID":         organizationCategory.RuleID,
+		})); err != nil {
+		return errors.Wrap(err, "could not delete previous organization category")
+	}
+	if _, err := s.store.execBuilder(tx, s.queryBuilder.
+		Insert("CSA_OrganizationCategory").
+		SetMap(map[string]interface{}{
+			"UserID":         organizationCategory.UserID,
+			"TeamID":         organizationCategory.TeamID,
+			"OrganizationID": organizationCategory.OrganizationID,
+			"RuleID":         organizationCategory.RuleID,
+			"CategoryID":     organizationCategory.CategoryID,
+			"CreateAt":       organizationCategory.CreateAt,
+		})); err != nil {
+		return errors.Wrap(err, "could not add organization category")
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "could not commit transaction")
 	}
+	return nil
 }
 
-// The Mattermost RPC API lacks a method to delete categories. This is a temporary solution.
+func (s *categoryStore) DeleteOrganizationCategory(userID, teamID, organizationID, ruleID string) error {
+	if _, err := s.store.execBuilder(s.store.db, s.queryBuilder.
+		Delete("CSA_OrganizationCategory").
+		Where(sq.Eq{"UserID": userID, "TeamID": teamID, "OrganizationID": organizationID, "RuleID": ruleID})); err != nil {
+		return errors.Wrapf(err, "could not delete category of organization '%s' for user '%s'", organizationID, userID)
+	}
+	return nil
+}
+
+// The Mattermost RPC API lacks a method to delete categories. This is a temporary solution,
+// only used for the categories owned by the plugin once their channels have been moved elsewhere.
 func (s *categoryStore) DeleteCategories(categories []*model.SidebarCategoryWithChannels) error {
+	if len(categories) == 0 {
+		return nil
+	}
 	var ids []string
 	for _, category := range categories {
 		ids = append(ids, category.Id)
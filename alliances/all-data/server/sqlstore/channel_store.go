@@ -17,6 +17,8 @@ import (
 	"github.com/tizianocitro/hood-framework/alliances/all-data/server/util"
 )
 
+const teamUsersPerPage = 200
+
 // channelStore is a sql store for channels
 // Use NewChannelStore to create it
 type channelStore struct {
@@ -149,9 +151,10 @@ func (s *channelStore) addExistingChannel(sectionID string, params app.AddChanne
 	if _, err := s.store.execBuilder(tx, sq.
 		Insert("CSA_Channel").
 		SetMap(map[string]interface{}{
-			"ChannelID": params.ChannelID,
-			"ParentID":  params.ParentID,
-			"SectionID": sectionID,
+			"ChannelID":      params.ChannelID,
+			"ParentID":       params.ParentID,
+			"SectionID":      sectionID,
+			"OrganizationID": params.OrganizationID,
 		})); err != nil {
 		return app.AddChannelResult{}, errors.Wrap(err, "could not add existing channel to section")
 	}
@@ -228,13 +231,12 @@ func (s *channelStore) createAndAddChannel(params app.AddChannelParams) (*model.
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create channel to add")
 	}
-	members, getErr := s.getOrganizationMembers(params.ChannelID, params.OrganizationID, params.TeamID)
-	// members, err := s.pluginAPI.API.GetTeamMembers(params.TeamID, 0, 200)
+	memberIDs, getErr := s.getOrganizationMembers(params.OrganizationID, params.TeamID)
 	if getErr != nil {
-		return nil, errors.Wrap(err, "could not add channel to users in team")
+		return nil, errors.Wrap(getErr, "could not add channel to users in team")
 	}
-	for _, member := range members {
-		if _, err := s.pluginAPI.API.AddChannelMember(channel.Id, member.Id); err != nil {
+	for _, memberID := range memberIDs {
+		if _, err := s.pluginAPI.API.AddChannelMember(channel.Id, memberID); err != nil {
 			return nil, errors.Wrap(err, "could not add channel to user's channel list")
 		}
 	}
@@ -267,6 +269,37 @@ func (s *channelStore) LinkChannelToOrganization(channelID, organizationID strin
 	return nil
 }
 
+func (s *channelStore) MoveChannel(channelID string, params app.MoveChannelParams) error {
+	result, err := s.store.execBuilder(s.store.db, s.store.builder.
+		Update("CSA_Channel").
+		Where(sq.Eq{"ChannelID": channelID}).
+		SetMap(map[string]interface{}{
+			"ParentID":       params.ParentID,
+			"SectionID":      params.SectionID,
+			"OrganizationID": params.OrganizationID,
+		}))
+	if err != nil {
+		return errors.Wrapf(err, "could not move channel '%s'", channelID)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return errors.Wrapf(app.ErrNotFound, "channel '%s' is not linked to any section", channelID)
+	}
+	return nil
+}
+
+func (s *channelStore) UnlinkChannel(channelID string) error {
+	result, err := s.store.execBuilder(s.store.db, s.store.builder.
+		Delete("CSA_Channel").
+		Where(sq.Eq{"ChannelID": channelID}))
+	if err != nil {
+		return errors.Wrapf(err, "could not unlink channel '%s'", channelID)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return errors.Wrapf(app.ErrNotFound, "channel '%s' is not linked to any section", channelID)
+	}
+	return nil
+}
+
 // func (s *channelStore) addChannelToCategory(channel *model.Channel, params app.AddChannelParams) error {
 // 	categories, err := s.pluginAPI.API.GetChannelSidebarCategories(params.UserID, params.TeamID)
 // 	if err != nil {
@@ -325,23 +358,38 @@ func (s *channelStore) toChannel(channelEntity ChannelEntity) app.Channel {
 	return channel
 }
 
-func (s *channelStore) getOrganizationMembers(channelID, organizationID, teamID string) ([]*model.User, error) {
-	allUsers, err := s.pluginAPI.API.GetUsersInTeam(teamID, 0, 200)
-	var orgUsers []*model.User
-	if err != nil {
-		return nil, errors.Wrap(err, "could not add channel to users in team")
+// Gets the team members belonging to an organization, including those who selected all organizations
+func (s *channelStore) getOrganizationMembers(organizationID, teamID string) ([]string, error) {
+	var userIDs []string
+	if err := s.store.selectBuilder(s.store.db, &userIDs, s.store.builder.
+		Select("UserID").
+		From("CSA_OrganizationMembership").
+		Where(sq.Eq{"OrganizationID": organizationID})); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "could not get organization members")
+	}
+	var orgUserIDs []string
+	for _, userID := range userIDs {
+		if _, err := s.pluginAPI.API.GetTeamMember(teamID, userID); err == nil {
+			orgUserIDs = append(orgUserIDs, userID)
+		}
 	}
 
-	for _, user := range allUsers {
-		userOrgID, isPropSet := user.GetProp("orgId")
-		if isPropSet {
-			if userOrgID == organizationID || userOrgID == config.OrganizationIDAll {
-				orgUsers = append(orgUsers, user)
+	for page := 0; ; page++ {
+		users, err := s.pluginAPI.API.GetUsersInTeam(teamID, page, teamUsersPerPage)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not add channel to users in team")
+		}
+		for _, user := range users {
+			if userOrgID, isPropSet := user.GetProp("orgId"); isPropSet && userOrgID == config.OrganizationIDAll {
+				orgUserIDs = append(orgUserIDs, user.Id)
 			}
 		}
+		if len(users) < teamUsersPerPage {
+			break
+		}
 	}
 
-	return orgUsers, nil
+	return orgUserIDs, nil
 }
 
 func (s *channelStore) AddBacklinks(postID string, backlinks []app.BacklinkData) error {
@@ -351,6 +399,131 @@ func (s *channelStore) AddBacklinks(postID string, backlinks []app.BacklinkData)
 	}
 	defer s.store.finalizeTransaction(tx)
 
+	if err := s.insertBacklinks(tx, postID, backlinks); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "could not commit transaction")
+	}
+	return nil
+}
+
+// SetBacklinks replaces all the backlinks of a post with the given ones in a single transaction.
+// An empty list of backlinks simply removes the existing ones.
+func (s *channelStore) SetBacklinks(postID string, backlinks []app.BacklinkData) error {
+	tx, err := s.store.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	defer s.store.finalizeTransaction(tx)
+
+	if err := s.deleteBacklinksByPostID(tx, postID); err != nil {
+		return err
+	}
+	if err := s.insertBacklinks(tx, postID, backlinks); err != nil {
+		return err
+	}
+	// Stances only survive for the elements the post still links
+	keys := make([]string, 0, len(backlinks))
+	for _, backlink := range backlinks {
+		keys = append(keys, backlink.MarkdownLink)
+	}
+	if _, err := s.store.execBuilder(tx, s.store.builder.
+		Delete("").
+		From("CSA_BacklinkStance").
+		Where(sq.Eq{"PostID": postID}).
+		Where(sq.NotEq{"ElementLinkPart": keys})); err != nil {
+		return errors.Wrapf(err, "could not delete stances for post with id '%s'", postID)
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "could not commit transaction")
+	}
+	return nil
+}
+
+func (s *channelStore) DeleteBacklinksByPostID(postID string) error {
+	tx, err := s.store.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	defer s.store.finalizeTransaction(tx)
+
+	if err := s.deleteBacklinksByPostID(tx, postID); err != nil {
+		return err
+	}
+	if _, err := s.store.execBuilder(tx, s.store.builder.
+		Delete("").
+		From("CSA_BacklinkStance").
+		Where(sq.Eq{"PostID": postID})); err != nil {
+		return errors.Wrapf(err, "could not delete stances for post with id '%s'", postID)
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "could not commit transaction")
+	}
+	return nil
+}
+
+// DeleteBacklinksOfDeletedPosts removes the backlinks and the stances of the posts that are deleted or no longer exist
+func (s *channelStore) DeleteBacklinksOfDeletedPosts() (int64, error) {
+	tx, err := s.store.db.Beginx()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not begin transaction")
+	}
+	defer s.store.finalizeTransaction(tx)
+
+	if _, err := s.store.execBuilder(tx, s.store.builder.
+		Delete("").
+		From("CSA_BacklinkStance").
+		Where("NOT EXISTS (SELECT 1 FROM Posts AS p WHERE p.Id = CSA_BacklinkStance.PostID AND p.DeleteAt = 0)")); err != nil {
+		return 0, errors.Wrap(err, "could not delete stances of deleted posts")
+	}
+	result, err := s.store.execBuilder(tx, s.store.builder.
+		Delete("").
+		From("CSA_Backlinks").
+		Where("NOT EXISTS (SELECT 1 FROM Posts AS p WHERE p.Id = CSA_Backlinks.PostID AND p.DeleteAt = 0)"))
+	if err != nil {
+		return 0, errors.Wrap(err, "could not delete backlinks of deleted posts")
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, errors.Wrap(err, "could not commit transaction")
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.Wrap(err, "could not count deleted backlinks")
+	}
+	return deleted, nil
+}
+
+// ResetBacklinks drops every stored backlink and replaces them with the given ones, mapped by post id.
+// Everything happens in a single transaction, so a failure leaves the previous backlinks untouched.
+func (s *channelStore) ResetBacklinks(backlinksByPostID map[string][]app.BacklinkData) error {
+	tx, err := s.store.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	defer s.store.finalizeTransaction(tx)
+
+	if _, err := s.store.execBuilder(tx, s.store.builder.
+		Delete("").
+		From("CSA_Backlinks")); err != nil {
+		return errors.Wrap(err, "could not delete backlinks")
+	}
+	for postID, backlinks := range backlinksByPostID {
+		if err := s.insertBacklinks(tx, postID, backlinks); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "could not commit transaction")
+	}
+	return nil
+}
+
+func (s *channelStore) insertBacklinks(tx *sqlx.Tx, postID string, backlinks []app.BacklinkData) error {
+	if len(backlinks) == 0 {
+		return nil
+	}
+
 	builder := sq.Insert("CSA_Backlinks").
 		Columns("ID", "PostID", "ElementMarkdownPath", "ElementLinkPart")
 	for _, backlink := range backlinks {
@@ -361,8 +534,15 @@ func (s *channelStore) AddBacklinks(postID string, backlinks []app.BacklinkData)
 	if _, err := s.store.execBuilder(tx, builder); err != nil {
 		return errors.Wrap(err, "could not add backlinks")
 	}
-	if err := tx.Commit(); err != nil {
-		return errors.Wrap(err, "could not commit transaction")
+	return nil
+}
+
+func (s *channelStore) deleteBacklinksByPostID(tx *sqlx.Tx, postID string) error {
+	if _, err := s.store.execBuilder(tx, s.store.builder.
+		Delete("").
+		From("CSA_Backlinks").
+		Where(sq.Eq{"PostID": postID})); err != nil {
+		return errors.Wrapf(err, "could not delete backlinks for post with id '%s'", postID)
 	}
 	return nil
 }
@@ -378,6 +558,61 @@ func (s *channelStore) GetBacklinks(elementLinkPart string) ([]app.BacklinkEntit
 	return results, nil
 }
 
+func (s *channelStore) GetBacklinksByOrganizationID(organizationID string) ([]app.BacklinkEntity, error) {
+	var results []app.BacklinkEntity
+	if err := s.store.selectBuilder(s.store.db, &results, s.backlinksWithPostsSelect().
+		Where(sq.Eq{"c.OrganizationID": organizationID})); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrapf(err, "failed to get backlinks for organization with id '%s'", organizationID)
+	}
+	return results, nil
+}
+
+func (s *channelStore) GetAllBacklinks() ([]app.BacklinkEntity, error) {
+	var results []app.BacklinkEntity
+	if err := s.store.selectBuilder(s.store.db, &results, s.backlinksWithPostsSelect()); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "failed to get all backlinks")
+	}
+	return results, nil
+}
+
+func (s *channelStore) GetBacklinksByChannelID(channelID string) ([]app.BacklinkEntity, error) {
+	var results []app.BacklinkEntity
+	if err := s.store.selectBuilder(s.store.db, &results, s.store.builder.
+		Select(
+			"b.ID AS ID",
+			"b.PostID AS PostID",
+			"b.ElementLinkPart AS ElementLinkPart",
+			"b.ElementMarkdownPath AS ElementMarkdownPath",
+		).
+		From("CSA_Backlinks AS b").
+		Join("Posts AS p ON p.Id = b.PostID").
+		Where(sq.Eq{"p.ChannelId": channelID, "p.DeleteAt": 0})); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrapf(err, "failed to get backlinks for channel with id '%s'", channelID)
+	}
+	return results, nil
+}
+
+// Selects the backlinks of non deleted posts in organization channels, together with the details of their posts
+func (s *channelStore) backlinksWithPostsSelect() sq.SelectBuilder {
+	return s.store.builder.
+		Select(
+			"b.ID AS ID",
+			"b.PostID AS PostID",
+			"p.ChannelId AS ChannelID",
+			"p.UserId AS UserID",
+			"p.RootId AS RootID",
+			"p.CreateAt AS CreateAt",
+			"p.Message AS Message",
+			"c.OrganizationID AS OrganizationID",
+			"b.ElementLinkPart AS ElementLinkPart",
+			"b.ElementMarkdownPath AS ElementMarkdownPath",
+		).
+		From("CSA_Backlinks AS b").
+		Join("Posts AS p ON p.Id = b.PostID").
+		Join("CSA_Channel AS c ON c.ChannelID = p.ChannelId").
+		Where(sq.Eq{"p.DeleteAt": 0})
+}
+
 func (s *channelStore) DeleteBacklink(id string) error {
 	tx, err := s.store.db.Beginx()
 	if err != nil {
@@ -397,3 +632,63 @@ func (s *channelStore) DeleteBacklink(id string) error {
 
 	return nil
 }
+
+// SetBacklinkStance replaces the stance of a backlink in a single transaction
+func (s *channelStore) SetBacklinkStance(stance app.BacklinkStance) error {
+	tx, err := s.store.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	defer s.store.finalizeTransaction(tx)
+
+	if _, err := s.store.execBuilder(tx, s.store.builder.
+		Delete("").
+		From("CSA_BacklinkStance").
+		Where(sq.Eq{"PostID": stance.PostID, "ElementLinkPart": stance.ElementLinkPart})); err != nil {
+		return errors.Wrapf(err, "could not delete stance for post with id '%s'", stance.PostID)
+	}
+	if _, err := s.store.execBuilder(tx, s.store.builder.
+		Insert("CSA_BacklinkStance").
+		Columns("PostID", "ElementLinkPart", "Stance", "UserID", "UpdateAt").
+		Values(stance.PostID, stance.ElementLinkPart, stance.Stance, stance.UserID, stance.UpdateAt)); err != nil {
+		return errors.Wrapf(err, "could not add stance for post with id '%s'", stance.PostID)
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "could not commit transaction")
+	}
+	return nil
+}
+
+func (s *channelStore) DeleteBacklinkStance(postID, elementLinkPart string) error {
+	if _, err := s.store.execBuilder(s.store.db, s.store.builder.
+		Delete("").
+		From("CSA_BacklinkStance").
+		Where(sq.Eq{"PostID": postID, "ElementLinkPart": elementLinkPart})); err != nil {
+		return errors.Wrapf(err, "could not delete stance for post with id '%s'", postID)
+	}
+	return nil
+}
+
+func (s *channelStore) GetBacklinkStancesByPostID(postID string) ([]app.BacklinkStance, error) {
+	results := []app.BacklinkStance{}
+	if err := s.store.selectBuilder(s.store.db, &results, s.backlinkStancesSelect().
+		Where(sq.Eq{"PostID": postID})); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrapf(err, "failed to get stances for post with id '%s'", postID)
+	}
+	return results, nil
+}
+
+func (s *channelStore) GetBacklinkStances(elementLinkPart string) ([]app.BacklinkStance, error) {
+	results := []app.BacklinkStance{}
+	if err := s.store.selectBuilder(s.store.db, &results, s.backlinkStancesSelect().
+		Where(sq.Eq{"ElementLinkPart": elementLinkPart})); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrapf(err, "failed to get stances for element with id '%s'", elementLinkPart)
+	}
+	return results, nil
+}
+
+func (s *channelStore) backlinkStancesSelect() sq.SelectBuilder {
+	return s.store.builder.
+		Select("PostID", "ElementLinkPart", "Stance", "UserID", "UpdateAt").
+		From("CSA_BacklinkStance")
+}
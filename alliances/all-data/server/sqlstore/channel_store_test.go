@@ -0,0 +1,230 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// The store tests need an empty database, set with CSA_TEST_DB_DRIVER (postgres or mysql) and CSA_TEST_DB_DSN, and are skipped otherwise
+const (
+	testDBDriverEnv = "CSA_TEST_DB_DRIVER"
+	testDBDSNEnv    = "CSA_TEST_DB_DSN"
+)
+
+type testStoreAPI struct {
+	db         *sql.DB
+	driverName string
+}
+
+func (a testStoreAPI) GetMasterDB() (*sql.DB, error) {
+	return a.db, nil
+}
+
+func (a testStoreAPI) DriverName() string {
+	return a.driverName
+}
+
+// Stands in for the Mattermost posts table, with the columns the backlinks are joined on
+const testPostsTable = `
+	CREATE TABLE IF NOT EXISTS Posts (
+		Id VARCHAR(26) PRIMARY KEY,
+		ChannelId VARCHAR(26) NOT NULL,
+		UserId VARCHAR(26) NOT NULL,
+		RootId VARCHAR(26) NOT NULL,
+		CreateAt BIGINT NOT NULL,
+		Message TEXT NOT NULL,
+		DeleteAt BIGINT NOT NULL
+	)`
+
+func setupChannelStore(t *testing.T) (*channelStore, *SQLStore) {
+	t.Helper()
+
+	driverName, dsn := os.Getenv(testDBDriverEnv), os.Getenv(testDBDSNEnv)
+	if driverName == "" || dsn == "" {
+		t.Skipf("%s and %s are not set", testDBDriverEnv, testDBDSNEnv)
+	}
+	db, err := sql.Open(driverName, dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	pluginAPI := PluginAPIClient{Store: testStoreAPI{db: db, driverName: driverName}}
+	sqlStore, err := New(pluginAPI)
+	require.NoError(t, err)
+	require.NoError(t, sqlStore.RunMigrations())
+	_, err = sqlStore.db.Exec(testPostsTable)
+	require.NoError(t, err)
+
+	return NewChannelStore(pluginAPI, sqlStore).(*channelStore), sqlStore
+}
+
+func addTestPost(t *testing.T, sqlStore *SQLStore, deleteAt int64) string {
+	t.Helper()
+	return addTestChannelPost(t, sqlStore, model.NewId(), deleteAt)
+}
+
+func addTestChannelPost(t *testing.T, sqlStore *SQLStore, channelID string, deleteAt int64) string {
+	t.Helper()
+
+	postID := model.NewId()
+	_, err := sqlStore.execBuilder(sqlStore.db, sqlStore.builder.
+		Insert("Posts").
+		Columns("Id", "ChannelId", "UserId", "RootId", "CreateAt", "Message", "DeleteAt").
+		Values(postID, channelID, model.NewId(), "", model.GetMillis(), "", deleteAt))
+	require.NoError(t, err)
+	return postID
+}
+
+func backlinkData(keys ...string) []app.BacklinkData {
+	backlinks := make([]app.BacklinkData, 0, len(keys))
+	for _, key := range keys {
+		backlinks = append(backlinks, app.BacklinkData{MarkdownText: key, MarkdownLink: key})
+	}
+	return backlinks
+}
+
+func stanceFor(postID, key, stance string) app.BacklinkStance {
+	return app.BacklinkStance{
+		PostID:          postID,
+		ElementLinkPart: key,
+		Stance:          stance,
+		UserID:          model.NewId(),
+		UpdateAt:        model.GetMillis(),
+	}
+}
+
+func TestSetBacklinks(t *testing.T) {
+	store, sqlStore := setupChannelStore(t)
+	postID := addTestPost(t, sqlStore, 0)
+	kept, dropped := model.NewId(), model.NewId()
+
+	require.NoError(t, store.SetBacklinks(postID, backlinkData(kept, dropped)))
+	require.NoError(t, store.SetBacklinkStance(stanceFor(postID, kept, app.StanceSupports)))
+	require.NoError(t, store.SetBacklinkStance(stanceFor(postID, dropped, app.StanceQuestions)))
+
+	t.Run("replaces the backlinks and prunes the stances of the elements no longer linked", func(t *testing.T) {
+		require.NoError(t, store.SetBacklinks(postID, backlinkData(kept)))
+
+		backlinks, err := store.GetBacklinks(kept)
+		require.NoError(t, err)
+		assert.Len(t, backlinks, 1)
+		backlinks, err = store.GetBacklinks(dropped)
+		require.NoError(t, err)
+		assert.Empty(t, backlinks)
+
+		stances, err := store.GetBacklinkStancesByPostID(postID)
+		require.NoError(t, err)
+		require.Len(t, stances, 1)
+		assert.Equal(t, kept, stances[0].ElementLinkPart)
+		assert.Equal(t, app.StanceSupports, stances[0].Stance)
+	})
+
+	t.Run("removes the backlinks and stances when no element is linked", func(t *testing.T) {
+		require.NoError(t, store.SetBacklinks(postID, nil))
+
+		backlinks, err := store.GetBacklinks(kept)
+		require.NoError(t, err)
+		assert.Empty(t, backlinks)
+		stances, err := store.GetBacklinkStancesByPostID(postID)
+		require.NoError(t, err)
+		assert.Empty(t, stances)
+	})
+}
+
+func TestBacklinkStances(t *testing.T) {
+	store, sqlStore := setupChannelStore(t)
+	postID, otherPostID := addTestPost(t, sqlStore, 0), addTestPost(t, sqlStore, 0)
+	key := model.NewId()
+
+	require.NoError(t, store.SetBacklinkStance(stanceFor(postID, key, app.StanceSupports)))
+	require.NoError(t, store.SetBacklinkStance(stanceFor(otherPostID, key, app.StanceContradicts)))
+
+	t.Run("replaces the stance of a backlink", func(t *testing.T) {
+		updated := stanceFor(postID, key, app.StanceMisrepresents)
+		require.NoError(t, store.SetBacklinkStance(updated))
+
+		stances, err := store.GetBacklinkStancesByPostID(postID)
+		require.NoError(t, err)
+		assert.Equal(t, []app.BacklinkStance{updated}, stances)
+	})
+
+	t.Run("gets the stances of every post linking an element", func(t *testing.T) {
+		stances, err := store.GetBacklinkStances(key)
+		require.NoError(t, err)
+		assert.Len(t, stances, 2)
+	})
+
+	t.Run("deletes the stance of a single backlink", func(t *testing.T) {
+		require.NoError(t, store.DeleteBacklinkStance(postID, key))
+
+		stances, err := store.GetBacklinkStances(key)
+		require.NoError(t, err)
+		require.Len(t, stances, 1)
+		assert.Equal(t, otherPostID, stances[0].PostID)
+	})
+
+	t.Run("deletes the stances with the backlinks of a post", func(t *testing.T) {
+		require.NoError(t, store.SetBacklinks(otherPostID, backlinkData(key)))
+		require.NoError(t, store.DeleteBacklinksByPostID(otherPostID))
+
+		stances, err := store.GetBacklinkStances(key)
+		require.NoError(t, err)
+		assert.Empty(t, stances)
+		backlinks, err := store.GetBacklinks(key)
+		require.NoError(t, err)
+		assert.Empty(t, backlinks)
+	})
+}
+
+func TestDeleteBacklinksOfDeletedPosts(t *testing.T) {
+	store, sqlStore := setupChannelStore(t)
+	activePostID := addTestPost(t, sqlStore, 0)
+	deletedPostID := addTestPost(t, sqlStore, model.GetMillis())
+	missingPostID := model.NewId()
+	key := model.NewId()
+
+	for _, postID := range []string{activePostID, deletedPostID, missingPostID} {
+		require.NoError(t, store.SetBacklinks(postID, backlinkData(key)))
+		require.NoError(t, store.SetBacklinkStance(stanceFor(postID, key, app.StanceSupports)))
+	}
+
+	deleted, err := store.DeleteBacklinksOfDeletedPosts()
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, deleted, int64(2))
+
+	backlinks, err := store.GetBacklinks(key)
+	require.NoError(t, err)
+	require.Len(t, backlinks, 1)
+	assert.Equal(t, activePostID, backlinks[0].PostID)
+
+	stances, err := store.GetBacklinkStances(key)
+	require.NoError(t, err)
+	require.Len(t, stances, 1)
+	assert.Equal(t, activePostID, stances[0].PostID)
+}
+
+func TestGetBacklinksByChannelID(t *testing.T) {
+	store, sqlStore := setupChannelStore(t)
+	channelID, key := model.NewId(), model.NewId()
+	activePostID := addTestChannelPost(t, sqlStore, channelID, 0)
+	deletedPostID := addTestChannelPost(t, sqlStore, channelID, model.GetMillis())
+	otherChannelPostID := addTestPost(t, sqlStore, 0)
+
+	for _, postID := range []string{activePostID, deletedPostID, otherChannelPostID} {
+		require.NoError(t, store.SetBacklinks(postID, backlinkData(key)))
+	}
+
+	backlinks, err := store.GetBacklinksByChannelID(channelID)
+	require.NoError(t, err)
+	require.Len(t, backlinks, 1)
+	assert.Equal(t, activePostID, backlinks[0].PostID)
+	assert.Equal(t, key, backlinks[0].ElementLinkPart)
+}
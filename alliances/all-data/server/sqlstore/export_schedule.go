@@ -0,0 +1,18 @@
+package sqlstore
+
+type ExportScheduleEntity struct {
+	ID              string
+	Name            string
+	Cron            string
+	Scope           string
+	ScopeID         string
+	Params          string // JSON encoded export params, including the filters
+	SinceLastRun    bool
+	ReportChannelID string
+	CreatorID       string
+	NextRunAt       int64
+	LastRunAt       int64
+	LastError       string
+	DisableAt       int64
+	CreateAt        int64
+}
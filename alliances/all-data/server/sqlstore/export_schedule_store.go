@@ -0,0 +1,173 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// exportScheduleStore is a sql store for export schedules
+// Use NewExportScheduleStore to create it
+type exportScheduleStore struct {
+	pluginAPI    PluginAPIClient
+	store        *SQLStore
+	queryBuilder sq.StatementBuilderType
+
+	schedulesSelect sq.SelectBuilder
+}
+
+var _ app.ExportScheduleStore = (*exportScheduleStore)(nil)
+
+// NewExportScheduleStore creates a new store for export schedules.
+func NewExportScheduleStore(pluginAPI PluginAPIClient, sqlStore *SQLStore) app.ExportScheduleStore {
+	schedulesSelect := sqlStore.builder.
+		Select(
+			"ID",
+			"Name",
+			"Cron",
+			"Scope",
+			"ScopeID",
+			"Params",
+			"SinceLastRun",
+			"ReportChannelID",
+			"CreatorID",
+			"NextRunAt",
+			"LastRunAt",
+			"LastError",
+			"DisableAt",
+			"CreateAt",
+		).
+		From("CSA_ExportSchedule")
+
+	return &exportScheduleStore{
+		pluginAPI:       pluginAPI,
+		store:           sqlStore,
+		queryBuilder:    sqlStore.builder,
+		schedulesSelect: schedulesSelect,
+	}
+}
+
+func (s *exportScheduleStore) AddExportSchedule(schedule app.ExportSchedule) error {
+	params, err := json.Marshal(schedule.Params)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal export schedule params")
+	}
+	if _, err := s.store.execBuilder(s.store.db, s.queryBuilder.
+		Insert("CSA_ExportSchedule").
+		SetMap(map[string]interface{}{
+			"ID":              schedule.ID,
+			"Name":            schedule.Name,
+			"Cron":            schedule.Cron,
+			"Scope":           schedule.Scope,
+			"ScopeID":         schedule.ScopeID,
+			"Params":          string(params),
+			"SinceLastRun":    schedule.SinceLastRun,
+			"ReportChannelID": schedule.ReportChannelID,
+			"CreatorID":       schedule.CreatorID,
+			"NextRunAt":       schedule.NextRunAt,
+			"LastRunAt":       schedule.LastRunAt,
+			"LastError":       schedule.LastError,
+			"DisableAt":       schedule.DisableAt,
+			"CreateAt":        schedule.CreateAt,
+		})); err != nil {
+		return errors.Wrap(err, "could not add export schedule")
+	}
+	return nil
+}
+
+func (s *exportScheduleStore) GetExportSchedule(scheduleID string) (app.ExportSchedule, error) {
+	var entity ExportScheduleEntity
+	err := s.store.getBuilder(s.store.db, &entity, s.schedulesSelect.Where(sq.Eq{"ID": scheduleID}))
+	if err == sql.ErrNoRows {
+		return app.ExportSchedule{}, errors.Wrapf(app.ErrNotFound, "export schedule with id '%s' does not exist", scheduleID)
+	} else if err != nil {
+		return app.ExportSchedule{}, errors.Wrapf(err, "failed to get export schedule with id '%s'", scheduleID)
+	}
+	return s.toExportSchedule(entity)
+}
+
+func (s *exportScheduleStore) GetExportSchedules(creatorID string) ([]app.ExportSchedule, error) {
+	query := s.schedulesSelect.OrderBy("CreateAt ASC")
+	if creatorID != "" {
+		query = query.Where(sq.Eq{"CreatorID": creatorID})
+	}
+	return s.getExportSchedules(query)
+}
+
+func (s *exportScheduleStore) GetDueExportSchedules(now int64) ([]app.ExportSchedule, error) {
+	return s.getExportSchedules(s.schedulesSelect.Where(sq.LtOrEq{"NextRunAt": now}).Where(sq.Eq{"DisableAt": 0}).OrderBy("NextRunAt ASC"))
+}
+
+func (s *exportScheduleStore) UpdateExportScheduleRun(scheduleID string, lastRunAt, nextRunAt int64, lastError string) error {
+	if _, err := s.store.execBuilder(s.store.db, s.queryBuilder.
+		Update("CSA_ExportSchedule").
+		Set("LastRunAt", lastRunAt).
+		Set("NextRunAt", nextRunAt).
+		Set("LastError", lastError).
+		Where(sq.Eq{"ID": scheduleID})); err != nil {
+		return errors.Wrapf(err, "could not update run of export schedule with id '%s'", scheduleID)
+	}
+	return nil
+}
+
+func (s *exportScheduleStore) DisableExportSchedule(scheduleID string, disableAt int64, reason string) error {
+	if _, err := s.store.execBuilder(s.store.db, s.queryBuilder.
+		Update("CSA_ExportSchedule").
+		Set("DisableAt", disableAt).
+		Set("LastError", reason).
+		Where(sq.Eq{"ID": scheduleID})); err != nil {
+		return errors.Wrapf(err, "could not disable export schedule with id '%s'", scheduleID)
+	}
+	return nil
+}
+
+func (s *exportScheduleStore) DeleteExportSchedule(scheduleID string) error {
+	if _, err := s.store.execBuilder(s.store.db, s.queryBuilder.
+		Delete("CSA_ExportSchedule").
+		Where(sq.Eq{"ID": scheduleID})); err != nil {
+		return errors.Wrapf(err, "could not delete export schedule with id '%s'", scheduleID)
+	}
+	return nil
+}
+
+func (s *exportScheduleStore) getExportSchedules(query sq.SelectBuilder) ([]app.ExportSchedule, error) {
+	var entities []ExportScheduleEntity
+	if err := s.store.selectBuilder(s.store.db, &entities, query); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "failed to get export schedules")
+	}
+	schedules := make([]app.ExportSchedule, 0, len(entities))
+	for _, entity := range entities {
+		schedule, err := s.toExportSchedule(entity)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+	return schedules, nil
+}
+
+func (s *exportScheduleStore) toExportSchedule(entity ExportScheduleEntity) (app.ExportSchedule, error) {
+	schedule := app.ExportSchedule{
+		ID:              entity.ID,
+		Name:            entity.Name,
+		Cron:            entity.Cron,
+		Scope:           entity.Scope,
+		ScopeID:         entity.ScopeID,
+		SinceLastRun:    entity.SinceLastRun,
+		ReportChannelID: entity.ReportChannelID,
+		CreatorID:       entity.CreatorID,
+		NextRunAt:       entity.NextRunAt,
+		LastRunAt:       entity.LastRunAt,
+		LastError:       entity.LastError,
+		DisableAt:       entity.DisableAt,
+		CreateAt:        entity.CreateAt,
+	}
+	if err := json.Unmarshal([]byte(entity.Params), &schedule.Params); err != nil {
+		return app.ExportSchedule{}, errors.Wrapf(err, "could not unmarshal params of export schedule with id '%s'", entity.ID)
+	}
+	return schedule, nil
+}
@@ -0,0 +1,136 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/mattermost/mattermost-server/v6/model"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// An interface to the Mattermost posts table, to page through channels in conversation order.
+// The RPC API only returns posts newest first by offset, which is neither stable nor cheap on large channels.
+type mattermostPostStore struct {
+	pluginAPI    PluginAPIClient
+	store        *SQLStore
+	queryBuilder sq.StatementBuilderType
+
+	postsSelect sq.SelectBuilder
+}
+
+var _ app.MattermostPostStore = (*mattermostPostStore)(nil)
+
+func NewMattermostPostStore(pluginAPI PluginAPIClient, sqlStore *SQLStore) app.MattermostPostStore {
+	postsSelect := sqlStore.builder.
+		Select(
+			"p.Id AS Id",
+			"p.CreateAt AS CreateAt",
+			"p.UpdateAt AS UpdateAt",
+			"p.EditAt AS EditAt",
+			"p.DeleteAt AS DeleteAt",
+			"p.IsPinned AS IsPinned",
+			"p.UserId AS UserId",
+			"p.ChannelId AS ChannelId",
+			"p.RootId AS RootId",
+			"p.OriginalId AS OriginalId",
+			"p.Message AS Message",
+			"p.Type AS Type",
+			"p.Props AS Props",
+			"p.Hashtags AS Hashtags",
+			"p.FileIds AS FileIds",
+		).
+		From("Posts AS p").
+		Where(sq.Eq{"p.DeleteAt": 0}).
+		Where(sq.Eq{"p.OriginalId": ""})
+
+	return &mattermostPostStore{
+		pluginAPI:    pluginAPI,
+		store:        sqlStore,
+		queryBuilder: sqlStore.builder,
+		postsSelect:  postsSelect,
+	}
+}
+
+// Get a page of the root posts of a channel, oldest first, following the given cursor.
+func (s *mattermostPostStore) GetRootPostsForChannel(channelID string, after app.PostCursor, limit int, options app.RootPostsOptions) ([]*model.Post, error) {
+	queryForResults := s.rootPostsWhere(s.postsSelect, channelID, options).
+		Where(sq.Or{
+			sq.Gt{"p.CreateAt": after.CreateAt},
+			sq.And{sq.Eq{"p.CreateAt": after.CreateAt}, sq.Gt{"p.Id": after.PostID}},
+		}).
+		OrderBy("p.CreateAt ASC", "p.Id ASC").
+		Limit(uint64(limit))
+
+	var posts []*model.Post
+	if err := s.store.selectBuilder(s.store.db, &posts, queryForResults); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrapf(err, "could not get root posts for channel with id '%s'", channelID)
+	}
+	return posts, nil
+}
+
+// Count the root posts of a channel, to report the progress of an export.
+func (s *mattermostPostStore) CountRootPostsForChannel(channelID string, options app.RootPostsOptions) (int, error) {
+	queryForCount := s.rootPostsWhere(s.queryBuilder.Select("COUNT(*)").From("Posts AS p"), channelID, options).
+		Where(sq.Eq{"p.DeleteAt": 0}).
+		Where(sq.Eq{"p.OriginalId": ""})
+
+	var count int
+	if err := s.store.getBuilder(s.store.db, &count, queryForCount); err != nil {
+		return 0, errors.Wrapf(err, "could not count root posts for channel with id '%s'", channelID)
+	}
+	return count, nil
+}
+
+// Get the replies to the given root posts, oldest first.
+func (s *mattermostPostStore) GetRepliesForRootPosts(rootIDs []string) ([]*model.Post, error) {
+	if len(rootIDs) == 0 {
+		return []*model.Post{}, nil
+	}
+	queryForResults := s.postsSelect.
+		Where(sq.Eq{"p.RootId": rootIDs}).
+		OrderBy("p.CreateAt ASC", "p.Id ASC")
+
+	var posts []*model.Post
+	if err := s.store.selectBuilder(s.store.db, &posts, queryForResults); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "could not get replies for root posts")
+	}
+	return posts, nil
+}
+
+// Get the reactions to the given posts.
+func (s *mattermostPostStore) GetReactionsForPosts(postIDs []string) ([]*model.Reaction, error) {
+	if len(postIDs) == 0 {
+		return []*model.Reaction{}, nil
+	}
+	queryForResults := s.queryBuilder.
+		Select(
+			"r.UserId AS UserId",
+			"r.PostId AS PostId",
+			"r.EmojiName AS EmojiName",
+			"r.CreateAt AS CreateAt",
+		).
+		From("Reactions AS r").
+		Where(sq.Eq{"r.PostId": postIDs}).
+		Where(sq.Eq{"r.DeleteAt": 0})
+
+	var reactions []*model.Reaction
+	if err := s.store.selectBuilder(s.store.db, &reactions, queryForResults); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "could not get reactions for posts")
+	}
+	return reactions, nil
+}
+
+func (s *mattermostPostStore) rootPostsWhere(builder sq.SelectBuilder, channelID string, options app.RootPostsOptions) sq.SelectBuilder {
+	builder = builder.
+		Where(sq.Eq{"p.ChannelId": channelID}).
+		Where(sq.Eq{"p.RootId": ""})
+	if options.PinnedOnly {
+		builder = builder.Where(sq.Eq{"p.IsPinned": true})
+	}
+	if options.Until > 0 {
+		builder = builder.Where(sq.LtOrEq{"p.CreateAt": options.Until})
+	}
+	return builder
+}
@@ -0,0 +1,9 @@
+package sqlstore
+
+type MembershipEntity struct {
+	UserID         string
+	OrganizationID string
+	Role           string
+	CreateAt       int64
+	UpdateAt       int64
+}
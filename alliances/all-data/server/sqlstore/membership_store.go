@@ -0,0 +1,145 @@
+package sqlstore
+
+import (
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/pkg/errors"
+
+	"github.com/tizianocitro/hood-framework/alliances/all-data/server/app"
+)
+
+// membershipStore is a sql store for the memberships of users in organizations
+// Use NewMembershipStore to create it
+type membershipStore struct {
+	pluginAPI    PluginAPIClient
+	store        *SQLStore
+	queryBuilder sq.StatementBuilderType
+
+	membershipsSelect sq.SelectBuilder
+}
+
+var _ app.MembershipStore = (*membershipStore)(nil)
+
+// NewMembershipStore creates a new store for organization memberships.
+func NewMembershipStore(pluginAPI PluginAPIClient, sqlStore *SQLStore) app.MembershipStore {
+	membershipsSelect := sqlStore.builder.
+		Select(
+			"UserID",
+			"OrganizationID",
+			"Role",
+			"CreateAt",
+			"UpdateAt",
+		).
+		From("CSA_OrganizationMembership")
+
+	return &membershipStore{
+		pluginAPI:         pluginAPI,
+		store:             sqlStore,
+		queryBuilder:      sqlStore.builder,
+		membershipsSelect: membershipsSelect,
+	}
+}
+
+func (s *membershipStore) AddMembership(membership app.OrganizationMembership) error {
+	tx, err := s.store.db.Beginx()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	defer s.store.finalizeTransaction(tx)
+
+	var entity MembershipEntity
+	err = s.store.getBuilder(tx, &entity, s.membershipsSelect.Where(sq.Eq{
+		"UserID":         membership.UserID,
+		"OrganizationID": membership.OrganizationID,
+	}))
+	if err == nil {
+		return errors.Wrapf(app.ErrAlreadyExists, "user '%s' is already a member of organization '%s'", membership.UserID, membership.OrganizationID)
+	} else if err != sql.ErrNoRows {
+		return errors.Wrap(err, "could not check existing membership")
+	}
+
+	if _, err := s.store.execBuilder(tx, s.queryBuilder.
+		Insert("CSA_OrganizationMembership").
+		SetMap(map[string]interface{}{
+			"UserID":         membership.UserID,
+			"OrganizationID": membership.OrganizationID,
+			"Role":           membership.Role,
+			"CreateAt":       membership.CreateAt,
+			"UpdateAt":       membership.UpdateAt,
+		})); err != nil {
+		return errors.Wrap(err, "could not add membership")
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "could not commit transaction")
+	}
+	return nil
+}
+
+func (s *membershipStore) GetMembership(userID, organizationID string) (app.OrganizationMembership, error) {
+	var entity MembershipEntity
+	err := s.store.getBuilder(s.store.db, &entity, s.membershipsSelect.Where(sq.Eq{
+		"UserID":         userID,
+		"OrganizationID": organizationID,
+	}))
+	if err == sql.ErrNoRows {
+		return app.OrganizationMembership{}, errors.Wrapf(app.ErrNotFound, "user '%s' is not a member of organization '%s'", userID, organizationID)
+	} else if err != nil {
+		return app.OrganizationMembership{}, errors.Wrapf(err, "failed to get membership of user '%s' in organization '%s'", userID, organizationID)
+	}
+	return toMembership(entity), nil
+}
+
+func (s *membershipStore) GetMembershipsByUserID(userID string) ([]app.OrganizationMembership, error) {
+	return s.getMemberships(s.membershipsSelect.Where(sq.Eq{"UserID": userID}).OrderBy("CreateAt ASC"))
+}
+
+func (s *membershipStore) GetMembershipsByOrganizationID(organizationID string) ([]app.OrganizationMembership, error) {
+	return s.getMemberships(s.membershipsSelect.Where(sq.Eq{"OrganizationID": organizationID}).OrderBy("CreateAt ASC"))
+}
+
+func (s *membershipStore) UpdateMembershipRole(userID, organizationID, role string, updateAt int64) error {
+	result, err := s.store.execBuilder(s.store.db, s.queryBuilder.
+		Update("CSA_OrganizationMembership").
+		Set("Role", role).
+		Set("UpdateAt", updateAt).
+		Where(sq.Eq{"UserID": userID, "OrganizationID": organizationID}))
+	if err != nil {
+		return errors.Wrapf(err, "could not update role of user '%s' in organization '%s'", userID, organizationID)
+	}
+	if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+		return errors.Wrapf(app.ErrNotFound, "user '%s' is not a member of organization '%s'", userID, organizationID)
+	}
+	return nil
+}
+
+func (s *membershipStore) DeleteMembership(userID, organizationID string) error {
+	if _, err := s.store.execBuilder(s.store.db, s.queryBuilder.
+		Delete("CSA_OrganizationMembership").
+		Where(sq.Eq{"UserID": userID, "OrganizationID": organizationID})); err != nil {
+		return errors.Wrapf(err, "could not delete membership of user '%s' in organization '%s'", userID, organizationID)
+	}
+	return nil
+}
+
+func (s *membershipStore) getMemberships(query sq.SelectBuilder) ([]app.OrganizationMembership, error) {
+	var entities []MembershipEntity
+	if err := s.store.selectBuilder(s.store.db, &entities, query); err != nil && err != sql.ErrNoRows {
+		return nil, errors.Wrap(err, "failed to get memberships")
+	}
+	memberships := make([]app.OrganizationMembership, 0, len(entities))
+	for _, entity := range entities {
+		memberships = append(memberships, toMembership(entity))
+	}
+	return memberships, nil
+}
+
+func toMembership(entity MembershipEntity) app.OrganizationMembership {
+	return app.OrganizationMembership{
+		UserID:         entity.UserID,
+		OrganizationID: entity.OrganizationID,
+		Role:           entity.Role,
+		CreateAt:       entity.CreateAt,
+		UpdateAt:       entity.UpdateAt,
+	}
+}
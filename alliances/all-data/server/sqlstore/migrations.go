@@ -128,4 +128,223 @@ var migrations = []Migration{
 			return nil
 		},
 	},
+	{
+		fromVersion: semver.MustParse("0.5.0"),
+		toVersion:   semver.MustParse("0.6.0"),
+		migrationFunc: func(e sqlx.Ext, sqlStore *SQLStore) error {
+			if e.DriverName() == model.DatabaseDriverMysql {
+				if _, err := e.Exec(`
+				CREATE TABLE IF NOT EXISTS CSA_ExportSchedule (
+					ID VARCHAR(36) PRIMARY KEY,
+					Name VARCHAR(256) NOT NULL,
+					Cron VARCHAR(128) NOT NULL,
+					Scope VARCHAR(32) NOT NULL,
+					ScopeID VARCHAR(128) NOT NULL,
+					Params TEXT NOT NULL,
+					SinceLastRun BOOLEAN NOT NULL,
+					ReportChannelID VARCHAR(26) NOT NULL,
+					CreatorID VARCHAR(26) NOT NULL,
+					NextRunAt BIGINT NOT NULL,
+					LastRunAt BIGINT NOT NULL,
+					LastError TEXT NOT NULL,
+					CreateAt BIGINT NOT NULL,
+					INDEX (NextRunAt),
+					INDEX (CreatorID)
+				)
+			` + MySQLCharset); err != nil {
+					return errors.Wrapf(err, "failed creating table CSA_ExportSchedule")
+				}
+			} else {
+				if _, err := e.Exec(`
+				CREATE TABLE IF NOT EXISTS CSA_ExportSchedule (
+					ID VARCHAR(36) PRIMARY KEY,
+					Name VARCHAR(256) NOT NULL,
+					Cron VARCHAR(128) NOT NULL,
+					Scope VARCHAR(32) NOT NULL,
+					ScopeID VARCHAR(128) NOT NULL,
+					Params TEXT NOT NULL,
+					SinceLastRun BOOLEAN NOT NULL,
+					ReportChannelID VARCHAR(26) NOT NULL,
+					CreatorID VARCHAR(26) NOT NULL,
+					NextRunAt BIGINT NOT NULL,
+					LastRunAt BIGINT NOT NULL,
+					LastError TEXT NOT NULL,
+					CreateAt BIGINT NOT NULL
+				);
+
+				CREATE INDEX CSA_ExportSchedule_NextRunAt_idx ON CSA_ExportSchedule (NextRunAt ASC);
+				CREATE INDEX CSA_ExportSchedule_CreatorID_idx ON CSA_ExportSchedule (CreatorID ASC);
+				`); err != nil {
+					return errors.Wrapf(err, "failed creating table CSA_ExportSchedule")
+				}
+			}
+			return nil
+		},
+	},
+	{
+		fromVersion: semver.MustParse("0.6.0"),
+		toVersion:   semver.MustParse("0.7.0"),
+		migrationFunc: func(e sqlx.Ext, sqlStore *SQLStore) error {
+			if e.DriverName() == model.DatabaseDriverMysql {
+				if _, err := e.Exec(`
+				CREATE TABLE IF NOT EXISTS CSA_OrganizationMembership (
+					UserID VARCHAR(26) NOT NULL,
+					OrganizationID VARCHAR(26) NOT NULL,
+					Role VARCHAR(32) NOT NULL,
+					CreateAt BIGINT NOT NULL,
+					UpdateAt BIGINT NOT NULL,
+					PRIMARY KEY (UserID, OrganizationID),
+					INDEX (OrganizationID)
+				)
+			` + MySQLCharset); err != nil {
+					return errors.Wrapf(err, "failed creating table CSA_OrganizationMembership")
+				}
+			} else {
+				if _, err := e.Exec(`
+				CREATE TABLE IF NOT EXISTS CSA_OrganizationMembership (
+					UserID VARCHAR(26) NOT NULL,
+					OrganizationID VARCHAR(26) NOT NULL,
+					Role VARCHAR(32) NOT NULL,
+					CreateAt BIGINT NOT NULL,
+					UpdateAt BIGINT NOT NULL,
+					PRIMARY KEY (UserID, OrganizationID)
+				);
+
+				CREATE INDEX CSA_OrganizationMembership_OrganizationID_idx ON CSA_OrganizationMembership (OrganizationID ASC);
+				`); err != nil {
+					return errors.Wrapf(err, "failed creating table CSA_OrganizationMembership")
+				}
+			}
+			return nil
+		},
+	},
+	{
+		fromVersion: semver.MustParse("0.7.0"),
+		toVersion:   semver.MustParse("0.8.0"),
+		migrationFunc: func(e sqlx.Ext, sqlStore *SQLStore) error {
+			if e.DriverName() == model.DatabaseDriverMysql {
+				if _, err := e.Exec(`
+				CREATE TABLE IF NOT EXISTS CSA_AuditLog (
+					ID VARCHAR(36) PRIMARY KEY,
+					UserID VARCHAR(26) NOT NULL,
+					Action VARCHAR(64) NOT NULL,
+					TeamID VARCHAR(26) NOT NULL,
+					TargetID VARCHAR(128) NOT NULL,
+					Allowed BOOLEAN NOT NULL,
+					Details TEXT NOT NULL,
+					CreateAt BIGINT NOT NULL,
+					INDEX (CreateAt),
+					INDEX (UserID)
+				)
+			` + MySQLCharset); err != nil {
+					return errors.Wrapf(err, "failed creating table CSA_AuditLog")
+				}
+			} else {
+				if _, err := e.Exec(`
+				CREATE TABLE IF NOT EXISTS CSA_AuditLog (
+					ID VARCHAR(36) PRIMARY KEY,
+					UserID VARCHAR(26) NOT NULL,
+					Action VARCHAR(64) NOT NULL,
+					TeamID VARCHAR(26) NOT NULL,
+					TargetID VARCHAR(128) NOT NULL,
+					Allowed BOOLEAN NOT NULL,
+					Details TEXT NOT NULL,
+					CreateAt BIGINT NOT NULL
+				);
+
+				CREATE INDEX CSA_AuditLog_CreateAt_idx ON CSA_AuditLog (CreateAt ASC);
+				CREATE INDEX CSA_AuditLog_UserID_idx ON CSA_AuditLog (UserID ASC);
+				`); err != nil {
+					return errors.Wrapf(err, "failed creating table CSA_AuditLog")
+				}
+			}
+			return nil
+		},
+	},
+	{
+		fromVersion: semver.MustParse("0.8.0"),
+		toVersion:   semver.MustParse("0.9.0"),
+		migrationFunc: func(e sqlx.Ext, sqlStore *SQLStore) error {
+			// Organizations can have more categories, one for each sidebar rule besides the main one, which has no rule id
+			if e.DriverName() == model.DatabaseDriverMysql {
+				if _, err := e.Exec(`
+				CREATE TABLE IF NOT EXISTS CSA_OrganizationCategory (
+					UserID VARCHAR(26) NOT NULL,
+					TeamID VARCHAR(26) NOT NULL,
+					OrganizationID VARCHAR(26) NOT NULL,
+					CategoryID VARCHAR(128) NOT NULL,
+					CreateAt BIGINT NOT NULL,
+					RuleID VARCHAR(64) NOT NULL DEFAULT '',
+					PRIMARY KEY (UserID, TeamID, OrganizationID, RuleID)
+				)
+			` + MySQLCharset); err != nil {
+					return errors.Wrapf(err, "failed creating table CSA_OrganizationCategory")
+				}
+			} else {
+				if _, err := e.Exec(`
+				CREATE TABLE IF NOT EXISTS CSA_OrganizationCategory (
+					UserID VARCHAR(26) NOT NULL,
+					TeamID VARCHAR(26) NOT NULL,
+					OrganizationID VARCHAR(26) NOT NULL,
+					CategoryID VARCHAR(128) NOT NULL,
+					CreateAt BIGINT NOT NULL,
+					RuleID VARCHAR(64) NOT NULL DEFAULT '',
+					PRIMARY KEY (UserID, TeamID, OrganizationID, RuleID)
+				);
+				`); err != nil {
+					return errors.Wrapf(err, "failed creating table CSA_OrganizationCategory")
+				}
+			}
+			return nil
+		},
+	},
+	{
+		fromVersion: semver.MustParse("0.9.0"),
+		toVersion:   semver.MustParse("0.10.0"),
+		migrationFunc: func(e sqlx.Ext, sqlStore *SQLStore) error {
+			// Stances are kept apart from the backlinks, which are replaced whenever a post is edited
+			if e.DriverName() == model.DatabaseDriverMysql {
+				if _, err := e.Exec(`
+				CREATE TABLE IF NOT EXISTS CSA_BacklinkStance (
+					PostID VARCHAR(26) NOT NULL,
+					ElementLinkPart VARCHAR(300) NOT NULL,
+					Stance VARCHAR(32) NOT NULL,
+					UserID VARCHAR(26) NOT NULL,
+					UpdateAt BIGINT NOT NULL,
+					PRIMARY KEY (PostID, ElementLinkPart),
+					INDEX (ElementLinkPart)
+				)
+			` + MySQLCharset); err != nil {
+					return errors.Wrapf(err, "failed creating table CSA_BacklinkStance")
+				}
+			} else {
+				if _, err := e.Exec(`
+				CREATE TABLE IF NOT EXISTS CSA_BacklinkStance (
+					PostID VARCHAR(26) NOT NULL,
+					ElementLinkPart VARCHAR(300) NOT NULL,
+					Stance VARCHAR(32) NOT NULL,
+					UserID VARCHAR(26) NOT NULL,
+					UpdateAt BIGINT NOT NULL,
+					PRIMARY KEY (PostID, ElementLinkPart)
+				);
+
+				CREATE INDEX CSA_BacklinkStance_Element_ID_idx ON CSA_BacklinkStance (ElementLinkPart ASC);
+				`); err != nil {
+					return errors.Wrapf(err, "failed creating table CSA_BacklinkStance")
+				}
+			}
+			return nil
+		},
+	},
+	{
+		fromVersion: semver.MustParse("0.10.0"),
+		toVersion:   semver.MustParse("0.11.0"),
+		migrationFunc: func(e sqlx.Ext, sqlStore *SQLStore) error {
+			// Schedules whose creator can no longer run them are disabled instead of running with stale permissions
+			if _, err := e.Exec(`ALTER TABLE CSA_ExportSchedule ADD DisableAt BIGINT NOT NULL DEFAULT 0`); err != nil {
+				return errors.Wrapf(err, "failed adding column DisableAt to table CSA_ExportSchedule")
+			}
+			return nil
+		},
+	},
 }
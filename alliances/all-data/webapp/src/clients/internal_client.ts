@@ -8,16 +8,34 @@ import qs from 'qs';
 import {
     AddChannelParams,
     AddChannelResult,
+    AddExportScheduleParams,
     ArchiveChannelsParams,
+    BacklinkStance,
+    ExportFilters,
+    ExportJob,
+    ExportSchedule,
     FetchChannelByIDResult,
     FetchChannelsParams,
     FetchChannelsResult,
     GetBacklinksResult,
+    GetExportSchedulesResult,
+    ImportChannelParams,
+    ImportChannelResult,
+    MoveChannelParams,
+    RenameChannelParams,
+    SetBacklinkStanceParams,
+    SyncIssueChannelParams,
 } from 'src/types/channels';
 
 // import {PLATFORM_CONFIG_CACHE_NAME} from 'src/config/config';
 
-import {PlatformConfig} from 'src/types/organization';
+import {
+    GetMembershipsResult,
+    MembershipRole,
+    OrganizationMembership,
+    PlatformConfig,
+    ReconciliationReport,
+} from 'src/types/organization';
 import {pluginId} from 'src/manifest';
 import {
     ArchiveIssueChannelsParams,
@@ -122,6 +140,26 @@ export const fetchChannelById = async (channelId: string): Promise<FetchChannelB
     return data;
 };
 
+export const renameChannel = async (channelId: string, params: RenameChannelParams): Promise<FetchChannelByIDResult | undefined> => {
+    return doPut<FetchChannelByIDResult>(`${apiUrl}/channel/${channelId}/rename`, JSON.stringify(params));
+};
+
+export const moveChannel = async (channelId: string, params: MoveChannelParams): Promise<FetchChannelByIDResult | undefined> => {
+    return doPut<FetchChannelByIDResult>(`${apiUrl}/channel/${channelId}/move`, JSON.stringify(params));
+};
+
+export const unlinkChannel = async (channelId: string): Promise<void> => {
+    await doPost(`${apiUrl}/channel/${channelId}/unlink`, '');
+};
+
+export const archiveChannel = async (channelId: string): Promise<void> => {
+    await doPost(`${apiUrl}/channel/${channelId}/archive`, '');
+};
+
+export const restoreChannel = async (channelId: string): Promise<FetchChannelByIDResult | undefined> => {
+    return doPost<FetchChannelByIDResult>(`${apiUrl}/channel/${channelId}/restore`, '');
+};
+
 export const fetchPostsByIds = async (params: PostsByIdsParams): Promise<GetPostsByIdsResult> => {
     let data = await doPost<GetPostsByIdsResult>(
         `${apiUrl}/posts`,
@@ -157,6 +195,17 @@ export const addChannel = async (params: AddChannelParams): Promise<AddChannelRe
     return data;
 };
 
+export const syncIssueChannel = async (issueId: string, params: SyncIssueChannelParams): Promise<AddChannelResult> => {
+    let data = await doPost<AddChannelResult>(
+        `${apiUrl}/issues/${issueId}/channel`,
+        JSON.stringify(params),
+    );
+    if (!data) {
+        data = {channelId: '', parentId: '', sectionId: ''} as AddChannelResult;
+    }
+    return data;
+};
+
 export const userAdded = async (params: UserAddedParams): Promise<void> => {
     await doPost(
         `${apiUrl}/events/user_added`,
@@ -190,16 +239,129 @@ export const exportChannel = async (
     format: string,
     pinnedOnly: boolean,
     references: ExportReference[],
+    filters: ExportFilters = {},
 ): Promise<Blob> => {
     const body = JSON.stringify({
         format,
         pinnedOnly,
         references,
+        ...filters,
     });
     const {data} = await doFetchWithBlobResponse(`${apiUrl}/channel/${channelId}/export`, {method: 'POST', body});
     return data;
 };
 
+export const startExportJob = async (
+    channelId: string,
+    format: string,
+    pinnedOnly: boolean,
+    references: ExportReference[],
+    filters: ExportFilters = {},
+): Promise<ExportJob | undefined> => {
+    const body = JSON.stringify({
+        format,
+        pinnedOnly,
+        references,
+        ...filters,
+    });
+    return doPost<ExportJob>(`${apiUrl}/exports/channels/${channelId}`, body);
+};
+
+export const startSectionExportJob = async (
+    sectionId: string,
+    format: string,
+    pinnedOnly: boolean,
+): Promise<ExportJob | undefined> => {
+    const body = JSON.stringify({format, pinnedOnly});
+    return doPost<ExportJob>(`${apiUrl}/exports/sections/${sectionId}`, body);
+};
+
+export const startOrganizationExportJob = async (
+    organizationId: string,
+    format: string,
+    pinnedOnly: boolean,
+): Promise<ExportJob | undefined> => {
+    const body = JSON.stringify({format, pinnedOnly});
+    return doPost<ExportJob>(`${apiUrl}/exports/organizations/${organizationId}`, body);
+};
+
+export const getExportJob = async (jobId: string): Promise<ExportJob | undefined> => {
+    return doGet<ExportJob>(`${apiUrl}/exports/${jobId}`);
+};
+
+export const importChannel = async (
+    file: File,
+    params: ImportChannelParams,
+): Promise<ImportChannelResult | undefined> => {
+    const body = new FormData();
+    body.append('file', file);
+    Object.entries(params).forEach(([key, value]) => body.append(key, String(value ?? '')));
+    return doPost<ImportChannelResult>(`${apiUrl}/imports/channels`, body);
+};
+
+export const getUserMemberships = async (userId?: string): Promise<GetMembershipsResult> => {
+    const queryParams = qs.stringify({userId}, {addQueryPrefix: true});
+    let data = await doGet<GetMembershipsResult>(`${apiUrl}/memberships${queryParams}`);
+    if (!data) {
+        data = {items: []};
+    }
+    return data;
+};
+
+export const getOrganizationMemberships = async (organizationId: string): Promise<GetMembershipsResult> => {
+    let data = await doGet<GetMembershipsResult>(`${apiUrl}/memberships/organizations/${organizationId}`);
+    if (!data) {
+        data = {items: []};
+    }
+    return data;
+};
+
+export const addMembership = async (
+    userId: string,
+    organizationId: string,
+    role: MembershipRole = 'member',
+): Promise<OrganizationMembership | undefined> => {
+    const body = JSON.stringify({userId, organizationId, role});
+    return doPost<OrganizationMembership>(`${apiUrl}/memberships`, body);
+};
+
+export const updateMembership = async (
+    userId: string,
+    organizationId: string,
+    role: MembershipRole,
+): Promise<OrganizationMembership | undefined> => {
+    const body = JSON.stringify({role});
+    return doPut<OrganizationMembership>(`${apiUrl}/memberships/organizations/${organizationId}/users/${userId}`, body);
+};
+
+export const removeMembership = async (userId: string, organizationId: string): Promise<void> => {
+    await doDelete(`${apiUrl}/memberships/organizations/${organizationId}/users/${userId}`);
+};
+
+export const startReconciliation = async (): Promise<void> => {
+    await doPost(`${apiUrl}/memberships/reconcile`, '');
+};
+
+export const getReconciliationReport = async (): Promise<ReconciliationReport | undefined> => {
+    return doGet<ReconciliationReport>(`${apiUrl}/memberships/reconcile`);
+};
+
+export const getExportSchedules = async (): Promise<GetExportSchedulesResult> => {
+    let data = await doGet<GetExportSchedulesResult>(`${apiUrl}/export_schedules`);
+    if (!data) {
+        data = {items: []};
+    }
+    return data;
+};
+
+export const addExportSchedule = async (params: AddExportScheduleParams): Promise<ExportSchedule | undefined> => {
+    return doPost<ExportSchedule>(`${apiUrl}/export_schedules`, JSON.stringify(params));
+};
+
+export const deleteExportSchedule = async (scheduleId: string): Promise<void> => {
+    await doDelete(`${apiUrl}/export_schedules/${scheduleId}`);
+};
+
 export interface UserProps {
     orgId: string;
 }
@@ -222,6 +384,16 @@ export const getBacklinks = async (params: GetBacklinksParams): Promise<GetBackl
     return data as GetBacklinksResult;
 };
 
+export const setBacklinkStance = async (postId: string, params: SetBacklinkStanceParams) => {
+    return doPut<BacklinkStance>(`${apiUrl}/backlinks/posts/${postId}/stance`, JSON.stringify(params));
+};
+
+export const exportBacklinkGraph = async (format: string, organizationId?: string): Promise<Blob> => {
+    const queryParams = qs.stringify({format, organizationId}, {addQueryPrefix: true, indices: false});
+    const {data} = await doFetchWithBlobResponse(`${apiUrl}/backlinks/graph${queryParams}`, {method: 'get'});
+    return data;
+};
+
 const doGet = async <TData = any>(url: string): Promise<TData | undefined> => {
     const {data} = await doFetchWithResponse<TData>(url, {method: 'get'});
     return data;
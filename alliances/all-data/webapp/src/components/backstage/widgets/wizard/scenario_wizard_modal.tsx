@@ -11,9 +11,7 @@ import {getCurrentTeamId} from 'mattermost-webapp/packages/mattermost-redux/src/
 import {useSelector} from 'react-redux';
 import {useRouteMatch} from 'react-router-dom';
 
-import {getCurrentUserId} from 'mattermost-webapp/packages/mattermost-redux/src/selectors/entities/common';
-
-import {addChannel, saveSectionInfo, updateSectionInfo} from 'src/clients';
+import {saveSectionInfo, syncIssueChannel, updateSectionInfo} from 'src/clients';
 import {navigateToUrl} from 'src/browser_routing';
 import {
     formatName,
@@ -29,7 +27,6 @@ import {
     ecosystemOutcomesWidget,
     ecosystemRolesWidget,
 } from 'src/constants';
-import {useOrganization} from 'src/hooks';
 import {OrganizationIdContext} from 'src/components/backstage/organizations/organization_details';
 import {HorizontalSpacer} from 'src/components/backstage/grid';
 import {ErrorMessage} from 'src/components/commons/messages';
@@ -140,9 +137,7 @@ const ScenarioWizardModal = ({
     const {formatMessage} = useIntl();
     const {path} = useRouteMatch();
     const teamId = useSelector(getCurrentTeamId);
-    const userId = useSelector(getCurrentUserId);
     const organizationId = useContext(OrganizationIdContext);
-    const organization = useOrganization(organizationId);
 
     const emptyWizardDataError = {
         nameError: '',
@@ -212,14 +207,11 @@ const ScenarioWizardModal = ({
     const saveIssue = async (issue: SectionInfo) => {
         try {
             const savedSectionInfo = await saveSectionInfo(issue, targetUrl);
-            await addChannel({
-                userId,
-                channelName: formatName(`${organization.name}-${savedSectionInfo.name}`),
-                createPublicChannel: true,
-                parentId,
-                sectionId: savedSectionInfo.id,
+            await syncIssueChannel(savedSectionInfo.id, {
                 teamId,
+                parentId,
                 organizationId,
+                createPublicChannel: true,
             });
             cleanModal();
             const basePath = `${formatSectionPath(path, organizationId)}/${formatName(name)}`;
@@ -1,4 +1,4 @@
-import React, {FC, HTMLAttributes} from 'react';
+import React, {FC, HTMLAttributes, useState} from 'react';
 import styled, {css} from 'styled-components';
 import {
     Alert,
@@ -6,10 +6,12 @@ import {
     Card,
     List,
     Modal,
+    Select,
     Space,
     Tabs,
     TabsProps,
     Tag,
+    message,
 } from 'antd';
 import {useSelector} from 'react-redux';
 import {getCurrentTeamId} from 'mattermost-webapp/packages/mattermost-redux/src/selectors/entities/teams';
@@ -18,11 +20,16 @@ import {Team} from 'mattermost-webapp/packages/types/src/teams';
 
 import Tooltip from 'src/components/commons/tooltip';
 import {OVERLAY_DELAY} from 'src/constants';
-import {getBacklinks} from 'src/clients';
+import {getBacklinks, setBacklinkStance} from 'src/clients';
 import {navigateToChannel, navigateToPost} from 'src/browser_routing';
 import {Timestamp} from 'src/webapp_globals';
 import {teamNameSelector} from 'src/selectors';
-import {Backlink, ChannelCount} from 'src/types/channels';
+import {
+    Backlink,
+    BacklinkStanceValue,
+    ChannelCount,
+    StanceCount,
+} from 'src/types/channels';
 import MarkdownEdit from 'src/components/commons/markdown_edit';
 
 type Props = {
@@ -31,10 +38,38 @@ type Props = {
 
 type BacklinkItemProps = {
     backlink: Backlink;
+    href: string;
     team: Team;
 }
 
-const BacklinkItem = ({backlink, team}: BacklinkItemProps) => {
+// Stances a post can take towards the linked element, an empty stance removes the tag
+const stanceOptions = [
+    {value: '', label: 'No stance'},
+    {value: 'supports', label: 'Supports'},
+    {value: 'contradicts', label: 'Contradicts'},
+    {value: 'questions', label: 'Questions'},
+    {value: 'misrepresents', label: 'Misrepresents'},
+];
+
+const stanceLabel = (stance: string) => {
+    return stanceOptions.find((option) => option.value === stance)?.label || stance;
+};
+
+const BacklinkItem = ({backlink, href, team}: BacklinkItemProps) => {
+    const {formatMessage} = useIntl();
+    const [stance, setStance] = useState<BacklinkStanceValue | ''>(backlink.stance);
+
+    const tagStance = async (value: BacklinkStanceValue | '') => {
+        const previous = stance;
+        setStance(value);
+        try {
+            await setBacklinkStance(backlink.id, {elementUrl: href, stance: value});
+        } catch (e) {
+            message.error(formatMessage({defaultMessage: 'Unable to tag the stance of the message.'}));
+            setStance(previous);
+        }
+    };
+
     return (
         <Card
             title={
@@ -46,12 +81,21 @@ const BacklinkItem = ({backlink, team}: BacklinkItemProps) => {
                 </>
             }
             extra={
-                <Button
-                    type={'link'}
-                    onClick={() => {
-                        navigateToPost(team.name, backlink.id);
-                    }}
-                >{'Jump'}</Button>}
+                <>
+                    <Select
+                        size='small'
+                        value={stance}
+                        options={stanceOptions}
+                        onChange={tagStance}
+                        style={{width: '140px'}}
+                    />
+                    <Button
+                        type={'link'}
+                        onClick={() => {
+                            navigateToPost(team.name, backlink.id);
+                        }}
+                    >{'Jump'}</Button>
+                </>}
             style={{width: '100%'}}
         >
             <MarkdownEdit
@@ -94,6 +138,7 @@ const BacklinksAction: FC<Props & HTMLAttributes<HTMLElement>> = ({href}: Props)
                             <BacklinkItem
                                 key={`backlink-${item.id}`}
                                 backlink={item}
+                                href={href}
                                 team={team}
                             />
                         </Space>
@@ -136,6 +181,20 @@ const BacklinksAction: FC<Props & HTMLAttributes<HTMLElement>> = ({href}: Props)
             </Space>
         );
 
+        const stancesCountList = (
+            <List
+                dataSource={backlinks.stancesCount}
+                renderItem={(item: StanceCount) => (
+                    <List.Item>
+                        <List.Item.Meta
+                            title={<b>{item.stance ? stanceLabel(item.stance) : 'Untagged'}</b>}
+                            description={`${item.count} ${item.count > 1 ? 'messages' : 'message'}`}
+                        />
+                    </List.Item>
+                )}
+            />
+        );
+
         const usersCountList = (
             <Alert
                 message={formatMessage({defaultMessage: 'Work in progress!'})}
@@ -155,6 +214,11 @@ const BacklinksAction: FC<Props & HTMLAttributes<HTMLElement>> = ({href}: Props)
                 label: 'Channels',
                 children: channelsCountList,
             },
+            {
+                key: 'Stances',
+                label: 'Stances',
+                children: stancesCountList,
+            },
             {
                 key: 'Users',
                 label: 'Users',
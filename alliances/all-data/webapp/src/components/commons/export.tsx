@@ -1,8 +1,10 @@
 import {
     Checkbox,
     CheckboxProps,
+    DatePicker,
     Modal,
     Select,
+    message,
 } from 'antd';
 import React, {useEffect, useState} from 'react';
 import {FormattedMessage} from 'react-intl';
@@ -10,11 +12,12 @@ import styled from 'styled-components';
 import {ModalBody} from 'react-bootstrap';
 import {useDispatch, useSelector} from 'react-redux';
 
-import {exportChannel, getSectionInfoUrl} from 'src/clients';
+import {getSectionInfoUrl, startExportJob} from 'src/clients';
 import {channelNameSelector, exportChannelSelector} from 'src/selectors';
 import {useIsSectionFromEcosystem, useSection, useSectionInfo} from 'src/hooks';
 import {getSectionById} from 'src/config/config';
 import {exportAction} from 'src/actions';
+import {ExportFilters} from 'src/types/channels';
 
 export type ExportReference = {
     source_name: string,
@@ -37,6 +40,7 @@ export const Exporter = ({parentId, sectionId}: Props) => {
     const exportData = useSelector(exportChannelSelector);
     const [format, setFormat] = useState('json');
     const [pinnedOnly, setPinnedOnly] = useState(false);
+    const [filters, setFilters] = useState<ExportFilters>({});
     const channel = useSelector(channelNameSelector(exportData?.channelId));
     const dispatch = useDispatch();
     const [open, setOpen] = useState(false);
@@ -68,22 +72,16 @@ export const Exporter = ({parentId, sectionId}: Props) => {
                 }),
             });
         }
-        const data = await exportChannel(channel.id, format, pinnedOnly, references);
-        const fileURL = window.URL.createObjectURL(data);
 
-        // Emulate a click on an anchor to trigger a browser download
-        const link = document.createElement('a');
-        link.href = fileURL;
-        link.download = `${channel.name}.${format}`;
-        link.click();
-        setTimeout(() => {
-            URL.revokeObjectURL(fileURL);
-        }, 0);
+        // Exports run in the background, the bot sends the file in a direct message once ready
+        await startExportJob(channel.id, format, pinnedOnly, references, filters);
+        message.info(`The export of ${channel.name} has started, you will receive it in a direct message`);
         dispatch(exportAction(''));
         setOpen(false);
     };
 
     const onCancel = () => {
+        setFilters({});
         setOpen(false);
         dispatch(exportAction(''));
     };
@@ -116,8 +114,46 @@ export const Exporter = ({parentId, sectionId}: Props) => {
                         }}
                         options={[
                             {value: 'json', label: 'JSON/STIX'},
+                            {value: 'markdown', label: 'Markdown'},
+                            {value: 'html', label: 'HTML'},
+                            {value: 'csv', label: 'CSV'},
+                            {value: 'pdf', label: 'PDF'},
                         ]}
                     />
+                    <Text>{'Only export the posts matching the filters below, if any.'}</Text>
+                    <DatePicker.RangePicker
+                        id={'export-range'}
+                        allowEmpty={[true, true]}
+                        onChange={(dates) => setFilters({
+                            ...filters,
+                            since: dates?.[0]?.startOf('day').valueOf(),
+                            until: dates?.[1]?.endOf('day').valueOf(),
+                        })}
+                    />
+                    <Select
+                        id={'export-select-authors'}
+                        mode={'tags'}
+                        placeholder={'Authors (usernames)'}
+                        open={false}
+                        onChange={(authors: string[]) => setFilters({...filters, authors})}
+                    />
+                    <Select
+                        id={'export-select-elements'}
+                        mode={'tags'}
+                        placeholder={'Linked elements (URLs)'}
+                        open={false}
+                        onChange={(elements: string[]) => setFilters({...filters, elements})}
+                    />
+                    <Select
+                        id={'export-select-reactions'}
+                        mode={'tags'}
+                        placeholder={'Reactions (emoji names)'}
+                        open={false}
+                        onChange={(reactions: string[]) => setFilters({...filters, reactions})}
+                    />
+                    <Checkbox onChange={(e) => setFilters({...filters, excludeReplies: e.target.checked})}>
+                        {'Exclude replies'}
+                    </Checkbox>
                 </Container>
             </ModalBody>
         </Modal>
@@ -128,6 +164,7 @@ const Container = styled.div`
     width: 100%;
     display: flex;
     flex-direction: column;
+    gap: 8px;
     margin-top: 24px;
 `;
 
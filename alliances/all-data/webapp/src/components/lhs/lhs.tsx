@@ -1,16 +1,9 @@
-import {
-    Button,
-    Form,
-    Input,
-    Modal,
-    Select,
-} from 'antd';
+import {Select, message} from 'antd';
 import {getCurrentUserId} from 'mattermost-webapp/packages/mattermost-redux/src/selectors/entities/common';
 import {getCurrentTeamId} from 'mattermost-webapp/packages/mattermost-redux/src/selectors/entities/teams';
 import React, {useEffect, useState} from 'react';
-import {FormattedMessage, useIntl} from 'react-intl';
+import {useIntl} from 'react-intl';
 import {useSelector} from 'react-redux';
-import {LockOutlined} from '@ant-design/icons';
 
 import styled from 'styled-components';
 
@@ -30,10 +23,6 @@ const LHSView = () => {
     const teamId = useSelector(getCurrentTeamId);
     const userId = useSelector(getCurrentUserId);
     const [userProps, setUserProps] = useUserProps();
-    const [isPasswordModalOpen, setIsPasswordModalOpen] = useState(false);
-    const [isPasswordInvalid, setIsPasswordInvalid] = useState(false);
-    const [confirmLoading, setConfirmLoading] = useState(false);
-    const [form] = Form.useForm();
 
     // Do not consider user props referring to a deleted organization
     const isUserOrgIDValid = () => {
@@ -71,49 +60,25 @@ const LHSView = () => {
             return;
         }
 
-        if (selectedObject.value === ORGANIZATION_ID_ALL) {
-            setIsPasswordModalOpen(true);
-            return;
-        }
-
         async function setUserOrganizationAsync() {
-            setUserOrganization({teamId, userId, orgId: selectedObject.value});
+            try {
+                await setUserOrganization({teamId, userId, orgId: selectedObject.value});
+            } catch (e) {
+                // Viewing all the organizations is reserved to administrators
+                const errorMessage = selectedObject.value === ORGANIZATION_ID_ALL ?
+                    formatMessage({defaultMessage: 'You need to be an administrator to view all the organizations.'}) :
+                    formatMessage({defaultMessage: 'Unable to set your organization.'});
+                message.error(errorMessage);
+                setSelectedObject(defaultSelectObject);
+                setDisabled(false);
+                return;
+            }
             setUserProps({orgId: selectedObject.value});
         }
 
         setUserOrganizationAsync();
     }, [selectedObject]);
 
-    useEffect(() => {
-        if (isPasswordInvalid) {
-            form.validateFields();
-        }
-    }, [isPasswordInvalid]);
-
-    const onFinish = async (values: FieldType) => {
-        setConfirmLoading(true);
-
-        try {
-            await setUserOrganization({teamId, userId, orgId: selectedObject.value, password: values.password});
-        } catch (e) {
-            setConfirmLoading(false);
-            setIsPasswordInvalid(true);
-            return;
-        }
-        setUserProps({orgId: selectedObject.value});
-
-        setConfirmLoading(false);
-
-        setIsPasswordModalOpen(false);
-    };
-
-    const closeModal = () => {
-        setIsPasswordModalOpen(false);
-        setSelectedObject(defaultSelectObject);
-        setDisabled(false);
-        setIsPasswordInvalid(false);
-    };
-
     if (isUserOrgIDValid() && selectedObject !== defaultSelectObject) {
         return <StyledContainer>{selectedObject.label}</StyledContainer>;
     }
@@ -123,61 +88,6 @@ const LHSView = () => {
 
     return (
         <>
-            <Modal
-                open={isPasswordModalOpen}
-                width={'20vw'}
-                title={'View all organizations'}
-                centered={true}
-                onCancel={closeModal}
-                confirmLoading={confirmLoading}
-                destroyOnClose={true}
-                footer={[]}
-            >
-                <FormattedMessage defaultMessage='You need to be an administrator to view all the organizations.'/>
-                <Form
-                    name='set_org_pw_check'
-                    form={form}
-                    onFinish={onFinish}
-                    style={{paddingTop: '15px'}}
-                    onChange={() => {
-                        if (isPasswordInvalid) {
-                            setIsPasswordInvalid(false);
-                        }
-                    }}
-                >
-                    <Form.Item
-                        name='password'
-                        rules={[
-                            {required: true, message: 'Input the administrator password.'},
-                            () => ({
-                                validator() {
-                                    if (isPasswordInvalid) {
-                                        return Promise.reject(new Error('The password is incorrect.'));
-                                    }
-                                    return Promise.resolve();
-                                },
-                            }),
-                        ]}
-                    >
-                        <Input
-                            prefix={<LockOutlined className='site-form-item-icon'/>}
-                            type='password'
-                            placeholder='Password'
-                            autoFocus={true}
-                        />
-                    </Form.Item>
-
-                    <Form.Item wrapperCol={{offset: 8, span: 16}}>
-                        <Button
-                            type='primary'
-                            htmlType='submit'
-                        >
-                            <FormattedMessage defaultMessage='Confirm'/>
-                        </Button>
-                    </Form.Item>
-                </Form>
-            </Modal>
-
             {/* Force a null value in case the selectedObject is an empty string to properly show the placeholder message */}
             <StyledSelect
                 value={selectedObject.value || null}
@@ -210,8 +120,4 @@ const StyledContainer = styled.div`
 	padding-left: 16px;
 `;
 
-type FieldType = {
-    password?: string;
-};
-
 export default LHSView;
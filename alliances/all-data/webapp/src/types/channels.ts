@@ -38,6 +38,13 @@ export interface AddChannelParams {
     organizationId: string;
 }
 
+export interface SyncIssueChannelParams {
+    teamId: string;
+    parentId: string;
+    organizationId: string;
+    createPublicChannel?: boolean;
+}
+
 export interface AddChannelResult {
     channelId: string;
     parentId: string,
@@ -52,6 +59,16 @@ export const notFoundWidgetChannel: WidgetChannel = {
     deletedAt: 0,
 };
 
+export interface RenameChannelParams {
+    displayName: string;
+}
+
+export interface MoveChannelParams {
+    parentId: string;
+    sectionId: string;
+    organizationId: string;
+}
+
 export interface ArchiveChannelsParams {
     sectionId: string;
 }
@@ -59,10 +76,12 @@ export interface ArchiveChannelsParams {
 export interface Backlink {
     id: string,
     message: string,
+    authorId: string,
     authorName: string,
     channelName: string,
     sectionName: string,
     createAt: number,
+    stance: BacklinkStanceValue | '',
 }
 
 export interface ChannelCount {
@@ -71,7 +90,141 @@ export interface ChannelCount {
     sectionName: string,
 }
 
+export interface UserCount {
+    userId: string,
+    name: string,
+    count: number,
+}
+
+export interface SectionCount {
+    name: string,
+    count: number,
+}
+
+export type BacklinkStanceValue = 'supports' | 'contradicts' | 'questions' | 'misrepresents';
+
+export interface StanceCount {
+    stance: BacklinkStanceValue | '',
+    count: number,
+}
+
+export interface BacklinkStance {
+    postId: string,
+    elementLinkPart: string,
+    stance: BacklinkStanceValue,
+    userId: string,
+    updateAt: number,
+}
+
+export interface SetBacklinkStanceParams {
+    elementUrl: string,
+    stance: BacklinkStanceValue | '',
+}
+
+export interface HistogramCount {
+    date: string,
+    count: number,
+}
+
 export interface GetBacklinksResult {
     items: Backlink[],
-    channelsCount: ChannelCount[]
+    channelsCount: ChannelCount[],
+    usersCount: UserCount[],
+    sectionsCount: SectionCount[],
+    stancesCount: StanceCount[],
+    histogram: HistogramCount[],
+}
+
+export interface ElementCount {
+    name: string,
+    link: string,
+    count: number,
+}
+
+export interface GetTopBacklinksResult {
+    elements: ElementCount[],
+    users: UserCount[],
+}
+
+export type ExportScope = 'channel' | 'section' | 'organization';
+
+export interface ExportFilters {
+    since?: number,
+    until?: number,
+    authors?: string[],
+    elements?: string[],
+    reactions?: string[],
+    excludeReplies?: boolean,
+}
+
+export interface ExportJob {
+    id: string,
+    userId: string,
+    scope: ExportScope,
+    scopeId: string,
+    format: string,
+    status: 'pending' | 'running' | 'done' | 'failed',
+    threadsDone: number,
+    threadsTotal: number,
+    fileId?: string,
+    fileName?: string,
+    downloadUrl?: string,
+    error?: string,
+    createAt: number,
+    updateAt: number,
+}
+
+export interface ExportParams extends ExportFilters {
+    format: string,
+    pinnedOnly?: boolean,
+}
+
+export interface ExportSchedule {
+    id: string,
+    name: string,
+    cron: string,
+    scope: ExportScope,
+    scopeId: string,
+    params: ExportParams,
+    sinceLastRun: boolean,
+    reportChannelId: string,
+    creatorId: string,
+    nextRunAt: number,
+    lastRunAt: number,
+    lastError: string,
+    disableAt: number,
+    createAt: number,
+}
+
+export interface AddExportScheduleParams {
+    name: string,
+    cron: string,
+    scope: ExportScope,
+    scopeId: string,
+    params: ExportParams,
+    sinceLastRun: boolean,
+    reportChannelId: string,
+}
+
+export interface GetExportSchedulesResult {
+    items: ExportSchedule[],
+}
+
+export interface ImportChannelParams {
+    channelName?: string,
+    createPublicChannel: boolean,
+    parentId: string,
+    sectionId: string,
+    teamId: string,
+    organizationId: string,
+    mapAuthors?: boolean,
+}
+
+export interface ImportChannelResult {
+    channelId: string,
+    parentId: string,
+    sectionId: string,
+    postsCount: number,
+    failedCount: number,
+    unmappedAuthors: string[],
 }
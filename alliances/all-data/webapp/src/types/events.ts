@@ -7,7 +7,6 @@ export interface SetUserOrganizationParams {
     teamId: string;
     userId: string;
     orgId: string;
-    password?: string;
 }
 
 export interface GetUserPropsParams {
@@ -3,6 +3,39 @@ import {ChartType} from 'src/components/backstage/widgets/widget_types';
 export interface PlatformConfig {
     environmentConfig: EnvironmentConfig;
     organizations: Organization[];
+    sidebarConfig?: SidebarConfig;
+    issueChannels?: IssueChannels;
+}
+
+export interface IssueChannels {
+    enabled: boolean;
+    teamName: string;
+    pollingInterval?: number;
+    createPublicChannel?: boolean;
+}
+
+export type SidebarSorting = 'alphabetical' | 'recent' | 'config';
+
+export interface SidebarConfig {
+    sorting?: SidebarSorting;
+    collapsed?: boolean;
+    sectionCategories?: SectionCategory[];
+    pinnedIssues?: PinnedIssuesCategory;
+}
+
+export interface SectionCategory {
+    id: string;
+    name: string;
+    sectionIds: string[];
+    sorting?: SidebarSorting;
+    collapsed?: boolean;
+}
+
+export interface PinnedIssuesCategory {
+    enabled: boolean;
+    name?: string;
+    sorting?: SidebarSorting;
+    collapsed?: boolean;
 }
 
 export interface EnvironmentConfig {
@@ -23,6 +56,21 @@ export interface Organization {
     name: string;
     sections: Section[];
     widgets: Widget[];
+    channelTemplate?: ChannelTemplate;
+}
+
+export interface ChannelTemplate {
+    header: string;
+    purpose: string;
+    introMessage: string;
+    members: string[];
+    roles: string[];
+    checklists: Checklist[];
+}
+
+export interface Checklist {
+    title: string;
+    items: string[];
 }
 
 export interface Section {
@@ -67,3 +115,33 @@ export interface FetchOrganizationsParams {
 export type Object = any;
 
 export const ORGANIZATION_ID_ALL = '__all';
+
+export type MembershipRole = 'member' | 'facilitator' | 'admin';
+
+export interface OrganizationMembership {
+    userId: string;
+    organizationId: string;
+    role: MembershipRole;
+    createAt: number;
+    updateAt: number;
+}
+
+export interface GetMembershipsResult {
+    items: OrganizationMembership[];
+}
+
+export interface MembershipChange {
+    channelId: string;
+    userId: string;
+    organizationId: string;
+}
+
+export interface ReconciliationReport {
+    startAt: number;
+    endAt: number;
+    dryRun: boolean;
+    channelsChecked: number;
+    added: MembershipChange[];
+    removed: MembershipChange[];
+    errors: string[];
+}